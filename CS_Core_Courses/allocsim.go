@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	osmemory "CS_Core_Courses/operating_system/memory"
+)
+
+// cmdAllocSim 用请求序列文件或随机负载比较动态分区放置策略
+func cmdAllocSim(args []string) int {
+	fs := flag.NewFlagSet("allocsim", flag.ContinueOnError)
+	total := fs.Int("size", 4096, "内存总大小")
+	seed := fs.Int64("seed", 1, "随机负载的种子（未指定文件时使用）")
+	count := fs.Int("n", 200, "随机负载的请求条数")
+	minSize := fs.Int("min", 16, "随机分配大小下限")
+	maxSize := fs.Int("max", 256, "随机分配大小上限")
+	freeRatio := fs.Float64("free", 0.4, "随机负载中释放请求的比例")
+	samples := fs.Int("samples", 10, "碎片变化表的采样点数")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "用法: %s allocsim [选项] [请求文件|-]\n\n", programName)
+		fmt.Fprintln(os.Stderr, "请求文件每行一条：\"alloc <进程> <大小>\" 或 \"free <进程>\"；不指定文件时生成随机负载")
+		fmt.Fprintln(os.Stderr, "\n选项:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	var requests []osmemory.AllocRequest
+	if fs.NArg() == 1 {
+		var input io.Reader = os.Stdin
+		if name := fs.Arg(0); name != "-" {
+			f, err := os.Open(name)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "错误:", err)
+				return exitFailure
+			}
+			defer f.Close()
+			input = f
+		}
+		var err error
+		if requests, err = osmemory.ParseWorkload(input); err != nil {
+			fmt.Fprintln(os.Stderr, "错误:", err)
+			return exitFailure
+		}
+		fmt.Printf("请求序列: %d 条，内存 %d\n\n", len(requests), *total)
+	} else {
+		requests = osmemory.RandomWorkload(osmemory.WorkloadConfig{
+			Seed: *seed, Requests: *count, MinSize: *minSize, MaxSize: *maxSize, FreeRatio: *freeRatio,
+		})
+		fmt.Printf("随机负载: 种子 %d，%d 条请求，大小 %d~%d，内存 %d\n\n", *seed, *count, *minSize, *maxSize, *total)
+	}
+
+	osmemory.PrintWorkloadReport(osmemory.RunWorkload(*total, requests), *samples)
+	return exitOK
+}
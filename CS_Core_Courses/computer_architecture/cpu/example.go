@@ -1,7 +1,22 @@
 package cpu
 
+import "CS_Core_Courses/registry"
+
+func init() {
+	registry.Register(&registry.Module{
+		Name:    "arch/cpu",
+		Section: "3.1",
+		Title:   "CPU",
+		Run:     RunAllCPUExamples,
+		Examples: []registry.Example{
+			{Name: "register", Run: RegisterExample},
+			{Name: "alu", Run: ALUExample},
+		},
+	})
+}
+
 // RunAllCPUExamples 运行所有CPU相关的示例
 func RunAllCPUExamples() {
 	RegisterExample()
 	ALUExample()
-}
\ No newline at end of file
+}
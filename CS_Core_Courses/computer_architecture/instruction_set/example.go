@@ -1,6 +1,22 @@
 package instruction_set
 
-import "fmt"
+import (
+	"fmt"
+
+	"CS_Core_Courses/registry"
+)
+
+func init() {
+	registry.Register(&registry.Module{
+		Name:    "arch/instruction_set",
+		Section: "3.3",
+		Title:   "指令系统",
+		Run:     RunAllInstructionSetExamples,
+		Examples: []registry.Example{
+			{Name: "instruction_set", Run: InstructionSetExample},
+		},
+	})
+}
 
 func RunAllInstructionSetExamples() {
 	fmt.Println("\n╔══════════════════════════════════════╗")
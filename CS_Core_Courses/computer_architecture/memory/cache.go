@@ -4,16 +4,21 @@ import (
 	"fmt"
 	"math"
 	"math/rand"
+	"strings"
+
+	"CS_Core_Courses/trace"
 )
 
 // CacheLine 表示 Cache 中的一行
 // 408 考点：Cache 行结构（有效位、标记、数据块）
 type CacheLine struct {
 	Valid      bool   // 有效位：标识该行是否存有有效数据
+	Dirty      bool   // 脏位：写回策略下该行被写过，与主存不一致，替换时需写回
 	Tag        int    // 标记：用于区分主存中映射到同一 Cache 行的不同块
 	Data       []byte // 数据块：实际存储的数据
 	AccessTime int    // 访问时间戳：用于 LRU 算法
 	LoadTime   int    // 装入时间：用于 FIFO 算法
+	Prefetched bool   // 由预取器调入且尚未被访问
 }
 
 // MappingType Cache 映射方式
@@ -34,6 +39,41 @@ const (
 	Random                          // 随机替换
 )
 
+// AccessType 访存类型
+type AccessType int
+
+const (
+	Read  AccessType = iota // 读
+	Write                   // 写
+	Fetch                   // 取指（对 Cache 而言与读相同，多级 Cache 中访问指令 Cache）
+)
+
+func (at AccessType) String() string {
+	switch at {
+	case Write:
+		return "写"
+	case Fetch:
+		return "取指"
+	}
+	return "读"
+}
+
+// WritePolicy 写命中策略
+type WritePolicy int
+
+const (
+	WriteBack    WritePolicy = iota // 写回：只写 Cache 并置脏位，替换时再写回主存
+	WriteThrough                    // 写直达（全写）：同时写 Cache 和主存
+)
+
+// WriteMissPolicy 写缺失策略
+type WriteMissPolicy int
+
+const (
+	WriteAllocate   WriteMissPolicy = iota // 写分配：先把块调入 Cache 再写
+	NoWriteAllocate                        // 非写分配：直接写主存，不调入 Cache
+)
+
 // CacheConfig Cache 配置参数
 type CacheConfig struct {
 	CacheSize     int               // Cache 总大小（字节）
@@ -41,21 +81,51 @@ type CacheConfig struct {
 	Associativity int               // 相联度（组相联时使用，直接映射为1，全相联为行数）
 	MappingType   MappingType       // 映射方式
 	Policy        ReplacementPolicy // 替换策略
+	WritePolicy   WritePolicy       // 写命中策略（默认写回）
+	WriteMiss     WriteMissPolicy   // 写缺失策略（默认写分配）
+	WordSize      int               // 写直达时每次写主存的字节数（默认 4）
+
+	Prefetcher      PrefetcherType // 硬件预取器（默认不预取）
+	PrefetchDegree  int            // 每次触发预取的块数（默认 1）
+	VictimCacheSize int            // 全相联牺牲缓存的行数（0 表示不使用）
 }
 
 // CacheSimulator Cache 模拟器
 type CacheSimulator struct {
 	Config      CacheConfig
-	Lines       []CacheLine // Cache 行数组
-	NumLines    int         // Cache 行数
-	NumSets     int         // 组数（直接映射和全相联时为特殊值）
-	BlockOffset int         // 块内地址位数
-	IndexBits   int         // 索引位数
-	TagBits     int         // 标记位数
-	Hits        int         // 命中次数
-	Misses      int         // 缺失次数
-	AccessCount int         // 总访问次数
-	CurrentTime int         // 当前时间（用于 LRU 和 FIFO）
+	Lines       []CacheLine  // Cache 行数组
+	NumLines    int          // Cache 行数
+	NumSets     int          // 组数（直接映射和全相联时为特殊值）
+	BlockOffset int          // 块内地址位数
+	IndexBits   int          // 索引位数
+	TagBits     int          // 标记位数
+	Hits        int          // 命中次数
+	Misses      int          // 缺失次数
+	AccessCount int          // 总访问次数
+	Reads       int          // 读访问次数
+	Writes      int          // 写访问次数
+	ReadHits    int          // 读命中次数
+	WriteHits   int          // 写命中次数
+	BlockLoads  int          // 从主存调入的块数
+	Writebacks  int          // 替换（或 Flush）时写回主存的脏块数
+	WordWrites  int          // 直接写主存的次数（写直达、非写分配的写缺失）
+	CurrentTime int          // 当前时间（用于 LRU 和 FIFO）
+	Tracer      trace.Tracer // 事件输出（nil 时使用默认控制台）
+
+	PrefetchIssued  int // 发出的预取次数（调入的块也计入 BlockLoads）
+	PrefetchUseful  int // 预取的块在被替换前被访问到
+	PrefetchUseless int // 预取的块未被访问就被替换
+	VictimHits      int // 主 Cache 缺失但在牺牲缓存中命中的次数（计入 Hits）
+
+	pc          int           // 当前访问指令的地址（步长预取器按 PC 区分访问流）
+	prefetchHit bool          // 本次访问命中了一个预取的块
+	strideTable []strideEntry // 步长预取器的参考预测表
+	streams     []streamEntry // 流预取器跟踪的访问流
+	victims     []victimEntry // 牺牲缓存
+
+	// Evicted 有效块被替换出 Cache 时回调（参数为块首地址和是否为脏块），
+	// 供多级 Cache 等上层结构把被替换的块写回或移入下一级
+	Evicted func(blockAddress int, dirty bool)
 }
 
 // NewCacheSimulator 创建 Cache 模拟器
@@ -129,13 +199,46 @@ func (cs *CacheSimulator) ParseAddress(address int) (tag, index, offset int) {
 	return
 }
 
-// Access 访问 Cache
+// Access 读 Cache
 // 408 考点：Cache 访问过程（查找、命中/缺失判断、替换）
 func (cs *CacheSimulator) Access(address int) (hit bool, message string) {
+	return cs.AccessWithType(address, Read)
+}
+
+// Write 写 Cache
+func (cs *CacheSimulator) Write(address int) (hit bool, message string) {
+	return cs.AccessWithType(address, Write)
+}
+
+// AccessWithType 按访存类型访问 Cache
+// 408 考点：
+//   - 写命中：写回法只写 Cache 并置脏位；写直达法同时写主存
+//   - 写缺失：写分配法先调块再写；非写分配法直接写主存
+//   - 替换脏块时必须先把它写回主存
+func (cs *CacheSimulator) AccessWithType(address int, kind AccessType) (hit bool, message string) {
+	hit, message = cs.access(address, kind)
+	if cs.Config.Prefetcher != NoPrefetch {
+		cs.trainPrefetcher(address, hit)
+	}
+	return hit, message
+}
+
+// access 处理一次需求访问（不含预取）
+func (cs *CacheSimulator) access(address int, kind AccessType) (hit bool, message string) {
 	cs.AccessCount++
+	cs.prefetchHit = false
 	cs.CurrentTime++
+	if kind == Write {
+		cs.Writes++
+	} else {
+		cs.Reads++
+	}
 
 	tag, index, offset := cs.ParseAddress(address)
+	op := ""
+	if kind == Write {
+		op = "写"
+	}
 
 	// 确定搜索范围
 	startLine := index * cs.Config.Associativity
@@ -147,26 +250,238 @@ func (cs *CacheSimulator) Access(address int) (hit bool, message string) {
 			// Cache 命中
 			cs.Hits++
 			cs.Lines[i].AccessTime = cs.CurrentTime
-			message = fmt.Sprintf("命中 - 地址: 0x%X, Tag: %d, Index: %d, Offset: %d",
-				address, tag, index, offset)
+			if cs.Lines[i].Prefetched {
+				cs.Lines[i].Prefetched = false
+				cs.PrefetchUseful++
+				cs.prefetchHit = true
+			}
+			if kind == Write {
+				cs.WriteHits++
+				cs.writeLine(i)
+			} else {
+				cs.ReadHits++
+			}
+			message = fmt.Sprintf("%s命中 - 地址: 0x%X, Tag: %d, Index: %d, Offset: %d",
+				op, address, tag, index, offset)
+			cs.emit("hit", kind, address, tag, index, offset, i, false)
+			return true, message
+		}
+	}
+
+	// 主 Cache 缺失，先查牺牲缓存
+	if cs.Config.VictimCacheSize > 0 {
+		if line, ok := cs.swapFromVictim(address, startLine, endLine); ok {
+			cs.Hits++
+			cs.VictimHits++
+			if kind == Write {
+				cs.WriteHits++
+				cs.writeLine(line)
+			} else {
+				cs.ReadHits++
+			}
+			message = fmt.Sprintf("%s命中牺牲缓存 - 地址: 0x%X, Tag: %d, Index: %d, Offset: %d, 换回行: %d",
+				op, address, tag, index, offset, line)
+			cs.emit("victim_hit", kind, address, tag, index, offset, line, false)
 			return true, message
 		}
 	}
 
-	// Cache 缺失，需要替换
+	// Cache 缺失
 	cs.Misses++
-	victimLine := cs.selectVictim(startLine, endLine)
 
-	cs.Lines[victimLine].Valid = true
-	cs.Lines[victimLine].Tag = tag
-	cs.Lines[victimLine].AccessTime = cs.CurrentTime
-	cs.Lines[victimLine].LoadTime = cs.CurrentTime
+	// 非写分配：直接写主存，Cache 不变
+	if kind == Write && cs.Config.WriteMiss == NoWriteAllocate {
+		cs.WordWrites++
+		message = fmt.Sprintf("写缺失 - 地址: 0x%X, Tag: %d, Index: %d, Offset: %d, 直接写主存（非写分配）",
+			address, tag, index, offset)
+		cs.emit("miss", kind, address, tag, index, offset, -1, false)
+		return false, message
+	}
 
-	message = fmt.Sprintf("缺失 - 地址: 0x%X, Tag: %d, Index: %d, Offset: %d, 替换行: %d",
-		address, tag, index, offset, victimLine)
+	// 需要调入块并替换
+	victimLine := cs.selectVictim(startLine, endLine)
+	writeback := cs.replace(victimLine, tag)
+	cs.BlockLoads++
+	if kind == Write {
+		cs.writeLine(victimLine)
+	}
+
+	message = fmt.Sprintf("%s缺失 - 地址: 0x%X, Tag: %d, Index: %d, Offset: %d, 替换行: %d",
+		op, address, tag, index, offset, victimLine)
+	if writeback {
+		message += "（脏块写回主存）"
+	}
+	cs.emit("miss", kind, address, tag, index, offset, victimLine, writeback)
 	return false, message
 }
 
+// replace 把块装入指定行，返回是否有脏块写回主存
+// 使用牺牲缓存时，被替换的块先移入牺牲缓存，从牺牲缓存淘汰时才写回
+func (cs *CacheSimulator) replace(line, tag int) (writeback bool) {
+	old := cs.Lines[line]
+
+	cs.Lines[line].Valid = true
+	cs.Lines[line].Dirty = false
+	cs.Lines[line].Tag = tag
+	cs.Lines[line].AccessTime = cs.CurrentTime
+	cs.Lines[line].LoadTime = cs.CurrentTime
+	cs.Lines[line].Prefetched = false
+
+	if !old.Valid {
+		return false
+	}
+	if old.Prefetched {
+		cs.PrefetchUseless++
+	}
+	block := cs.blockAddress(line, old.Tag)
+	if cs.Config.VictimCacheSize > 0 {
+		return cs.pushVictim(block, old.Dirty)
+	}
+	return cs.evict(block, old.Dirty)
+}
+
+// evict 块离开 Cache：脏块写回主存，并通知上层结构
+func (cs *CacheSimulator) evict(block int, dirty bool) (writeback bool) {
+	if dirty {
+		cs.Writebacks++
+	}
+	if cs.Evicted != nil {
+		cs.Evicted(block, dirty)
+	}
+	return dirty
+}
+
+// blockAddress 由行号和标记还原块首地址
+func (cs *CacheSimulator) blockAddress(line, tag int) int {
+	index := line / cs.Config.Associativity
+	return (tag<<cs.IndexBits | index) << cs.BlockOffset
+}
+
+// findLine 查找地址所在的行，不在 Cache 中时返回 -1（不计入统计）
+func (cs *CacheSimulator) findLine(address int) int {
+	tag, index, _ := cs.ParseAddress(address)
+	start := index * cs.Config.Associativity
+	for i := start; i < start+cs.Config.Associativity; i++ {
+		if cs.Lines[i].Valid && cs.Lines[i].Tag == tag {
+			return i
+		}
+	}
+	return -1
+}
+
+// Contains 地址所在的块是否在 Cache 中（含牺牲缓存，只查看，不计入访问统计）
+func (cs *CacheSimulator) Contains(address int) bool {
+	return cs.findLine(address) >= 0 || cs.findVictim(address) >= 0
+}
+
+// Invalidate 使地址所在的块失效，返回块是否存在以及是否为脏块
+// 失效不是替换，不会触发 Evicted 回调，也不计入写回次数
+func (cs *CacheSimulator) Invalidate(address int) (found, dirty bool) {
+	line := cs.findLine(address)
+	if line < 0 {
+		if i := cs.findVictim(address); i >= 0 {
+			dirty = cs.victims[i].dirty
+			cs.removeVictim(i)
+			return true, dirty
+		}
+		return false, false
+	}
+	dirty = cs.Lines[line].Dirty
+	cs.Lines[line].Valid = false
+	cs.Lines[line].Dirty = false
+	return true, dirty
+}
+
+// Fill 由其他层次把块放入 Cache（如下一级调块、上一级写回或牺牲块下移），不计入访问统计
+// 块已存在时只更新访问时间，dirty 为 true 时置脏位
+func (cs *CacheSimulator) Fill(address int, dirty bool) {
+	cs.CurrentTime++
+	line := cs.findLine(address)
+	if line < 0 {
+		tag, index, _ := cs.ParseAddress(address)
+		start := index * cs.Config.Associativity
+		if victimLine, ok := cs.swapFromVictim(address, start, start+cs.Config.Associativity); ok {
+			line = victimLine
+		} else {
+			line = cs.selectVictim(start, start+cs.Config.Associativity)
+			cs.replace(line, tag)
+		}
+	}
+	cs.Lines[line].AccessTime = cs.CurrentTime
+	if dirty {
+		cs.Lines[line].Dirty = true
+	}
+}
+
+// writeLine 写入 Cache 行：写回法置脏位，写直达法同时写主存
+func (cs *CacheSimulator) writeLine(line int) {
+	if cs.Config.WritePolicy == WriteThrough {
+		cs.WordWrites++
+		return
+	}
+	cs.Lines[line].Dirty = true
+}
+
+// Flush 把所有脏块写回主存并清除脏位，返回写回的块数
+// 计算一段程序结束时的总写流量时使用
+func (cs *CacheSimulator) Flush() int {
+	flushed := 0
+	for i := range cs.Lines {
+		if cs.Lines[i].Valid && cs.Lines[i].Dirty {
+			cs.Lines[i].Dirty = false
+			flushed++
+		}
+	}
+	for i := range cs.victims {
+		if cs.victims[i].dirty {
+			cs.victims[i].dirty = false
+			flushed++
+		}
+	}
+	cs.Writebacks += flushed
+	return flushed
+}
+
+// MemoryReadBytes 从主存读入的字节数
+func (cs *CacheSimulator) MemoryReadBytes() int {
+	return cs.BlockLoads * cs.Config.BlockSize
+}
+
+// MemoryWriteBytes 写入主存的字节数（写回的块 + 直接写主存的字）
+func (cs *CacheSimulator) MemoryWriteBytes() int {
+	return cs.Writebacks*cs.Config.BlockSize + cs.WordWrites*cs.wordSize()
+}
+
+func (cs *CacheSimulator) wordSize() int {
+	if cs.Config.WordSize > 0 {
+		return cs.Config.WordSize
+	}
+	return 4
+}
+
+// emit 发送 Cache 访问事件
+// Access 通过返回值把描述交给调用方打印，因此事件本身不带控制台消息
+func (cs *CacheSimulator) emit(kind string, access AccessType, address, tag, index, offset, line int, writeback bool) {
+	op := "read"
+	if access == Write {
+		op = "write"
+	}
+	trace.Emit(cs.Tracer, trace.Event{
+		Cycle:     cs.CurrentTime,
+		Component: "cache",
+		Kind:      kind,
+		Fields: map[string]any{
+			"op":        op,
+			"address":   address,
+			"tag":       tag,
+			"index":     index,
+			"offset":    offset,
+			"line":      line,
+			"writeback": writeback,
+		},
+	})
+}
+
 // selectVictim 选择被替换的 Cache 行
 // 408 考点：三种替换算法的实现
 func (cs *CacheSimulator) selectVictim(start, end int) int {
@@ -221,16 +536,23 @@ func (cs *CacheSimulator) GetStatistics() string {
 		hitRate = float64(cs.Hits) / float64(cs.AccessCount) * 100
 	}
 
-	return fmt.Sprintf(`
+	stats := fmt.Sprintf(`
 Cache 统计信息：
-  总访问次数: %d
-  命中次数:   %d
+  总访问次数: %d（读 %d，写 %d）
+  命中次数:   %d（读 %d，写 %d）
   缺失次数:   %d
   命中率:     %.2f%%
   
+主存流量：
+  调入块数:   %d（%d 字节）
+  写回块数:   %d
+  直接写主存: %d 次
+  写主存总量: %d 字节
+  
 Cache 配置：
   映射方式:   %s
   替换策略:   %s
+  写策略:     %s
   Cache大小:  %d 字节
   块大小:     %d 字节
   行数:       %d
@@ -242,12 +564,17 @@ Cache 配置：
   索引位数:   %d 位
   块内偏移:   %d 位
 `,
-		cs.AccessCount, cs.Hits, cs.Misses, hitRate,
+		cs.AccessCount, cs.Reads, cs.Writes,
+		cs.Hits, cs.ReadHits, cs.WriteHits, cs.Misses, hitRate,
+		cs.BlockLoads, cs.MemoryReadBytes(),
+		cs.Writebacks, cs.WordWrites, cs.MemoryWriteBytes(),
 		getMappingTypeName(cs.Config.MappingType),
 		getPolicyName(cs.Config.Policy),
+		getWritePolicyName(cs.Config),
 		cs.Config.CacheSize, cs.Config.BlockSize,
 		cs.NumLines, cs.NumSets, cs.Config.Associativity,
 		cs.TagBits, cs.IndexBits, cs.BlockOffset)
+	return stats + cs.prefetchStatistics()
 }
 
 func getMappingTypeName(mt MappingType) string {
@@ -263,6 +590,18 @@ func getMappingTypeName(mt MappingType) string {
 	}
 }
 
+func getWritePolicyName(config CacheConfig) string {
+	hit := "写回"
+	if config.WritePolicy == WriteThrough {
+		hit = "写直达"
+	}
+	miss := "写分配"
+	if config.WriteMiss == NoWriteAllocate {
+		miss = "非写分配"
+	}
+	return hit + " + " + miss
+}
+
 func getPolicyName(policy ReplacementPolicy) string {
 	switch policy {
 	case LRU:
@@ -343,6 +682,65 @@ func CacheExample() {
 	fmt.Println("\n" + cache408Summary())
 }
 
+// MemoryAccess 一次带类型的访存
+type MemoryAccess struct {
+	Type    AccessType
+	Address int
+}
+
+// CacheWritePolicyExample 写策略示例程序
+// 408 考点：写回/写直达与写分配/非写分配组合下的主存写流量
+func CacheWritePolicyExample() {
+	fmt.Println("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Println("  Cache 写策略与脏块")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	// 直接映射，4 行 × 64 字节；块 0 与块 4、块 1 与块 5 映射到同一行
+	accesses := []MemoryAccess{
+		{Write, 0x000}, // 块 0
+		{Read, 0x040},  // 块 1
+		{Write, 0x004}, // 块 0
+		{Write, 0x100}, // 块 4，与块 0 冲突
+		{Read, 0x000},  // 块 0
+		{Write, 0x044}, // 块 1
+		{Read, 0x140},  // 块 5，与块 1 冲突
+		{Write, 0x048}, // 块 1
+	}
+	base := CacheConfig{CacheSize: 256, BlockSize: 64, MappingType: DirectMapped, Policy: LRU}
+
+	fmt.Println("\n【写回 + 写分配：逐次访问】")
+	simulator := NewCacheSimulator(base)
+	for i, a := range accesses {
+		_, msg := simulator.AccessWithType(a.Address, a.Type)
+		fmt.Printf("  访问 %d (%s): %s\n", i+1, a.Type, msg)
+	}
+	fmt.Printf("  程序结束，Flush 写回 %d 个脏块\n", simulator.Flush())
+	fmt.Println(simulator.GetStatistics())
+
+	fmt.Println("【四种写策略组合对比（结束时 Flush 脏块）】")
+	fmt.Println("  写策略                命中率  调入块  写回块  直接写  写主存字节")
+	for _, wp := range []WritePolicy{WriteBack, WriteThrough} {
+		for _, wm := range []WriteMissPolicy{WriteAllocate, NoWriteAllocate} {
+			config := base
+			config.WritePolicy = wp
+			config.WriteMiss = wm
+			sim := NewCacheSimulator(config)
+			for _, a := range accesses {
+				sim.AccessWithType(a.Address, a.Type)
+			}
+			sim.Flush()
+			name := getWritePolicyName(config)
+			// 中文字符占两列，按显示宽度补齐
+			pad := 20 - len([]rune(name)) - (len(name)-len([]rune(name)))/2
+			fmt.Printf("  %s%s %6.2f%%  %6d  %6d  %6d  %10d\n",
+				name, strings.Repeat(" ", pad),
+				float64(sim.Hits)/float64(sim.AccessCount)*100,
+				sim.BlockLoads, sim.Writebacks, sim.WordWrites, sim.MemoryWriteBytes())
+		}
+	}
+	fmt.Println("\n说明：写回法把多次写合并为一次块写回；写直达法每次写都访问主存，但替换时无需写回")
+}
+
 func runCacheTest(config CacheConfig, addresses []int) {
 	simulator := NewCacheSimulator(config)
 
@@ -0,0 +1,553 @@
+package memory
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"CS_Core_Courses/trace"
+)
+
+// CoherenceState Cache 行的一致性状态
+type CoherenceState int
+
+const (
+	StateInvalid   CoherenceState = iota // I：无效
+	StateShared                          // S：共享，与主存一致，其他 Cache 可能也有副本
+	StateExclusive                       // E：独占，与主存一致，只有本 Cache 有副本
+	StateOwned                           // O：拥有（仅 MOESI），块已修改且可能被共享，由本 Cache 负责写回
+	StateModified                        // M：已修改，只有本 Cache 有副本，与主存不一致
+)
+
+func (s CoherenceState) String() string {
+	return [...]string{"I", "S", "E", "O", "M"}[s]
+}
+
+// dirty 该状态的块是否与主存不一致（替换时需要写回）
+func (s CoherenceState) dirty() bool {
+	return s == StateModified || s == StateOwned
+}
+
+// CoherenceProtocol 一致性协议
+type CoherenceProtocol int
+
+const (
+	ProtocolMESI  CoherenceProtocol = iota // MESI（Illinois 协议）
+	ProtocolMOESI                          // MOESI：增加 O 状态，脏块被读共享时不必写回主存
+)
+
+func (p CoherenceProtocol) String() string {
+	if p == ProtocolMOESI {
+		return "MOESI"
+	}
+	return "MESI"
+}
+
+// BusTransaction 总线事务
+type BusTransaction int
+
+const (
+	BusNone  BusTransaction = iota // 无总线事务（命中）
+	BusRd                          // 读缺失：请求块的共享副本
+	BusRdX                         // 写缺失：请求块的独占副本，其他副本失效
+	BusUpgr                        // 写命中共享块：只使其他副本失效，不传数据
+	BusFlush                       // 把脏块放到总线上（提供给请求者或写回主存）
+)
+
+func (b BusTransaction) String() string {
+	return [...]string{"-", "BusRd", "BusRdX", "BusUpgr", "Flush"}[b]
+}
+
+// CoreCache 一个处理器核的私有 Cache
+// 块的存放与替换沿用 CacheSimulator，States 与 Cache.Lines 一一对应
+type CoreCache struct {
+	ID     int
+	Cache  *CacheSimulator
+	States []CoherenceState
+}
+
+// state 返回地址所在块在本 Cache 中的状态和行号
+func (cc *CoreCache) state(address int) (CoherenceState, int) {
+	line := cc.Cache.findLine(address)
+	if line < 0 {
+		return StateInvalid, -1
+	}
+	return cc.States[line], line
+}
+
+// CoreAccess 多核访问序列中的一次访问
+type CoreAccess struct {
+	Core    int
+	Type    AccessType
+	Address int
+}
+
+// CoherenceStep 一次访问的处理结果
+type CoherenceStep struct {
+	Step    int
+	Core    int
+	Type    AccessType
+	Address int
+	Hit     bool
+	Bus     BusTransaction   // 请求者发出的总线事务
+	Source  string           // 数据来源：Cache 命中、主存或提供数据的核
+	States  []CoherenceState // 访问后该块在各核中的状态
+	Actions []string         // 其他核的监听动作和替换写回
+}
+
+// CoherenceTransition 一次状态转换
+type CoherenceTransition struct {
+	Step  int
+	Core  int
+	Block int            // 块首地址
+	From  CoherenceState // 原状态
+	To    CoherenceState // 新状态
+	Event string         // 触发事件：PrRd、PrWr（本核请求）、BusRd/BusRdX/BusUpgr（监听到的事务）、Replace（替换）
+}
+
+// MulticoreSimulator 基于监听总线的多核 Cache 一致性模拟器
+// 408 考点：
+//   - 多核各有私有 Cache，同一块可能有多个副本，写操作必须使其他副本失效（写无效协议）
+//   - 所有 Cache 监听总线，根据其他核的总线事务修改自己块的状态
+//   - MESI 中 M 块被其他核读取时要写回主存并变为 S；MOESI 中变为 O，由其提供数据、推迟写回
+type MulticoreSimulator struct {
+	Protocol      CoherenceProtocol
+	Cores         []*CoreCache
+	StepCount     int
+	BusCounts     map[BusTransaction]int // 各类总线事务次数
+	MemoryReads   int                    // 从主存读块的次数
+	MemoryWrites  int                    // 写回主存的次数
+	CacheToCache  int                    // 由其他 Cache 提供数据的次数
+	Invalidations int                    // 因监听而失效的副本数
+	Steps         []CoherenceStep
+	Transitions   []CoherenceTransition
+	Tracer        trace.Tracer // 事件输出（nil 时使用默认控制台）
+}
+
+// NewMulticoreSimulator 创建多核模拟器，每个核的私有 Cache 使用相同配置
+func NewMulticoreSimulator(numCores int, config CacheConfig, protocol CoherenceProtocol) *MulticoreSimulator {
+	s := &MulticoreSimulator{
+		Protocol:  protocol,
+		BusCounts: make(map[BusTransaction]int),
+	}
+	for i := 0; i < numCores; i++ {
+		cache := NewCacheSimulator(config)
+		s.Cores = append(s.Cores, &CoreCache{
+			ID:     i,
+			Cache:  cache,
+			States: make([]CoherenceState, cache.NumLines),
+		})
+	}
+	return s
+}
+
+// blockOf 返回地址所在块的首地址
+func (s *MulticoreSimulator) blockOf(address int) int {
+	return address &^ (s.Cores[0].Cache.Config.BlockSize - 1)
+}
+
+// setState 修改一个核中某行的状态并记录转换
+func (s *MulticoreSimulator) setState(cc *CoreCache, line, block int, to CoherenceState, event string) {
+	from := cc.States[line]
+	if !cc.Cache.Lines[line].Valid {
+		from = StateInvalid
+	}
+	cc.States[line] = to
+	cc.Cache.Lines[line].Dirty = to.dirty()
+	if to == StateInvalid {
+		cc.Cache.Lines[line].Valid = false
+	}
+	if from == to {
+		return
+	}
+
+	s.Transitions = append(s.Transitions, CoherenceTransition{
+		Step: s.StepCount, Core: cc.ID, Block: block, From: from, To: to, Event: event,
+	})
+	trace.Emit(s.Tracer, trace.Event{
+		Cycle:     s.StepCount,
+		Component: "coherence",
+		Kind:      "transition",
+		Fields: map[string]any{
+			"core":  cc.ID,
+			"block": block,
+			"from":  from.String(),
+			"to":    to.String(),
+			"event": event,
+		},
+	})
+}
+
+// bus 在总线上发出一次事务
+func (s *MulticoreSimulator) bus(core int, tx BusTransaction, block int) {
+	s.BusCounts[tx]++
+	trace.Emit(s.Tracer, trace.Event{
+		Cycle:     s.StepCount,
+		Component: "coherence",
+		Kind:      "bus",
+		Fields: map[string]any{
+			"core":        core,
+			"transaction": tx.String(),
+			"block":       block,
+		},
+	})
+}
+
+// snoop 其他核监听总线事务，返回提供数据的核（-1 表示由主存提供）和是否还有其他副本
+func (s *MulticoreSimulator) snoop(requester int, tx BusTransaction, block int, step *CoherenceStep) (supplier int, shared bool) {
+	supplier = -1
+	for _, cc := range s.Cores {
+		if cc.ID == requester {
+			continue
+		}
+		st, line := cc.state(block)
+		if st == StateInvalid {
+			continue
+		}
+
+		next := StateInvalid
+		switch tx {
+		case BusRd:
+			shared = true
+			switch st {
+			case StateModified:
+				if s.Protocol == ProtocolMOESI {
+					next = StateOwned
+				} else {
+					next = StateShared
+				}
+			case StateOwned:
+				next = StateOwned
+			default:
+				next = StateShared
+			}
+		case BusRdX, BusUpgr:
+			s.Invalidations++
+		}
+
+		// 脏块由本核提供数据
+		if st.dirty() && tx != BusUpgr {
+			supplier = cc.ID
+			s.bus(cc.ID, BusFlush, block)
+			action := fmt.Sprintf("P%d Flush", cc.ID)
+			// MESI 中 Flush 的数据同时写回主存；MOESI 中由 O 状态继续负责，或随 BusRdX 交给请求者
+			if s.Protocol == ProtocolMESI {
+				s.MemoryWrites++
+				action += "(写回主存)"
+			}
+			step.Actions = append(step.Actions, action)
+		}
+		if next != st {
+			step.Actions = append(step.Actions, fmt.Sprintf("P%d %s→%s", cc.ID, st, next))
+		}
+		s.setState(cc, line, block, next, tx.String())
+	}
+	return supplier, shared
+}
+
+// allocate 在请求核的 Cache 中为块分配一行，必要时替换，被替换的脏块写回主存
+func (s *MulticoreSimulator) allocate(cc *CoreCache, block int, step *CoherenceStep) int {
+	cs := cc.Cache
+	tag, index, _ := cs.ParseAddress(block)
+	start := index * cs.Config.Associativity
+	line := cs.selectVictim(start, start+cs.Config.Associativity)
+
+	if old := cc.States[line]; cs.Lines[line].Valid && old != StateInvalid {
+		victim := cs.blockAddress(line, cs.Lines[line].Tag)
+		action := fmt.Sprintf("P%d 替换 0x%X(%s)", cc.ID, victim, old)
+		if old.dirty() {
+			s.bus(cc.ID, BusFlush, victim)
+			s.MemoryWrites++
+			cs.Writebacks++
+			action += " 写回"
+		}
+		step.Actions = append(step.Actions, action)
+		s.setState(cc, line, victim, StateInvalid, "Replace")
+	}
+
+	cs.replace(line, tag)
+	cs.BlockLoads++
+	return line
+}
+
+// Access 核 core 访问一个地址；核编号超出范围时返回错误
+func (s *MulticoreSimulator) Access(core int, kind AccessType, address int) (CoherenceStep, error) {
+	if core < 0 || core >= len(s.Cores) {
+		return CoherenceStep{}, fmt.Errorf("核 P%d 不存在（共 %d 个核）", core, len(s.Cores))
+	}
+	s.StepCount++
+	cc := s.Cores[core]
+	cs := cc.Cache
+	cs.AccessCount++
+	cs.CurrentTime++
+	if kind == Write {
+		cs.Writes++
+	} else {
+		cs.Reads++
+	}
+
+	block := s.blockOf(address)
+	st, line := cc.state(address)
+	step := CoherenceStep{Step: s.StepCount, Core: core, Type: kind, Address: address, Source: "Cache"}
+
+	event := "PrRd"
+	if kind == Write {
+		event = "PrWr"
+	}
+
+	switch {
+	case st != StateInvalid && (kind != Write || st == StateModified || st == StateExclusive):
+		// 读命中，或写命中 M/E：不需要总线事务，E 静默变为 M
+		step.Hit = true
+		if kind == Write {
+			s.setState(cc, line, block, StateModified, event)
+		}
+	case st != StateInvalid:
+		// 写命中 S/O：BusUpgr 使其他副本失效
+		step.Hit = true
+		step.Bus = BusUpgr
+		s.bus(core, BusUpgr, block)
+		s.snoop(core, BusUpgr, block, &step)
+		s.setState(cc, line, block, StateModified, event)
+	default:
+		// 缺失：读发 BusRd，写发 BusRdX
+		step.Bus = BusRd
+		if kind == Write {
+			step.Bus = BusRdX
+		}
+		s.bus(core, step.Bus, block)
+		supplier, shared := s.snoop(core, step.Bus, block, &step)
+		if supplier >= 0 {
+			s.CacheToCache++
+			step.Source = fmt.Sprintf("P%d", supplier)
+		} else {
+			s.MemoryReads++
+			step.Source = "主存"
+		}
+
+		line = s.allocate(cc, block, &step)
+		next := StateModified
+		if kind != Write {
+			next = StateExclusive
+			if shared {
+				next = StateShared
+			}
+		}
+		s.setState(cc, line, block, next, event)
+	}
+
+	if step.Hit {
+		cs.Hits++
+		if kind == Write {
+			cs.WriteHits++
+		} else {
+			cs.ReadHits++
+		}
+	} else {
+		cs.Misses++
+	}
+	cs.Lines[line].AccessTime = cs.CurrentTime
+
+	for _, other := range s.Cores {
+		st, _ := other.state(block)
+		step.States = append(step.States, st)
+	}
+	s.Steps = append(s.Steps, step)
+	return step, nil
+}
+
+// Run 依次执行访问序列，遇到无效的访问时停止并返回错误
+func (s *MulticoreSimulator) Run(accesses []CoreAccess) error {
+	for i, a := range accesses {
+		if _, err := s.Access(a.Core, a.Type, a.Address); err != nil {
+			return fmt.Errorf("第 %d 次访问: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// InterleaveTraces 把每个核各自的访问序列按轮转方式交织成一个全局序列
+func InterleaveTraces(perCore [][]MemoryAccess) []CoreAccess {
+	var accesses []CoreAccess
+	for i := 0; ; i++ {
+		added := false
+		for core, seq := range perCore {
+			if i < len(seq) {
+				accesses = append(accesses, CoreAccess{Core: core, Type: seq[i].Type, Address: seq[i].Address})
+				added = true
+			}
+		}
+		if !added {
+			return accesses
+		}
+	}
+}
+
+// ParseCoreTrace 读取多核访问序列，每行为 "<核> <类型> <十六进制地址> [字节数]"
+// 核写作 P0 或 0，类型与地址格式同 Dinero 轨迹（r/w/i 或 0/1/2），# 开头为注释
+// 核编号是否超出模拟器的核数由 Run 检查
+func ParseCoreTrace(r io.Reader) ([]CoreAccess, error) {
+	var accesses []CoreAccess
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		core, err := strconv.Atoi(strings.TrimLeft(fields[0], "Pp"))
+		if err != nil || core < 0 {
+			return nil, fmt.Errorf("第 %d 行: 无效的核编号 %q", lineNo, fields[0])
+		}
+		records, err := parseDinero(fields[1:], nil)
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 行: %w", lineNo, err)
+		}
+		accesses = append(accesses, CoreAccess{Core: core, Type: records[0].Type, Address: records[0].Address})
+	}
+	return accesses, scanner.Err()
+}
+
+// PrintSteps 打印每次访问后各核中该块的状态
+func (s *MulticoreSimulator) PrintSteps() {
+	fmt.Printf("\n%s 协议执行过程：\n", s.Protocol)
+	header := "步骤  核  操作  地址     结果  总线事务  数据来源"
+	for i := range s.Cores {
+		header += fmt.Sprintf("  P%d", i)
+	}
+	fmt.Println(header + "  监听动作")
+	for _, st := range s.Steps {
+		result := "缺失"
+		if st.Hit {
+			result = "命中"
+		}
+		source := st.Source
+		row := fmt.Sprintf("%4d  P%d  %s  0x%-5X  %s  %-8s  %s%s",
+			st.Step, st.Core, padCJK(st.Type.String(), 4), st.Address, result, st.Bus,
+			source, strings.Repeat(" ", 8-displayWidth(source)))
+		for _, state := range st.States {
+			row += fmt.Sprintf("  %2s", state)
+		}
+		fmt.Println(row + "  " + strings.Join(st.Actions, "，"))
+	}
+}
+
+// LineHistory 返回某个块的全部状态转换
+func (s *MulticoreSimulator) LineHistory(address int) []CoherenceTransition {
+	block := s.blockOf(address)
+	var history []CoherenceTransition
+	for _, t := range s.Transitions {
+		if t.Block == block {
+			history = append(history, t)
+		}
+	}
+	return history
+}
+
+// PrintLineHistory 打印某个块的状态转换日志
+func (s *MulticoreSimulator) PrintLineHistory(address int) {
+	fmt.Printf("\n块 0x%X 的状态转换：\n", s.blockOf(address))
+	for _, t := range s.LineHistory(address) {
+		fmt.Printf("  步骤 %2d  P%d  %s → %s  (%s)\n", t.Step, t.Core, t.From, t.To, t.Event)
+	}
+}
+
+// GetStatistics 获取一致性统计
+func (s *MulticoreSimulator) GetStatistics() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("\n%s 一致性统计：\n", s.Protocol))
+	for _, cc := range s.Cores {
+		cs := cc.Cache
+		hitRate := 0.0
+		if cs.AccessCount > 0 {
+			hitRate = float64(cs.Hits) / float64(cs.AccessCount) * 100
+		}
+		sb.WriteString(fmt.Sprintf("  P%d: 访问 %d 次，命中 %d 次，命中率 %.2f%%\n", cc.ID, cs.AccessCount, cs.Hits, hitRate))
+	}
+	sb.WriteString("  总线事务: ")
+	var counts []string
+	for _, tx := range []BusTransaction{BusRd, BusRdX, BusUpgr, BusFlush} {
+		counts = append(counts, fmt.Sprintf("%s %d", tx, s.BusCounts[tx]))
+	}
+	sb.WriteString(strings.Join(counts, "，") + "\n")
+	sb.WriteString(fmt.Sprintf("  主存读块: %d，写回主存: %d，Cache 间传送: %d，副本失效: %d\n",
+		s.MemoryReads, s.MemoryWrites, s.CacheToCache, s.Invalidations))
+	return sb.String()
+}
+
+// displayWidth 字符串的显示宽度（中文字符占两列）
+func displayWidth(s string) int {
+	return len([]rune(s)) + (len(s)-len([]rune(s)))/2
+}
+
+// padCJK 按显示宽度在右侧补空格
+func padCJK(s string, width int) string {
+	if w := displayWidth(s); w < width {
+		return s + strings.Repeat(" ", width-w)
+	}
+	return s
+}
+
+// CoherenceExample Cache 一致性示例
+// 408 考点：多核 Cache 一致性、MESI 状态转换
+func CoherenceExample() {
+	fmt.Println("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Println("  多核 Cache 一致性（监听总线）")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	// 1. 经典序列：三个核读写同一变量 x（地址 0x100）
+	source := `# 核 类型 地址
+P0 r 100
+P2 r 100
+P2 w 100
+P0 r 100
+P1 r 100
+P1 w 104
+P0 r 100
+P1 r 200
+`
+	accesses, err := ParseCoreTrace(strings.NewReader(source))
+	if err != nil {
+		fmt.Println("错误:", err)
+		return
+	}
+	config := CacheConfig{CacheSize: 256, BlockSize: 32, MappingType: DirectMapped}
+	fmt.Println("\n配置：3 个核，各有 256B 直接映射私有 Cache，块大小 32B（0x100 与 0x200 映射到同一行）")
+
+	for _, protocol := range []CoherenceProtocol{ProtocolMESI, ProtocolMOESI} {
+		sim := NewMulticoreSimulator(3, config, protocol)
+		if err := sim.Run(accesses); err != nil {
+			fmt.Println("错误:", err)
+			return
+		}
+		sim.PrintSteps()
+		sim.PrintLineHistory(0x100)
+		fmt.Print(sim.GetStatistics())
+	}
+
+	// 2. 伪共享：两个核各自反复累加自己的计数器
+	fmt.Println("\n【伪共享】两个核各写 8 次自己的计数器")
+	for _, layout := range []struct {
+		name   string
+		second int
+	}{
+		{"计数器在同一块（0x100、0x104）", 0x104},
+		{"计数器在不同块（0x100、0x140）", 0x140},
+	} {
+		perCore := make([][]MemoryAccess, 2)
+		for i := 0; i < 8; i++ {
+			perCore[0] = append(perCore[0], MemoryAccess{Read, 0x100}, MemoryAccess{Write, 0x100})
+			perCore[1] = append(perCore[1], MemoryAccess{Read, layout.second}, MemoryAccess{Write, layout.second})
+		}
+		sim := NewMulticoreSimulator(2, config, ProtocolMESI)
+		if err := sim.Run(InterleaveTraces(perCore)); err != nil {
+			fmt.Println("错误:", err)
+			return
+		}
+		fmt.Printf("\n%s：", layout.name)
+		fmt.Print(sim.GetStatistics())
+	}
+	fmt.Println("\n说明：不同核写同一块中的不同变量也会互相使对方的副本失效，应把频繁写的变量放在不同的块中")
+}
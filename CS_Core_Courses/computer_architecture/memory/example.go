@@ -1,11 +1,44 @@
 package memory
 
-import "fmt"
+import (
+	"fmt"
+
+	"CS_Core_Courses/registry"
+)
+
+func init() {
+	registry.Register(&registry.Module{
+		Name:    "arch/memory",
+		Section: "3.2",
+		Title:   "存储器层次",
+		Run:     RunAllMemoryExamples,
+		Examples: []registry.Example{
+			{Name: "cache", Run: CacheExample},
+			{Name: "cache_write", Run: CacheWritePolicyExample},
+			{Name: "cache_prefetch", Run: CachePrefetchExample},
+			{Name: "cache_hierarchy", Run: CacheHierarchyExample},
+			{Name: "trace_file", Run: TraceFileExample},
+			{Name: "coherence", Run: CoherenceExample},
+			{Name: "virtual_memory", Run: VirtualMemoryExample},
+			{Name: "page_replacement", Run: PageReplacementComparisonExample},
+			{Name: "replacement_policies", Run: ReplacementPolicyExample},
+			{Name: "translation", Run: AddressTranslationExample},
+		},
+	})
+}
 
 func RunAllMemoryExamples() {
 	fmt.Println("\n╔══════════════════════════════════════╗")
 	fmt.Println("║    计算机组成原理 - 存储器层次模块   ║")
 	fmt.Println("╚══════════════════════════════════════╝")
 	CacheExample()
+	CacheWritePolicyExample()
+	CachePrefetchExample()
+	CacheHierarchyExample()
+	TraceFileExample()
+	CoherenceExample()
 	VirtualMemoryExample()
+	PageReplacementComparisonExample()
+	ReplacementPolicyExample()
+	AddressTranslationExample()
 }
@@ -0,0 +1,407 @@
+package memory
+
+import (
+	"fmt"
+	"strings"
+
+	"CS_Core_Courses/trace"
+)
+
+// InclusionPolicy 多级 Cache 的包含策略
+type InclusionPolicy int
+
+const (
+	NonInclusive InclusionPolicy = iota // 非包含非互斥：缺失时各级都调入，下级替换不影响上级
+	Inclusive                           // 包含：上级的块一定在下级中，下级替换时使上级副本失效
+	Exclusive                           // 互斥：块只存在于一级，上级替换出的块移入下级（下级相当于牺牲缓存）
+)
+
+func (ip InclusionPolicy) String() string {
+	switch ip {
+	case Inclusive:
+		return "包含 (Inclusive)"
+	case Exclusive:
+		return "互斥 (Exclusive)"
+	}
+	return "非包含非互斥 (NINE)"
+}
+
+// CacheLevel 多级 Cache 中的一级
+type CacheLevel struct {
+	Name       string
+	Cache      *CacheSimulator
+	HitLatency int // 访问该级所需的周期数（命中时间）
+	Accesses   int // 需求访问次数（不含写回、牺牲块下移等层间传送）
+	Hits       int // 需求访问命中次数
+}
+
+// NewCacheLevel 创建一级 Cache
+func NewCacheLevel(name string, config CacheConfig, hitLatency int) *CacheLevel {
+	return &CacheLevel{
+		Name:       name,
+		Cache:      NewCacheSimulator(config),
+		HitLatency: hitLatency,
+	}
+}
+
+// HitRate 局部命中率（到达本级的访问中命中的比例）
+func (l *CacheLevel) HitRate() float64 {
+	if l.Accesses == 0 {
+		return 0
+	}
+	return float64(l.Hits) / float64(l.Accesses)
+}
+
+// MissRate 局部缺失率
+func (l *CacheLevel) MissRate() float64 {
+	if l.Accesses == 0 {
+		return 0
+	}
+	return 1 - l.HitRate()
+}
+
+// HierarchyConfig 多级 Cache 配置
+// 各级的块大小应相同；L1 按自身的写策略处理写操作，下级一律按写回、写分配处理
+type HierarchyConfig struct {
+	L1I           *CacheLevel     // 指令 Cache，nil 表示 L1 为统一 Cache（取指也访问 L1D）
+	L1D           *CacheLevel     // 数据 Cache
+	Lower         []*CacheLevel   // L2、L3 ...，按从上到下的顺序
+	MemoryLatency int             // 主存访问周期数
+	Inclusion     InclusionPolicy // 包含策略
+}
+
+// CacheHierarchy 多级 Cache 层次结构
+// 408 考点：
+//   - 访问依次查找 L1、L2、...，某级命中即停止；都不命中则访问主存
+//   - 平均访存时间 AMAT = T1 + m1 × (T2 + m2 × (... + Tm))，m 为各级局部缺失率
+type CacheHierarchy struct {
+	L1I               *CacheLevel
+	L1D               *CacheLevel
+	Lower             []*CacheLevel
+	MemoryLatency     int
+	Inclusion         InclusionPolicy
+	Accesses          int          // 总访问次数
+	TotalCycles       int          // 总访问周期数
+	MemoryReads       int          // 需求访问到达主存的次数
+	MemoryWrites      int          // 写回主存的次数（脏块写回、写直达）
+	BackInvalidations int          // 包含策略下因下级替换而使上级失效的次数
+	Tracer            trace.Tracer // 事件输出（nil 时使用默认控制台）
+}
+
+// NewCacheHierarchy 创建多级 Cache，并接管各级 Cache 的替换回调
+func NewCacheHierarchy(config HierarchyConfig) *CacheHierarchy {
+	h := &CacheHierarchy{
+		L1I:           config.L1I,
+		L1D:           config.L1D,
+		Lower:         config.Lower,
+		MemoryLatency: config.MemoryLatency,
+		Inclusion:     config.Inclusion,
+	}
+	for _, lvl := range h.levels() {
+		lvl := lvl
+		lvl.Cache.Evicted = func(address int, dirty bool) {
+			h.onEvict(lvl, address, dirty)
+		}
+	}
+	return h
+}
+
+// levels 返回所有级（L1I、L1D、L2、...）
+func (h *CacheHierarchy) levels() []*CacheLevel {
+	var all []*CacheLevel
+	if h.L1I != nil {
+		all = append(all, h.L1I)
+	}
+	all = append(all, h.L1D)
+	return append(all, h.Lower...)
+}
+
+// path 返回一次访问依次查找的各级
+func (h *CacheHierarchy) path(kind AccessType) []*CacheLevel {
+	l1 := h.L1D
+	if kind == Fetch && h.L1I != nil {
+		l1 = h.L1I
+	}
+	return append([]*CacheLevel{l1}, h.Lower...)
+}
+
+// next 返回下一级，最后一级返回 nil（主存）
+func (h *CacheHierarchy) next(lvl *CacheLevel) *CacheLevel {
+	if lvl == h.L1I || lvl == h.L1D {
+		if len(h.Lower) > 0 {
+			return h.Lower[0]
+		}
+		return nil
+	}
+	for i, l := range h.Lower {
+		if l == lvl && i+1 < len(h.Lower) {
+			return h.Lower[i+1]
+		}
+	}
+	return nil
+}
+
+// above 返回比 lvl 更靠近 CPU 的各级
+func (h *CacheHierarchy) above(lvl *CacheLevel) []*CacheLevel {
+	var upper []*CacheLevel
+	for _, l := range h.levels() {
+		if l == lvl {
+			break
+		}
+		upper = append(upper, l)
+	}
+	return upper
+}
+
+// Access 访问一个地址，返回访问周期数和提供数据的层次
+func (h *CacheHierarchy) Access(kind AccessType, address int) (latency int, servedBy string) {
+	path := h.path(kind)
+	h.Accesses++
+
+	hitLevel := -1
+	for i, lvl := range path {
+		latency += lvl.HitLatency
+		lvl.Accesses++
+		if lvl.Cache.Contains(address) {
+			lvl.Hits++
+			hitLevel = i
+			break
+		}
+	}
+
+	servedBy = "主存"
+	if hitLevel < 0 {
+		latency += h.MemoryLatency
+		h.MemoryReads++
+	} else {
+		servedBy = path[hitLevel].Name
+	}
+	h.TotalCycles += latency
+
+	h.update(path, hitLevel, kind, address)
+
+	trace.Emit(h.Tracer, trace.Event{
+		Cycle:     h.Accesses,
+		Component: "hierarchy",
+		Kind:      "access",
+		Fields: map[string]any{
+			"op":      kind.String(),
+			"address": address,
+			"served":  servedBy,
+			"latency": latency,
+		},
+	})
+	return latency, servedBy
+}
+
+// update 按包含策略调整各级内容
+func (h *CacheHierarchy) update(path []*CacheLevel, hitLevel int, kind AccessType, address int) {
+	l1 := path[0]
+	// L1 非写分配的写缺失：块不调入 L1
+	bypass := kind == Write && hitLevel != 0 && l1.Cache.Config.WriteMiss == NoWriteAllocate
+
+	dirtyFromBelow := false
+	switch {
+	case h.Inclusion == Exclusive && hitLevel > 0 && !bypass:
+		// 互斥：块从命中的下级移到 L1
+		_, dirtyFromBelow = path[hitLevel].Cache.Invalidate(address)
+	case h.Inclusion != Exclusive:
+		// 非互斥：缺失的各级自下而上调入块，命中级更新访问时间
+		from := hitLevel
+		if hitLevel < 0 {
+			from = len(path) - 1
+		}
+		for i := from; i >= 1; i-- {
+			path[i].Cache.Fill(address, false)
+		}
+	}
+
+	l1.Cache.AccessWithType(address, kind)
+	if dirtyFromBelow {
+		l1.Cache.Fill(address, true)
+	}
+
+	// 写直达或非写分配：写操作继续传给下级
+	if kind == Write && (l1.Cache.Config.WritePolicy == WriteThrough || bypass) {
+		switch {
+		case h.Inclusion != Exclusive:
+			h.writeDown(l1, address)
+		case bypass && hitLevel > 0:
+			path[hitLevel].Cache.Fill(address, true)
+		default:
+			h.MemoryWrites++
+		}
+	}
+}
+
+// writeDown 把 lvl 的一个脏块（或写直达的数据）写入下一级
+func (h *CacheHierarchy) writeDown(lvl *CacheLevel, address int) {
+	if next := h.next(lvl); next != nil {
+		next.Cache.Fill(address, true)
+		return
+	}
+	h.MemoryWrites++
+}
+
+// onEvict 处理某级替换出的块
+func (h *CacheHierarchy) onEvict(lvl *CacheLevel, address int, dirty bool) {
+	switch h.Inclusion {
+	case Inclusive:
+		// 包含：使上级的副本失效，上级的脏数据随之写回
+		for _, up := range h.above(lvl) {
+			if found, d := up.Cache.Invalidate(address); found {
+				h.BackInvalidations++
+				dirty = dirty || d
+			}
+		}
+		if dirty {
+			h.writeDown(lvl, address)
+		}
+	case Exclusive:
+		// 互斥：替换出的块（无论是否为脏）移入下一级
+		if next := h.next(lvl); next != nil {
+			next.Cache.Fill(address, dirty)
+		} else if dirty {
+			h.MemoryWrites++
+		}
+	default:
+		if dirty {
+			h.writeDown(lvl, address)
+		}
+	}
+}
+
+// Run 依次执行访问序列
+func (h *CacheHierarchy) Run(accesses []MemoryAccess) {
+	for _, a := range accesses {
+		h.Access(a.Type, a.Address)
+	}
+}
+
+// AMAT 实测平均访存时间（总周期 / 访问次数）
+func (h *CacheHierarchy) AMAT() float64 {
+	if h.Accesses == 0 {
+		return 0
+	}
+	return float64(h.TotalCycles) / float64(h.Accesses)
+}
+
+// lowerAMAT 从第 i 个下级开始的平均访问时间
+func (h *CacheHierarchy) lowerAMAT(i int) float64 {
+	if i >= len(h.Lower) {
+		return float64(h.MemoryLatency)
+	}
+	lvl := h.Lower[i]
+	return float64(lvl.HitLatency) + lvl.MissRate()*h.lowerAMAT(i+1)
+}
+
+// lowerFormula 从第 i 个下级开始的 AMAT 展开式
+func (h *CacheHierarchy) lowerFormula(i int) string {
+	if i >= len(h.Lower) {
+		return fmt.Sprintf("%d", h.MemoryLatency)
+	}
+	lvl := h.Lower[i]
+	return fmt.Sprintf("%d + %.3f × (%s)", lvl.HitLatency, lvl.MissRate(), h.lowerFormula(i+1))
+}
+
+// AMATFormula 按各级局部缺失率展开的 AMAT 公式（L1 分离时按访问比例加权）
+func (h *CacheHierarchy) AMATFormula() string {
+	var sb strings.Builder
+	below := h.lowerAMAT(0)
+	for _, l1 := range []*CacheLevel{h.L1I, h.L1D} {
+		if l1 == nil || l1.Accesses == 0 {
+			continue
+		}
+		value := float64(l1.HitLatency) + l1.MissRate()*below
+		sb.WriteString(fmt.Sprintf("  经 %s: %d + %.3f × (%s) = %.2f 周期（占访问 %.1f%%）\n",
+			l1.Name, l1.HitLatency, l1.MissRate(), h.lowerFormula(0), value,
+			float64(l1.Accesses)/float64(h.Accesses)*100))
+	}
+	return sb.String()
+}
+
+// GetStatistics 获取各级命中率和 AMAT
+func (h *CacheHierarchy) GetStatistics() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("\n多级 Cache 统计（%s，主存 %d 周期）：\n", h.Inclusion, h.MemoryLatency))
+	sb.WriteString("  层次  容量     相联度  命中时间  访问次数  命中次数  局部命中率  全局缺失率  写回块数\n")
+	for _, lvl := range h.levels() {
+		globalMiss := 0.0
+		if h.Accesses > 0 {
+			globalMiss = float64(lvl.Accesses-lvl.Hits) / float64(h.Accesses) * 100
+		}
+		sb.WriteString(fmt.Sprintf("  %-4s  %-7s  %6d  %8d  %8d  %8d  %9.2f%%  %9.2f%%  %8d\n",
+			lvl.Name, formatSize(lvl.Cache.Config.CacheSize), lvl.Cache.Config.Associativity,
+			lvl.HitLatency, lvl.Accesses, lvl.Hits, lvl.HitRate()*100, globalMiss,
+			lvl.Cache.Writebacks))
+	}
+	sb.WriteString(fmt.Sprintf("  主存  读 %d 次，写 %d 次\n", h.MemoryReads, h.MemoryWrites))
+	if h.Inclusion == Inclusive {
+		sb.WriteString(fmt.Sprintf("  反向失效: %d 次\n", h.BackInvalidations))
+	}
+	sb.WriteString(fmt.Sprintf("\n平均访存时间 AMAT = %d / %d = %.2f 周期\n", h.TotalCycles, h.Accesses, h.AMAT()))
+	sb.WriteString(h.AMATFormula())
+	return sb.String()
+}
+
+// formatSize 把字节数格式化为 B/KB/MB
+func formatSize(bytes int) string {
+	switch {
+	case bytes >= 1<<20 && bytes%(1<<20) == 0:
+		return fmt.Sprintf("%dMB", bytes>>20)
+	case bytes >= 1<<10 && bytes%(1<<10) == 0:
+		return fmt.Sprintf("%dKB", bytes>>10)
+	}
+	return fmt.Sprintf("%dB", bytes)
+}
+
+// CacheHierarchyExample 多级 Cache 示例程序
+// 408 考点：多级 Cache 的命中率与平均访存时间
+func CacheHierarchyExample() {
+	fmt.Println("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Println("  多级 Cache 与平均访存时间")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	accesses := buildLoopAccesses()
+	fmt.Println("\n访问序列：循环 4 次，每次取 8 条指令的循环体，顺序读 4KB 数组 A、每 4 个元素写一次数组 B")
+	fmt.Printf("共 %d 次访问\n", len(accesses))
+	fmt.Println("\n配置：L1I 1KB 直接映射 / L1D 1KB 2 路（1 周期），L2 4KB 4 路（10 周期），L3 16KB 8 路（30 周期），主存 100 周期，块大小 32B")
+
+	for _, policy := range []InclusionPolicy{NonInclusive, Inclusive, Exclusive} {
+		h := NewCacheHierarchy(HierarchyConfig{
+			L1I: NewCacheLevel("L1I", CacheConfig{CacheSize: 1024, BlockSize: 32, MappingType: DirectMapped}, 1),
+			L1D: NewCacheLevel("L1D", CacheConfig{CacheSize: 1024, BlockSize: 32, Associativity: 2, MappingType: SetAssociative}, 1),
+			Lower: []*CacheLevel{
+				NewCacheLevel("L2", CacheConfig{CacheSize: 4096, BlockSize: 32, Associativity: 4, MappingType: SetAssociative}, 10),
+				NewCacheLevel("L3", CacheConfig{CacheSize: 16384, BlockSize: 32, Associativity: 8, MappingType: SetAssociative}, 30),
+			},
+			MemoryLatency: 100,
+			Inclusion:     policy,
+		})
+		h.Run(accesses)
+		fmt.Println(h.GetStatistics())
+	}
+
+	fmt.Println("说明：互斥策略下各级不重复保存同一块，有效容量为各级之和；包含策略便于一致性维护但浪费容量")
+}
+
+// buildLoopAccesses 生成示例访问序列
+func buildLoopAccesses() []MemoryAccess {
+	const (
+		codeBase = 0x1000
+		arrayA   = 0x10000
+		arrayB   = 0x20000
+	)
+	var accesses []MemoryAccess
+	for pass := 0; pass < 4; pass++ {
+		for i := 0; i < 256; i++ {
+			pc := codeBase + (i%8)*4
+			accesses = append(accesses, MemoryAccess{Fetch, pc})
+			accesses = append(accesses, MemoryAccess{Read, arrayA + i*16})
+			if i%4 == 0 {
+				accesses = append(accesses, MemoryAccess{Write, arrayB + i*4})
+			}
+		}
+	}
+	return accesses
+}
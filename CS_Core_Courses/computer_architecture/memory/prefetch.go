@@ -0,0 +1,362 @@
+package memory
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// PrefetcherType 硬件预取器类型
+type PrefetcherType int
+
+const (
+	NoPrefetch       PrefetcherType = iota // 不预取
+	NextLinePrefetch                       // 下一块预取：缺失或命中预取块时调入后续块
+	StridePrefetch                         // 步长预取：按访存指令（PC）记录步长，步长稳定后按步长预取
+	StreamPrefetch                         // 流预取：识别连续缺失构成的升序/降序访问流并沿方向预取
+)
+
+func (p PrefetcherType) String() string {
+	switch p {
+	case NextLinePrefetch:
+		return "下一块预取"
+	case StridePrefetch:
+		return "步长预取"
+	case StreamPrefetch:
+		return "流预取"
+	}
+	return "无预取"
+}
+
+const (
+	strideTableSize = 16 // 步长预取器参考预测表的项数（按 PC 直接索引）
+	streamTableSize = 4  // 流预取器同时跟踪的访问流数
+)
+
+// strideEntry 步长预取器的一项：某条访存指令上次访问的地址和步长
+type strideEntry struct {
+	valid      bool
+	pc         int
+	lastAddr   int
+	stride     int
+	confidence int // 连续出现相同步长的次数（饱和于 3）
+}
+
+// streamEntry 流预取器跟踪的一个访问流
+type streamEntry struct {
+	lastBlock  int
+	direction  int // 1 升序，-1 降序，0 未确定
+	confidence int
+	lastUse    int
+}
+
+// victimEntry 牺牲缓存中的一块
+type victimEntry struct {
+	block int
+	dirty bool
+}
+
+// AccessPC 带指令地址的访问，步长预取器据此区分不同指令的访问流
+// 不提供 PC 的访问（Access、Write 等）都视为 PC 0
+func (cs *CacheSimulator) AccessPC(pc, address int, kind AccessType) (hit bool, message string) {
+	cs.pc = pc
+	hit, message = cs.AccessWithType(address, kind)
+	cs.pc = 0
+	return hit, message
+}
+
+// trainPrefetcher 用一次需求访问训练预取器，并发出预取
+func (cs *CacheSimulator) trainPrefetcher(address int, hit bool) {
+	block := address >> cs.BlockOffset
+	degree := cs.Config.PrefetchDegree
+	if degree <= 0 {
+		degree = 1
+	}
+
+	switch cs.Config.Prefetcher {
+	case NextLinePrefetch:
+		// 带标记的顺序预取：命中预取块时继续预取，保持领先于访问
+		if !hit || cs.prefetchHit {
+			for d := 1; d <= degree; d++ {
+				cs.prefetchBlock(block + d)
+			}
+		}
+	case StridePrefetch:
+		if stride, ok := cs.trainStride(address); ok {
+			for d := 1; d <= degree; d++ {
+				cs.prefetchBlock((address + d*stride) >> cs.BlockOffset)
+			}
+		}
+	case StreamPrefetch:
+		if hit && !cs.prefetchHit {
+			return
+		}
+		if dir, ok := cs.trainStream(block); ok {
+			for d := 1; d <= degree; d++ {
+				cs.prefetchBlock(block + dir*d)
+			}
+		}
+	}
+}
+
+// trainStride 更新当前 PC 的步长表项，步长连续两次相同时返回该步长
+func (cs *CacheSimulator) trainStride(address int) (stride int, ok bool) {
+	if cs.strideTable == nil {
+		cs.strideTable = make([]strideEntry, strideTableSize)
+	}
+	index := cs.pc % strideTableSize
+	if index < 0 {
+		index += strideTableSize
+	}
+
+	e := &cs.strideTable[index]
+	if !e.valid || e.pc != cs.pc {
+		*e = strideEntry{valid: true, pc: cs.pc, lastAddr: address}
+		return 0, false
+	}
+
+	stride = address - e.lastAddr
+	e.lastAddr = address
+	if stride == 0 {
+		return 0, false
+	}
+	if stride == e.stride {
+		if e.confidence < 3 {
+			e.confidence++
+		}
+	} else {
+		e.stride = stride
+		e.confidence = 0
+	}
+	return stride, e.confidence >= 1
+}
+
+// trainStream 把缺失的块归入一个访问流，方向确定后返回方向
+func (cs *CacheSimulator) trainStream(block int) (direction int, ok bool) {
+	for i := range cs.streams {
+		s := &cs.streams[i]
+		delta := block - s.lastBlock
+		if delta == 0 || delta < -2 || delta > 2 {
+			continue
+		}
+
+		dir := 1
+		if delta < 0 {
+			dir = -1
+		}
+		switch s.direction {
+		case dir:
+			s.confidence++
+		case 0:
+			s.direction = dir
+			s.confidence = 1
+		default:
+			// 方向反转，重新确认
+			s.direction = dir
+			s.confidence = 0
+		}
+		s.lastBlock = block
+		s.lastUse = cs.CurrentTime
+		return dir, s.confidence >= 1
+	}
+
+	// 新的访问流，表满时替换最久未用的
+	entry := streamEntry{lastBlock: block, lastUse: cs.CurrentTime}
+	if len(cs.streams) < streamTableSize {
+		cs.streams = append(cs.streams, entry)
+		return 0, false
+	}
+	oldest := 0
+	for i := range cs.streams {
+		if cs.streams[i].lastUse < cs.streams[oldest].lastUse {
+			oldest = i
+		}
+	}
+	cs.streams[oldest] = entry
+	return 0, false
+}
+
+// prefetchBlock 把一块预取进 Cache（已在 Cache 中则忽略）
+func (cs *CacheSimulator) prefetchBlock(block int) {
+	if block < 0 {
+		return
+	}
+	address := block << cs.BlockOffset
+	if cs.Contains(address) {
+		return
+	}
+
+	tag, index, offset := cs.ParseAddress(address)
+	start := index * cs.Config.Associativity
+	line := cs.selectVictim(start, start+cs.Config.Associativity)
+	writeback := cs.replace(line, tag)
+	cs.Lines[line].Prefetched = true
+	cs.BlockLoads++
+	cs.PrefetchIssued++
+	cs.emit("prefetch", Read, address, tag, index, offset, line, writeback)
+}
+
+// findVictim 在牺牲缓存中查找地址所在的块，返回下标，未找到返回 -1
+func (cs *CacheSimulator) findVictim(address int) int {
+	block := address >> cs.BlockOffset << cs.BlockOffset
+	for i, v := range cs.victims {
+		if v.block == block {
+			return i
+		}
+	}
+	return -1
+}
+
+// removeVictim 从牺牲缓存中取出一块
+func (cs *CacheSimulator) removeVictim(i int) {
+	cs.victims = append(cs.victims[:i], cs.victims[i+1:]...)
+}
+
+// pushVictim 把主 Cache 替换出的块放入牺牲缓存（先进先出），返回是否有脏块写回主存
+func (cs *CacheSimulator) pushVictim(block int, dirty bool) (writeback bool) {
+	cs.victims = append(cs.victims, victimEntry{block: block, dirty: dirty})
+	if len(cs.victims) <= cs.Config.VictimCacheSize {
+		return false
+	}
+	out := cs.victims[0]
+	cs.victims = cs.victims[1:]
+	return cs.evict(out.block, out.dirty)
+}
+
+// swapFromVictim 若块在牺牲缓存中，把它换回主 Cache 的 [start, end) 组，
+// 主 Cache 中被替换的块进入牺牲缓存
+func (cs *CacheSimulator) swapFromVictim(address, start, end int) (line int, ok bool) {
+	i := cs.findVictim(address)
+	if i < 0 {
+		return -1, false
+	}
+	entry := cs.victims[i]
+	cs.removeVictim(i)
+
+	tag, _, _ := cs.ParseAddress(address)
+	line = cs.selectVictim(start, end)
+	cs.replace(line, tag)
+	cs.Lines[line].Dirty = entry.dirty
+	return line, true
+}
+
+// prefetchStatistics 预取器和牺牲缓存的统计（未启用时为空）
+func (cs *CacheSimulator) prefetchStatistics() string {
+	var sb strings.Builder
+	if cs.Config.Prefetcher != NoPrefetch {
+		accuracy, coverage := 0.0, 0.0
+		if cs.PrefetchIssued > 0 {
+			accuracy = float64(cs.PrefetchUseful) / float64(cs.PrefetchIssued) * 100
+		}
+		if cs.PrefetchUseful+cs.Misses > 0 {
+			coverage = float64(cs.PrefetchUseful) / float64(cs.PrefetchUseful+cs.Misses) * 100
+		}
+		degree := cs.Config.PrefetchDegree
+		if degree <= 0 {
+			degree = 1
+		}
+		sb.WriteString(fmt.Sprintf(`
+预取（%s，每次 %d 块）：
+  发出预取:   %d
+  有用预取:   %d（准确率 %.2f%%，覆盖率 %.2f%%）
+  无用预取:   %d（未被访问就被替换）
+  尚未使用:   %d
+`,
+			cs.Config.Prefetcher, degree,
+			cs.PrefetchIssued, cs.PrefetchUseful, accuracy, coverage,
+			cs.PrefetchUseless, cs.PrefetchIssued-cs.PrefetchUseful-cs.PrefetchUseless))
+	}
+	if cs.Config.VictimCacheSize > 0 {
+		sb.WriteString(fmt.Sprintf(`
+牺牲缓存（%d 行，全相联）：
+  命中次数:   %d
+`, cs.Config.VictimCacheSize, cs.VictimHits))
+	}
+	return sb.String()
+}
+
+// pcAccess 带指令地址的一次访问
+type pcAccess struct {
+	pc      int
+	address int
+}
+
+// CachePrefetchExample 预取器与牺牲缓存示例
+// 408 考点：空间局部性；顺序访问时缺失集中在每块的第一次访问，预取可以提前调入下一块
+func CachePrefetchExample() {
+	fmt.Println("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Println("  Cache 硬件预取与牺牲缓存")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	// 1. CacheExample 中的访问序列（块 0,1,2,3,0,1,4,0）
+	addresses := []int{0x0000, 0x0040, 0x0080, 0x00C0, 0x0000, 0x0040, 0x0100, 0x0000}
+	fmt.Println("\n【1. CacheExample 的访问序列（直接映射，4 行 × 64B）】")
+	fmt.Println("  配置                  命中率  预取  有用  无用  牺牲缓存命中")
+	for _, c := range []struct {
+		name   string
+		config CacheConfig
+	}{
+		{"无预取", CacheConfig{}},
+		{"下一块预取", CacheConfig{Prefetcher: NextLinePrefetch}},
+		{"牺牲缓存 2 行", CacheConfig{VictimCacheSize: 2}},
+	} {
+		config := c.config
+		config.CacheSize, config.BlockSize, config.MappingType = 256, 64, DirectMapped
+		sim := NewCacheSimulator(config)
+		for _, addr := range addresses {
+			sim.Access(addr)
+		}
+		fmt.Printf("  %s %6.2f%%  %4d  %4d  %4d  %12d\n", padCJK(c.name, 20),
+			float64(sim.Hits)/float64(sim.AccessCount)*100,
+			sim.PrefetchIssued, sim.PrefetchUseful, sim.PrefetchUseless, sim.VictimHits)
+	}
+	fmt.Println("  说明：块 0、1、2、3 依次缺失是顺序访问的强制缺失，下一块预取把其中大部分提前调入；")
+	fmt.Println("        块 4 与块 0 映射到同一行发生冲突，被替换的块 0 留在牺牲缓存中，再次访问时换回")
+
+	// 2. 不同访问模式下三种预取器的对比
+	rng := rand.New(rand.NewSource(1))
+	var sequential, descending, strided, interleaved, random []pcAccess
+	for i := 0; i < 2048; i++ {
+		sequential = append(sequential, pcAccess{0x400, i * 4})
+		descending = append(descending, pcAccess{0x400, (2047 - i) * 4})
+	}
+	for i := 0; i < 256; i++ {
+		strided = append(strided, pcAccess{0x404, i * 192})
+		interleaved = append(interleaved, pcAccess{0x408, i * 16}, pcAccess{0x40C, 0x10000 + i*16})
+	}
+	for i := 0; i < 1024; i++ {
+		random = append(random, pcAccess{0x410, rng.Intn(1<<16) &^ 3})
+	}
+
+	fmt.Println("\n【2. 访问模式与预取器（1KB 二路组相联，块 64B，LRU）】")
+	for _, w := range []struct {
+		name     string
+		accesses []pcAccess
+	}{
+		{"顺序扫描 8KB 数组（步长 4B）", sequential},
+		{"逆序扫描 8KB 数组", descending},
+		{"步长 192B（每 3 块访问 1 块）", strided},
+		{"两个数组交错访问（步长 16B）", interleaved},
+		{"随机访问 64KB 范围", random},
+	} {
+		fmt.Printf("\n  %s，共 %d 次访问\n", w.name, len(w.accesses))
+		fmt.Println("    预取器       命中率   发出   有用   无用")
+		for _, p := range []PrefetcherType{NoPrefetch, NextLinePrefetch, StridePrefetch, StreamPrefetch} {
+			sim := NewCacheSimulator(CacheConfig{
+				CacheSize: 1024, BlockSize: 64, Associativity: 2,
+				MappingType: SetAssociative, Prefetcher: p,
+			})
+			for _, a := range w.accesses {
+				sim.AccessPC(a.pc, a.address, Read)
+			}
+			fmt.Printf("    %s %6.2f%%  %5d  %5d  %5d\n", padCJK(p.String(), 12),
+				float64(sim.Hits)/float64(sim.AccessCount)*100,
+				sim.PrefetchIssued, sim.PrefetchUseful, sim.PrefetchUseless)
+		}
+	}
+
+	fmt.Println("\n说明：")
+	fmt.Println("  - 顺序访问中每块只有第一次访问缺失（64B/4B = 16 次访问缺失 1 次），预取把这次缺失也消除")
+	fmt.Println("  - 下一块预取只会向前预取，逆序访问和大步长访问需要流预取或步长预取")
+	fmt.Println("  - 随机访问没有规律，预取只会占用带宽、挤掉有用的块（无用预取）")
+}
@@ -0,0 +1,425 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PageReplacementPolicies 对比时默认运行的全部页面替换算法
+var PageReplacementPolicies = []PageReplacementPolicy{
+	PageFIFO, PageLRU, PageClock, PageOptimal,
+	PageEnhancedClock, PageLFU, PageAging, PageWSClock,
+}
+
+// ReplacementOptions 对比时的可选参数，零值表示使用模拟器默认值
+type ReplacementOptions struct {
+	Writes           []bool // 引用串中每次访问是否为写（nil 表示全部为读）
+	WorkingSetWindow int    // 工作集窗口 Δ（WSClock）
+	AgingInterval    int    // 老化算法的时钟中断间隔
+}
+
+// ReplacementRun 一个算法在给定页框数下处理引用串的结果
+type ReplacementRun struct {
+	Policy     PageReplacementPolicy
+	Frames     int
+	Faults     int
+	WriteBacks int     // 换出脏页的写回次数
+	Residency  [][]int // 每次访问后各页框中的页号（-1 表示空闲），即考试中的驻留矩阵
+	Faulted    []bool  // 每次访问是否缺页
+}
+
+// FaultRate 缺页率
+func (r ReplacementRun) FaultRate() float64 {
+	if len(r.Faulted) == 0 {
+		return 0
+	}
+	return float64(r.Faults) / float64(len(r.Faulted))
+}
+
+// SimulateReplacement 用 VirtualMemorySimulator 按页面引用串运行一种替换算法
+func SimulateReplacement(references []int, frames int, policy PageReplacementPolicy) ReplacementRun {
+	return SimulateReplacementWith(references, frames, policy, ReplacementOptions{})
+}
+
+// SimulateReplacementWith 同 SimulateReplacement，可指定写访问与算法参数
+func SimulateReplacementWith(references []int, frames int, policy PageReplacementPolicy, opts ReplacementOptions) ReplacementRun {
+	// 页表只需容纳引用串中出现过的页：把页号按大小顺序重新编为 0..n-1，
+	// 驻留矩阵中再换回原页号（否则 "1 2 500000000" 这样的引用串会分配巨大的页表）
+	pages := distinctPages(references)
+	index := make(map[int]int, len(pages))
+	for i, page := range pages {
+		index[page] = i
+	}
+
+	vms := NewVirtualMemorySimulator(len(pages), frames, 1, policy)
+	if opts.WorkingSetWindow > 0 {
+		vms.WorkingSetWindow = opts.WorkingSetWindow
+	}
+	if opts.AgingInterval > 0 {
+		vms.AgingInterval = opts.AgingInterval
+	}
+	addresses := make([]int, len(references))
+	for i, page := range references {
+		addresses[i] = index[page] * vms.PageSize
+	}
+	if policy == PageOptimal {
+		vms.SetFutureAccesses(addresses)
+	}
+
+	run := ReplacementRun{Policy: policy, Frames: frames}
+	for i, addr := range addresses {
+		before := vms.PageFaults
+		if i < len(opts.Writes) && opts.Writes[i] {
+			vms.Write(addr)
+		} else {
+			vms.Access(addr)
+		}
+		run.Faulted = append(run.Faulted, vms.PageFaults > before)
+		resident := make([]int, len(vms.PhysicalMemory))
+		for f, page := range vms.PhysicalMemory {
+			resident[f] = -1
+			if page != -1 {
+				resident[f] = pages[page]
+			}
+		}
+		run.Residency = append(run.Residency, resident)
+	}
+	run.Faults = vms.PageFaults
+	run.WriteBacks = vms.WriteBacks
+	return run
+}
+
+// distinctPages 引用串中出现过的页号，从小到大排列
+func distinctPages(references []int) []int {
+	seen := make(map[int]bool)
+	var pages []int
+	for _, page := range references {
+		if !seen[page] {
+			seen[page] = true
+			pages = append(pages, page)
+		}
+	}
+	sort.Ints(pages)
+	return pages
+}
+
+// BeladyAnomaly 页框增加而缺页次数反而增加的一处记录
+type BeladyAnomaly struct {
+	Policy     PageReplacementPolicy
+	Frames     int // 较少的页框数
+	Faults     int
+	MoreFaults int // Frames+1 个页框时的缺页次数
+}
+
+// ReplacementComparison 多种算法、多种页框数的对比结果
+type ReplacementComparison struct {
+	References []int
+	MinFrames  int
+	MaxFrames  int
+	Policies   []PageReplacementPolicy
+	Options    ReplacementOptions
+	Runs       map[PageReplacementPolicy][]ReplacementRun // 下标为 页框数 - MinFrames
+}
+
+// CompareReplacement 对页框数 minFrames..maxFrames 运行各替换算法（未指定算法时运行全部）
+func CompareReplacement(references []int, minFrames, maxFrames int, policies ...PageReplacementPolicy) (*ReplacementComparison, error) {
+	return CompareReplacementWith(references, minFrames, maxFrames, ReplacementOptions{}, policies...)
+}
+
+// CompareReplacementWith 同 CompareReplacement，可指定写访问与算法参数
+func CompareReplacementWith(references []int, minFrames, maxFrames int, opts ReplacementOptions, policies ...PageReplacementPolicy) (*ReplacementComparison, error) {
+	if len(references) == 0 {
+		return nil, fmt.Errorf("页面引用串为空")
+	}
+	for _, page := range references {
+		if page < 0 {
+			return nil, fmt.Errorf("页号不能为负: %d", page)
+		}
+	}
+	if minFrames < 1 || maxFrames < minFrames {
+		return nil, fmt.Errorf("页框数范围无效: %d-%d", minFrames, maxFrames)
+	}
+	if opts.Writes != nil && len(opts.Writes) != len(references) {
+		return nil, fmt.Errorf("写访问标记数 %d 与引用串长度 %d 不一致", len(opts.Writes), len(references))
+	}
+	if len(policies) == 0 {
+		policies = PageReplacementPolicies
+	}
+
+	c := &ReplacementComparison{
+		References: references,
+		MinFrames:  minFrames,
+		MaxFrames:  maxFrames,
+		Policies:   policies,
+		Options:    opts,
+		Runs:       make(map[PageReplacementPolicy][]ReplacementRun),
+	}
+	for _, policy := range policies {
+		for frames := minFrames; frames <= maxFrames; frames++ {
+			c.Runs[policy] = append(c.Runs[policy], SimulateReplacementWith(references, frames, policy, opts))
+		}
+	}
+	return c, nil
+}
+
+// Run 返回某算法在某页框数下的结果
+func (c *ReplacementComparison) Run(policy PageReplacementPolicy, frames int) (ReplacementRun, bool) {
+	runs, ok := c.Runs[policy]
+	if !ok || frames < c.MinFrames || frames > c.MaxFrames {
+		return ReplacementRun{}, false
+	}
+	return runs[frames-c.MinFrames], true
+}
+
+// Anomalies 找出所有 Belady 异常：页框数加一后缺页次数增加
+// 408 考点：FIFO 可能出现 Belady 异常；LRU、OPT 属于栈算法，不会出现
+func (c *ReplacementComparison) Anomalies() []BeladyAnomaly {
+	var anomalies []BeladyAnomaly
+	for _, policy := range c.Policies {
+		runs := c.Runs[policy]
+		for i := 0; i+1 < len(runs); i++ {
+			if runs[i+1].Faults > runs[i].Faults {
+				anomalies = append(anomalies, BeladyAnomaly{
+					Policy:     policy,
+					Frames:     runs[i].Frames,
+					Faults:     runs[i].Faults,
+					MoreFaults: runs[i+1].Faults,
+				})
+			}
+		}
+	}
+	return anomalies
+}
+
+// PrintFaultTable 打印缺页次数表，出现 Belady 异常的算法在行尾标出
+func (c *ReplacementComparison) PrintFaultTable() {
+	fmt.Printf("\n页面引用串: %s（%d 次访问）\n", formatReferences(c.References, c.Options.Writes), len(c.References))
+	header := "  " + padCJK("算法", 22)
+	for frames := c.MinFrames; frames <= c.MaxFrames; frames++ {
+		header += fmt.Sprintf("  %2d 帧", frames)
+	}
+	fmt.Println(header)
+
+	anomalous := make(map[PageReplacementPolicy][]string)
+	for _, a := range c.Anomalies() {
+		anomalous[a.Policy] = append(anomalous[a.Policy], fmt.Sprintf("%d→%d 帧", a.Frames, a.Frames+1))
+	}
+	for _, policy := range c.Policies {
+		row := "  " + padCJK(getPolicyNameVM(policy), 22)
+		for _, run := range c.Runs[policy] {
+			row += fmt.Sprintf("  %5d", run.Faults)
+		}
+		if marks, ok := anomalous[policy]; ok {
+			row += "  ← Belady 异常（" + strings.Join(marks, "，") + "）"
+		}
+		fmt.Println(row)
+	}
+
+	if !hasWrites(c.Options.Writes) {
+		return
+	}
+	fmt.Println("\n脏页写回次数（w 表示写访问）：")
+	fmt.Println(header)
+	for _, policy := range c.Policies {
+		row := "  " + padCJK(getPolicyNameVM(policy), 22)
+		for _, run := range c.Runs[policy] {
+			row += fmt.Sprintf("  %5d", run.WriteBacks)
+		}
+		fmt.Println(row)
+	}
+}
+
+// PrintResidency 打印驻留矩阵：每列一次访问，每行一个页框，最后一行标出缺页
+func (c *ReplacementComparison) PrintResidency(policy PageReplacementPolicy, frames int) {
+	run, ok := c.Run(policy, frames)
+	if !ok {
+		fmt.Printf("没有 %s 在 %d 个页框下的结果\n", getPolicyNameVM(policy), frames)
+		return
+	}
+	run.PrintResidency(c.References, c.Options.Writes)
+}
+
+// PrintResidency 打印驻留矩阵，writes 非空时引用行以 w 标出写访问
+func (r ReplacementRun) PrintResidency(references []int, writes []bool) {
+	labels := strings.Fields(formatReferences(references, writes))
+	width := 2
+	for _, label := range labels {
+		if w := len(label) + 1; w > width {
+			width = w
+		}
+	}
+	cell := func(s string) string {
+		return strings.Repeat(" ", width+1-len([]rune(s))) + s // √ 按单个字符宽度对齐
+	}
+
+	writeBacks := ""
+	if hasWrites(writes) {
+		writeBacks = fmt.Sprintf("，写回 %d 次", r.WriteBacks)
+	}
+	fmt.Printf("\n%s，%d 个页框，缺页 %d 次（缺页率 %.2f%%）%s：\n",
+		getPolicyNameVM(r.Policy), r.Frames, r.Faults, r.FaultRate()*100, writeBacks)
+	line := "  引用  "
+	for _, label := range labels {
+		line += cell(label)
+	}
+	fmt.Println(line)
+	for f := 0; f < r.Frames; f++ {
+		line = fmt.Sprintf("  帧%-2d  ", f)
+		for _, resident := range r.Residency {
+			if resident[f] < 0 {
+				line += cell("")
+			} else {
+				line += cell(strconv.Itoa(resident[f]))
+			}
+		}
+		fmt.Println(strings.TrimRight(line, " "))
+	}
+	line = "  缺页  "
+	for _, faulted := range r.Faulted {
+		if faulted {
+			line += cell("√")
+		} else {
+			line += cell("")
+		}
+	}
+	fmt.Println(strings.TrimRight(line, " "))
+}
+
+// ParseReferenceString 解析页面引用串，页号之间用空格或逗号分隔（忽略写访问标记）
+func ParseReferenceString(s string) ([]int, error) {
+	references, _, err := ParseReferenceStringRW(s)
+	return references, err
+}
+
+// ParseReferenceStringRW 解析带读写标记的页面引用串，页号后加 w 表示写访问，如 "1 2w 3 1w"
+// 没有任何写访问时 writes 为 nil
+func ParseReferenceStringRW(s string) (references []int, writes []bool, err error) {
+	for _, field := range strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == '，' || r == ' ' || r == '\t'
+	}) {
+		write := strings.HasSuffix(field, "w") || strings.HasSuffix(field, "W")
+		page, err := strconv.Atoi(strings.TrimRight(field, "wW"))
+		if err != nil || page < 0 {
+			return nil, nil, fmt.Errorf("无效的页号 %q", field)
+		}
+		references = append(references, page)
+		writes = append(writes, write)
+	}
+	if len(references) == 0 {
+		return nil, nil, fmt.Errorf("页面引用串为空")
+	}
+	if !hasWrites(writes) {
+		writes = nil
+	}
+	return references, writes, nil
+}
+
+// formatReferences 格式化引用串，写访问的页号后加 w
+func formatReferences(references []int, writes []bool) string {
+	parts := make([]string, len(references))
+	for i, page := range references {
+		parts[i] = strconv.Itoa(page)
+		if i < len(writes) && writes[i] {
+			parts[i] += "w"
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func hasWrites(writes []bool) bool {
+	for _, w := range writes {
+		if w {
+			return true
+		}
+	}
+	return false
+}
+
+// PageReplacementComparisonExample 页面替换算法对比与 Belady 异常示例
+// 408 考点：根据引用串画出页框驻留情况、计算缺页次数；FIFO 的 Belady 异常
+func PageReplacementComparisonExample() {
+	fmt.Println("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Println("  页面替换算法对比与 Belady 异常")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	references, _ := ParseReferenceString("1 2 3 4 1 2 5 1 2 3 4 5")
+	c, err := CompareReplacement(references, 1, 6)
+	if err != nil {
+		fmt.Println("错误:", err)
+		return
+	}
+	c.PrintFaultTable()
+	c.PrintResidency(PageFIFO, 3)
+	c.PrintResidency(PageFIFO, 4)
+	c.PrintResidency(PageLRU, 4)
+
+	for _, a := range c.Anomalies() {
+		fmt.Printf("\n⚠️  %s 出现 Belady 异常：%d 个页框缺页 %d 次，%d 个页框反而缺页 %d 次\n",
+			getPolicyNameVM(a.Policy), a.Frames, a.Faults, a.Frames+1, a.MoreFaults)
+	}
+
+	// 408 真题常见的引用串
+	references, _ = ParseReferenceString("7,0,1,2,0,3,0,4,2,3,0,3,2,1,2,0,1,7,0,1")
+	c, _ = CompareReplacement(references, 3, 5)
+	c.PrintFaultTable()
+	c.PrintResidency(PageOptimal, 3)
+	if len(c.Anomalies()) == 0 {
+		fmt.Println("\n该引用串下各算法都没有出现 Belady 异常")
+	}
+	fmt.Println("\n说明：LRU 和 OPT 是栈算法，n 个页框中的页面集合总是 n+1 个页框时的子集，因此不会出现 Belady 异常")
+	fmt.Println("      命令行中可用 go run . pagesim -frames 1-6 \"1 2 3 4 1 2 5 1 2 3 4 5\" 对比任意引用串")
+}
+
+// ReplacementPolicyExample 改进型 Clock、LFU、老化算法与工作集示例
+// 408 考点：改进型 Clock 的四类页面与扫描轮次；工作集 W(t, Δ)
+func ReplacementPolicyExample() {
+	fmt.Println("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Println("  改进型 Clock、LFU、老化与工作集算法")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	// 1. 写访问让修改位有意义：改进型 Clock 优先换出未修改的页面
+	fmt.Println("\n【1. 改进型 Clock：优先淘汰 (A=0, M=0) 的页面】")
+	references, writes, _ := ParseReferenceStringRW("1w 2 3 1w 4 2 5 1w 2 3 4 5 1w 2")
+	opts := ReplacementOptions{Writes: writes}
+	c, err := CompareReplacementWith(references, 3, 4, opts, PageClock, PageEnhancedClock, PageLRU)
+	if err != nil {
+		fmt.Println("错误:", err)
+		return
+	}
+	c.PrintFaultTable()
+	c.PrintResidency(PageEnhancedClock, 3)
+
+	// 2. LFU 与老化算法：老化用若干位计数器近似 LRU，时钟中断越稀疏越粗糙
+	fmt.Println("\n【2. LFU 与老化算法（LRU 的近似）】")
+	references, _ = ParseReferenceString("1 1 1 2 3 4 2 3 4 5 1 2 5 3 4 1")
+	for _, interval := range []int{1, 3} {
+		c, _ = CompareReplacementWith(references, 3, 4, ReplacementOptions{AgingInterval: interval},
+			PageLRU, PageLFU, PageAging)
+		fmt.Printf("\n老化算法时钟中断间隔 = %d 次访问：", interval)
+		c.PrintFaultTable()
+	}
+	c.PrintResidency(PageLFU, 3)
+	fmt.Println("\nLFU 中页面 1 早期的多次访问使它长期驻留，即使之后很少使用")
+
+	// 3. 工作集：W(t, Δ) 随时间变化；WSClock 换出工作集以外的页面
+	fmt.Println("\n【3. 工作集与 WSClock】")
+	references, _ = ParseReferenceString("2 6 1 5 7 7 7 7 5 1 6 2 3 4 1 2 3 4 4 4 3 4 3 4 4 4")
+	vms := NewVirtualMemorySimulator(8, 5, 1, PageWSClock)
+	vms.WorkingSetWindow = 5
+	fmt.Printf("\nΔ = %d，引用串: %s\n", vms.WorkingSetWindow, formatReferences(references, nil))
+	for i, page := range references {
+		vms.Access(page * vms.PageSize)
+		if (i+1)%5 == 0 {
+			fmt.Printf("  t=%-3d W(t, %d) = %v\n", vms.CurrentTime, vms.WorkingSetWindow, vms.WorkingSet())
+		}
+	}
+
+	fmt.Println("\nWSClock 在不同窗口下的缺页次数（5 个页框）：")
+	for _, window := range []int{2, 4, 8} {
+		run := SimulateReplacementWith(references, 5, PageWSClock, ReplacementOptions{WorkingSetWindow: window})
+		fmt.Printf("  Δ = %d：缺页 %d 次\n", window, run.Faults)
+	}
+	fmt.Println("\n说明：Δ 过小时工作集不能覆盖程序的局部性，Δ 过大时退化为换出最久未访问的页面")
+}
@@ -0,0 +1,355 @@
+package memory
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// maxTraceErrors 最多保留的格式错误条数（其余只计数）
+const maxTraceErrors = 10
+
+// TraceRecord 访存轨迹中的一条记录
+type TraceRecord struct {
+	Type    AccessType // 读、写或取指
+	Address int        // 访问地址
+	Size    int        // 访问字节数（未给出时为 0）
+	Line    int        // 所在行号
+}
+
+// TraceReader 逐行流式读取访存轨迹文件，内存占用与文件长度无关
+// 支持两种常见格式（可以在同一文件中混用）：
+//
+//	Dinero 格式：  <类型> <十六进制地址> [字节数]
+//	               类型为 r/w/i 或 Dinero 的标号 0（读）/1（写）/2（取指），如 "r 7fff5a8c 4"、"2 0x400100"
+//	Valgrind lackey 格式（--trace-mem=yes）：<操作> <十六进制地址>,<字节数>
+//	               I 取指、L 读、S 写、M 修改（读后写），如 "I  04016a9c,3"、" M 7ff000390,8"
+//
+// 空行、以 # 开头的注释和 Valgrind 的 "==" 提示行被忽略；无法识别的行跳过并计数
+type TraceReader struct {
+	scanner *bufio.Scanner
+	line    int
+	pending []TraceRecord // 一行产生多条记录时（M 操作）尚未取走的记录
+	Skipped int           // 跳过的错误行数
+	Errors  []error       // 前 maxTraceErrors 条格式错误
+}
+
+// NewTraceReader 创建轨迹读取器
+func NewTraceReader(r io.Reader) *TraceReader {
+	return &TraceReader{scanner: bufio.NewScanner(r)}
+}
+
+// Next 返回下一条记录，读完或出现读取错误时返回 false（用 Err 区分）
+func (tr *TraceReader) Next() (TraceRecord, bool) {
+	for len(tr.pending) == 0 {
+		if !tr.scanner.Scan() {
+			return TraceRecord{}, false
+		}
+		tr.line++
+		records, err := parseTraceLine(tr.scanner.Text(), tr.pending[:0])
+		if err != nil {
+			tr.Skipped++
+			if len(tr.Errors) < maxTraceErrors {
+				tr.Errors = append(tr.Errors, fmt.Errorf("第 %d 行: %w", tr.line, err))
+			}
+			continue
+		}
+		tr.pending = records
+	}
+
+	record := tr.pending[0]
+	record.Line = tr.line
+	tr.pending = tr.pending[1:]
+	return record, true
+}
+
+// Err 返回底层读取错误（格式错误不算）
+func (tr *TraceReader) Err() error {
+	return tr.scanner.Err()
+}
+
+// parseTraceLine 解析一行轨迹，把得到的记录追加到 buf 后返回；注释行返回空
+func parseTraceLine(text string, buf []TraceRecord) ([]TraceRecord, error) {
+	text = strings.TrimSpace(text)
+	if text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(text, "==") {
+		return buf, nil
+	}
+
+	fields := strings.Fields(text)
+	if len(fields) == 2 && strings.Contains(fields[1], ",") {
+		return parseLackey(fields[0], fields[1], buf)
+	}
+	return parseDinero(fields, buf)
+}
+
+// parseLackey 解析 Valgrind lackey 格式
+func parseLackey(op, operand string, buf []TraceRecord) ([]TraceRecord, error) {
+	addrText, sizeText, _ := strings.Cut(operand, ",")
+	address, err := parseTraceAddress(addrText)
+	if err != nil {
+		return buf, err
+	}
+	size, err := strconv.Atoi(sizeText)
+	if err != nil || size < 0 {
+		return buf, fmt.Errorf("无效的字节数 %q", sizeText)
+	}
+
+	switch op {
+	case "I":
+		return append(buf, TraceRecord{Type: Fetch, Address: address, Size: size}), nil
+	case "L":
+		return append(buf, TraceRecord{Type: Read, Address: address, Size: size}), nil
+	case "S":
+		return append(buf, TraceRecord{Type: Write, Address: address, Size: size}), nil
+	case "M":
+		// 修改 = 先读后写
+		return append(buf,
+			TraceRecord{Type: Read, Address: address, Size: size},
+			TraceRecord{Type: Write, Address: address, Size: size}), nil
+	}
+	return buf, fmt.Errorf("未知的操作 %q", op)
+}
+
+// parseDinero 解析 Dinero 格式
+func parseDinero(fields []string, buf []TraceRecord) ([]TraceRecord, error) {
+	if len(fields) < 2 || len(fields) > 3 {
+		return buf, fmt.Errorf("应为 \"<类型> <地址> [字节数]\"，实际有 %d 个字段", len(fields))
+	}
+
+	var kind AccessType
+	switch strings.ToLower(fields[0]) {
+	case "r", "0":
+		kind = Read
+	case "w", "1":
+		kind = Write
+	case "i", "2":
+		kind = Fetch
+	default:
+		return buf, fmt.Errorf("未知的访问类型 %q", fields[0])
+	}
+
+	address, err := parseTraceAddress(fields[1])
+	if err != nil {
+		return buf, err
+	}
+	size := 0
+	if len(fields) == 3 {
+		if size, err = strconv.Atoi(fields[2]); err != nil || size < 0 {
+			return buf, fmt.Errorf("无效的字节数 %q", fields[2])
+		}
+	}
+	return append(buf, TraceRecord{Type: kind, Address: address, Size: size}), nil
+}
+
+// parseTraceAddress 解析十六进制地址（可带 0x 前缀）
+func parseTraceAddress(text string) (int, error) {
+	digits := strings.TrimPrefix(strings.TrimPrefix(text, "0x"), "0X")
+	address, err := strconv.ParseUint(digits, 16, 63)
+	if err != nil {
+		return 0, fmt.Errorf("无效的十六进制地址 %q", text)
+	}
+	return int(address), nil
+}
+
+// TraceSummary 轨迹回放汇总
+type TraceSummary struct {
+	Records    int     // 有效记录数
+	Reads      int     // 读
+	Writes     int     // 写
+	Fetches    int     // 取指
+	Bytes      int64   // 访问的总字节数
+	MinAddress int     // 最小地址
+	MaxAddress int     // 最大地址
+	Ignored    int     // 模拟器无法处理而忽略的记录（如超出虚拟地址空间）
+	Skipped    int     // 格式错误而跳过的行数
+	Errors     []error // 前若干条格式错误
+}
+
+func (s *TraceSummary) add(r TraceRecord) {
+	if s.Records == 0 || r.Address < s.MinAddress {
+		s.MinAddress = r.Address
+	}
+	if r.Address > s.MaxAddress {
+		s.MaxAddress = r.Address
+	}
+	s.Records++
+	s.Bytes += int64(r.Size)
+	switch r.Type {
+	case Read:
+		s.Reads++
+	case Write:
+		s.Writes++
+	case Fetch:
+		s.Fetches++
+	}
+}
+
+// Report 生成汇总报告
+func (s *TraceSummary) Report() string {
+	var sb strings.Builder
+	sb.WriteString("\n轨迹文件汇总：\n")
+	sb.WriteString(fmt.Sprintf("  记录数:     %d（读 %d，写 %d，取指 %d）\n", s.Records, s.Reads, s.Writes, s.Fetches))
+	sb.WriteString(fmt.Sprintf("  访问字节数: %d\n", s.Bytes))
+	if s.Records > 0 {
+		sb.WriteString(fmt.Sprintf("  地址范围:   0x%X - 0x%X\n", s.MinAddress, s.MaxAddress))
+	}
+	if s.Ignored > 0 {
+		sb.WriteString(fmt.Sprintf("  忽略记录:   %d（模拟器无法处理）\n", s.Ignored))
+	}
+	if s.Skipped > 0 {
+		sb.WriteString(fmt.Sprintf("  跳过行数:   %d（格式错误）\n", s.Skipped))
+		for _, err := range s.Errors {
+			sb.WriteString(fmt.Sprintf("    %v\n", err))
+		}
+		if s.Skipped > len(s.Errors) {
+			sb.WriteString(fmt.Sprintf("    ……其余 %d 行未列出\n", s.Skipped-len(s.Errors)))
+		}
+	}
+	return sb.String()
+}
+
+// ReplayTrace 流式读取轨迹，对每条记录调用 access；access 返回 false 表示该记录被忽略
+func ReplayTrace(r io.Reader, access func(TraceRecord) bool) (*TraceSummary, error) {
+	tr := NewTraceReader(r)
+	summary := &TraceSummary{}
+	for {
+		record, ok := tr.Next()
+		if !ok {
+			break
+		}
+		summary.add(record)
+		if !access(record) {
+			summary.Ignored++
+		}
+	}
+	summary.Skipped = tr.Skipped
+	summary.Errors = tr.Errors
+	if err := tr.Err(); err != nil {
+		return summary, fmt.Errorf("读取轨迹失败: %w", err)
+	}
+	return summary, nil
+}
+
+// RunCacheTrace 用轨迹驱动 Cache 模拟器
+// 每条记录按一次访问处理（跨块的访问只访问起始地址所在的块）
+func RunCacheTrace(cache *CacheSimulator, r io.Reader) (*TraceSummary, error) {
+	return ReplayTrace(r, func(rec TraceRecord) bool {
+		cache.AccessWithType(rec.Address, rec.Type)
+		return true
+	})
+}
+
+// RunHierarchyTrace 用轨迹驱动多级 Cache（取指访问 L1I）
+func RunHierarchyTrace(h *CacheHierarchy, r io.Reader) (*TraceSummary, error) {
+	return ReplayTrace(r, func(rec TraceRecord) bool {
+		h.Access(rec.Type, rec.Address)
+		return true
+	})
+}
+
+// RunVirtualMemoryTrace 用轨迹驱动虚拟存储器模拟器
+// 超出虚拟地址空间的访问被忽略；OPT 需要预知完整的访问序列，不能用于流式回放
+func RunVirtualMemoryTrace(vms *VirtualMemorySimulator, r io.Reader) (*TraceSummary, error) {
+	if vms.Policy == PageOptimal {
+		return nil, fmt.Errorf("OPT 算法需要完整的未来访问序列，不支持流式回放")
+	}
+	limit := vms.NumPages * vms.PageSize
+	return ReplayTrace(r, func(rec TraceRecord) bool {
+		if rec.Address >= limit {
+			return false
+		}
+		vms.AccessWithType(rec.Address, rec.Type)
+		return true
+	})
+}
+
+// TraceFileExample 轨迹文件回放示例
+// 408 考点：用真实访存序列评价 Cache 与页面替换；行优先与列优先遍历的局部性差异
+func TraceFileExample() {
+	fmt.Println("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Println("  访存轨迹文件回放")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	// 1. 两种格式混合的小轨迹，包含一行错误
+	small := `# 前几行为 Valgrind lackey 格式
+==1234== Lackey, an example Valgrind tool
+I  00400100,4
+ L 00600010,4
+ M 00600010,4
+I  00400104,4
+ S 00600020,4
+# 以下为 Dinero 格式
+2 400108
+0 600010 4
+w 0x600040 4
+x 600050 4
+`
+	fmt.Println("\n【小轨迹：lackey 与 Dinero 格式混合】")
+	fmt.Print(small)
+	cache := NewCacheSimulator(CacheConfig{CacheSize: 256, BlockSize: 32, MappingType: DirectMapped})
+	summary, err := RunCacheTrace(cache, strings.NewReader(small))
+	if err != nil {
+		fmt.Println("错误:", err)
+		return
+	}
+	fmt.Print(summary.Report())
+	fmt.Println(cache.GetStatistics())
+
+	// 2. 流式生成的大轨迹：64×64 int 矩阵按行、按列求和
+	const n = 64
+	for _, order := range []string{"行优先", "列优先"} {
+		byColumn := order == "列优先"
+		pr, pw := io.Pipe()
+		go func() {
+			w := bufio.NewWriter(pw)
+			for pass := 0; pass < 8; pass++ {
+				for i := 0; i < n; i++ {
+					for j := 0; j < n; j++ {
+						row, col := i, j
+						if byColumn {
+							row, col = j, i
+						}
+						fmt.Fprintf(w, "I  00400200,4\n L %08x,4\n", 0x10000+(row*n+col)*4)
+					}
+				}
+			}
+			w.Flush()
+			pw.Close()
+		}()
+
+		fmt.Printf("\n【%s遍历 64×64 int 矩阵 8 遍（流式读取 %d 行）】\n", order, 8*n*n*2)
+		cache := NewCacheSimulator(CacheConfig{CacheSize: 4096, BlockSize: 64, Associativity: 4, MappingType: SetAssociative})
+		summary, err := RunCacheTrace(cache, pr)
+		if err != nil {
+			fmt.Println("错误:", err)
+			return
+		}
+		fmt.Print(summary.Report())
+		fmt.Printf("  Cache 命中率: %.2f%%\n", float64(cache.Hits)/float64(cache.Hits+cache.Misses)*100)
+
+		pr, pw = io.Pipe()
+		go func() {
+			w := bufio.NewWriter(pw)
+			for i := 0; i < n; i++ {
+				for j := 0; j < n; j++ {
+					row, col := i, j
+					if byColumn {
+						row, col = j, i
+					}
+					fmt.Fprintf(w, "r %x 4\n", (row*n+col)*16) // 每行 1KB，一页 4 行
+				}
+			}
+			w.Flush()
+			pw.Close()
+		}()
+		vms := NewVirtualMemorySimulator(16, 4, 2, PageLRU)
+		if _, err := RunVirtualMemoryTrace(vms, pr); err != nil {
+			fmt.Println("错误:", err)
+			return
+		}
+		fmt.Printf("  虚拟存储器（每个元素占 16B，16 页、4 个页框、LRU）缺页 %d 次 / 访问 %d 次\n",
+			vms.PageFaults, vms.AccessCount)
+	}
+}
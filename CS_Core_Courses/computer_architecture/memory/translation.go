@@ -0,0 +1,489 @@
+package memory
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+
+	"CS_Core_Courses/trace"
+)
+
+// TranslatorConfig 地址转换通路配置
+type TranslatorConfig struct {
+	PageSize         int                   // 页面大小（字节，2 的幂，默认 4096）
+	LevelBits        []int                 // 各级页表索引位数，从顶级页表开始，如 {10, 10} 为二级页表
+	PTESize          int                   // 页表项字节数（默认 4）
+	NumFrames        int                   // 物理页框数（页表本身也占用页框，至少 2 个）
+	TLBSize          int                   // TLB 表项数（全相联，LRU 替换）
+	Policy           PageReplacementPolicy // 页面替换算法：FIFO、LRU 或 Clock（其余算法按 LRU 处理）
+	Cache            CacheConfig           // 物理地址索引的数据 Cache，页表项也经由它访问
+	TLBLatency       int                   // 查 TLB 的周期数
+	CacheLatency     int                   // 访问 Cache 的周期数
+	MemoryLatency    int                   // Cache 缺失时访问主存的周期数
+	PageFaultLatency int                   // 缺页处理（调页）的周期数，换出脏页另计一次
+}
+
+// pageTableEntry 页表项：非末级指向下一级页表所在页框，末级指向数据页框
+type pageTableEntry struct {
+	Valid bool
+	Frame int
+}
+
+// frameInfo 物理页框的使用情况
+type frameInfo struct {
+	used       bool
+	pageTable  bool // 存放页表的页框常驻内存，不参与替换
+	vpn        int  // 存放的虚拟页号
+	pteTable   int  // 指向本页框的末级页表项所在的页框
+	pteIndex   int  // 及其下标
+	loadTime   int
+	lastUse    int
+	referenced bool
+	dirty      bool
+}
+
+// TranslationResult 一次访问的完整过程
+type TranslationResult struct {
+	VirtualAddress  int
+	PhysicalAddress int
+	Type            AccessType
+	TLBHit          bool
+	WalkAccesses    int  // 页表遍历中访问页表项的次数
+	WalkCacheHits   int  // 其中在 Cache 中命中的次数
+	PageFault       bool // 是否缺页
+	CacheHit        bool // 数据访问是否命中 Cache
+	Cycles          int  // 本次访问的总周期数
+	Steps           []string
+}
+
+// AddressTranslator 端到端的地址转换与访存通路
+// 408 考点：
+//   - 虚拟地址先查 TLB，命中则直接得到页框号
+//   - TLB 缺失时逐级查多级页表，页表项本身存放在主存中，访问它们同样经过 Cache
+//   - 末级页表项无效时发生缺页，由操作系统调页，内存已满时按替换算法换出一页
+//   - 得到物理地址后访问物理地址索引的 Cache
+type AddressTranslator struct {
+	Config      TranslatorConfig
+	Cache       *CacheSimulator
+	TLB         []TLBEntry
+	OffsetBits  int
+	CurrentTime int
+	Tracer      trace.Tracer // 事件输出（nil 时使用默认控制台）
+
+	Accesses      int
+	TLBHits       int
+	PageFaults    int
+	DiskWrites    int // 换出脏页的次数
+	WalkAccesses  int
+	WalkCacheHits int
+	TotalCycles   int
+	TLBCycles     int // 各阶段周期数
+	WalkCycles    int
+	FaultCycles   int
+	DataCycles    int
+
+	root         int                      // 顶级页表所在页框
+	tables       map[int][]pageTableEntry // 页框号 → 该页框中的页表
+	frames       []frameInfo
+	clockPointer int
+}
+
+// NewAddressTranslator 创建地址转换通路，顶级页表占用第 0 个页框
+// 页面大小不是 2 的幂、页框数少于 2（顶级页表之外至少要有一个页框）或索引位数无效时返回错误
+func NewAddressTranslator(config TranslatorConfig) (*AddressTranslator, error) {
+	if config.PageSize <= 0 {
+		config.PageSize = 4096
+	}
+	if config.PageSize&(config.PageSize-1) != 0 {
+		return nil, fmt.Errorf("页面大小 %d 不是 2 的幂", config.PageSize)
+	}
+	if config.NumFrames < 2 {
+		return nil, fmt.Errorf("页框数 %d 太少：顶级页表占用一个页框，至少需要 2 个", config.NumFrames)
+	}
+	if config.PTESize <= 0 {
+		config.PTESize = 4
+	}
+	if len(config.LevelBits) == 0 {
+		config.LevelBits = []int{10, 10}
+	}
+	for i, b := range config.LevelBits {
+		if b <= 0 {
+			return nil, fmt.Errorf("第 %d 级页表索引位数 %d 无效", i+1, b)
+		}
+	}
+	if config.Policy != PageFIFO && config.Policy != PageClock {
+		config.Policy = PageLRU
+	}
+
+	t := &AddressTranslator{
+		Config:     config,
+		Cache:      NewCacheSimulator(config.Cache),
+		TLB:        make([]TLBEntry, config.TLBSize),
+		OffsetBits: bits.TrailingZeros(uint(config.PageSize)),
+		tables:     make(map[int][]pageTableEntry),
+		frames:     make([]frameInfo, config.NumFrames),
+	}
+	t.Cache.Tracer = trace.Discard
+	t.root = 0
+	t.frames[0] = frameInfo{used: true, pageTable: true}
+	t.tables[0] = make([]pageTableEntry, 1<<config.LevelBits[0])
+	return t, nil
+}
+
+// AddressBits 虚拟地址位数
+func (t *AddressTranslator) AddressBits() int {
+	n := t.OffsetBits
+	for _, b := range t.Config.LevelBits {
+		n += b
+	}
+	return n
+}
+
+// Access 访问一个虚拟地址，返回完整过程和周期数
+func (t *AddressTranslator) Access(virtualAddress int, kind AccessType) (TranslationResult, error) {
+	if virtualAddress < 0 || virtualAddress >= 1<<t.AddressBits() {
+		return TranslationResult{}, fmt.Errorf("虚拟地址 0x%X 超出 %d 位地址空间", virtualAddress, t.AddressBits())
+	}
+
+	t.Accesses++
+	t.CurrentTime++
+	res := TranslationResult{VirtualAddress: virtualAddress, Type: kind}
+	vpn := virtualAddress >> t.OffsetBits
+	offset := virtualAddress & (t.Config.PageSize - 1)
+
+	// 1. 查 TLB
+	res.Cycles += t.Config.TLBLatency
+	t.TLBCycles += t.Config.TLBLatency
+	frame, hit := t.searchTLB(vpn)
+	if hit {
+		res.TLBHit = true
+		t.TLBHits++
+		res.Steps = append(res.Steps, fmt.Sprintf("TLB 命中: 页号 %d → 页框 %d (+%d)", vpn, frame, t.Config.TLBLatency))
+	} else {
+		res.Steps = append(res.Steps, fmt.Sprintf("TLB 缺失: 页号 %d (+%d)", vpn, t.Config.TLBLatency))
+		// 2. 页表遍历（可能缺页）
+		var err error
+		if frame, err = t.walk(vpn, &res); err != nil {
+			return res, err
+		}
+		t.updateTLB(vpn, frame)
+	}
+
+	info := &t.frames[frame]
+	info.lastUse = t.CurrentTime
+	info.referenced = true
+	if kind == Write {
+		info.dirty = true
+	}
+
+	// 3. 用物理地址访问 Cache
+	res.PhysicalAddress = frame<<t.OffsetBits | offset
+	cacheHit, _ := t.Cache.AccessWithType(res.PhysicalAddress, kind)
+	cost := t.memoryCost(cacheHit)
+	res.CacheHit = cacheHit
+	res.Cycles += cost
+	t.DataCycles += cost
+	res.Steps = append(res.Steps, fmt.Sprintf("访问数据 物理地址 0x%X: Cache %s (+%d)", res.PhysicalAddress, hitText(cacheHit), cost))
+
+	t.TotalCycles += res.Cycles
+	trace.Emit(t.Tracer, trace.Event{
+		Cycle:     t.CurrentTime,
+		Component: "translation",
+		Kind:      "access",
+		Fields: map[string]any{
+			"virtual":    virtualAddress,
+			"physical":   res.PhysicalAddress,
+			"tlb_hit":    res.TLBHit,
+			"page_fault": res.PageFault,
+			"cache_hit":  res.CacheHit,
+			"cycles":     res.Cycles,
+		},
+	})
+	return res, nil
+}
+
+// walk 逐级查页表，返回数据页框号；缺少的页表和页面在此分配
+func (t *AddressTranslator) walk(vpn int, res *TranslationResult) (int, error) {
+	table := t.root
+	levels := t.Config.LevelBits
+	for level, bitsAtLevel := range levels {
+		shift := 0
+		for _, b := range levels[level+1:] {
+			shift += b
+		}
+		index := vpn >> shift & (1<<bitsAtLevel - 1)
+
+		// 页表项在物理内存中，访问它要经过 Cache
+		pteAddress := table<<t.OffsetBits + index*t.Config.PTESize
+		hit, _ := t.Cache.Access(pteAddress)
+		cost := t.memoryCost(hit)
+		res.Cycles += cost
+		res.WalkAccesses++
+		t.WalkAccesses++
+		t.WalkCycles += cost
+		if hit {
+			res.WalkCacheHits++
+			t.WalkCacheHits++
+		}
+		res.Steps = append(res.Steps, fmt.Sprintf("查 %d 级页表[%d] 页表项地址 0x%X: Cache %s (+%d)",
+			level+1, index, pteAddress, hitText(hit), cost))
+
+		entry := t.tables[table][index]
+		if !entry.Valid {
+			if !res.PageFault {
+				res.PageFault = true
+				t.PageFaults++
+				res.Cycles += t.Config.PageFaultLatency
+				t.FaultCycles += t.Config.PageFaultLatency
+				res.Steps = append(res.Steps, fmt.Sprintf("  页表项无效，缺页中断 (+%d)", t.Config.PageFaultLatency))
+			}
+			frame, err := t.allocateFrame(res)
+			if err != nil {
+				return -1, err
+			}
+			if level < len(levels)-1 {
+				t.frames[frame] = frameInfo{used: true, pageTable: true}
+				t.tables[frame] = make([]pageTableEntry, 1<<levels[level+1])
+				res.Steps = append(res.Steps, fmt.Sprintf("  分配 %d 级页表，放在页框 %d", level+2, frame))
+			} else {
+				t.frames[frame] = frameInfo{
+					used: true, vpn: vpn, pteTable: table, pteIndex: index,
+					loadTime: t.CurrentTime, lastUse: t.CurrentTime,
+				}
+				res.Steps = append(res.Steps, fmt.Sprintf("  调入页面 %d 到页框 %d", vpn, frame))
+			}
+			entry = pageTableEntry{Valid: true, Frame: frame}
+			t.tables[table][index] = entry
+		}
+		table = entry.Frame
+	}
+	return table, nil
+}
+
+// allocateFrame 分配一个页框，没有空闲页框时按替换算法换出一个数据页
+func (t *AddressTranslator) allocateFrame(res *TranslationResult) (int, error) {
+	for i := range t.frames {
+		if !t.frames[i].used {
+			return i, nil
+		}
+	}
+
+	victim := t.selectVictim()
+	if victim < 0 {
+		return -1, fmt.Errorf("物理页框不足：%d 个页框全部被页表占用", len(t.frames))
+	}
+	info := t.frames[victim]
+
+	// 使页表项和 TLB 项失效，并清除该页框在 Cache 中的块
+	t.tables[info.pteTable][info.pteIndex].Valid = false
+	for i := range t.TLB {
+		if t.TLB[i].Valid && t.TLB[i].PageNumber == info.vpn {
+			t.TLB[i].Valid = false
+		}
+	}
+	base := victim << t.OffsetBits
+	for addr := base; addr < base+t.Config.PageSize; addr += t.Cache.Config.BlockSize {
+		if found, dirty := t.Cache.Invalidate(addr); found && dirty {
+			t.Cache.Writebacks++
+			info.dirty = true
+		}
+	}
+
+	step := fmt.Sprintf("  内存已满，换出页面 %d（页框 %d）", info.vpn, victim)
+	if info.dirty {
+		t.DiskWrites++
+		res.Cycles += t.Config.PageFaultLatency
+		t.FaultCycles += t.Config.PageFaultLatency
+		step += fmt.Sprintf("，脏页写回磁盘 (+%d)", t.Config.PageFaultLatency)
+	}
+	res.Steps = append(res.Steps, step)
+	t.frames[victim] = frameInfo{}
+	return victim, nil
+}
+
+// selectVictim 在数据页中选择被换出的页框，没有可换出的页框时返回 -1
+func (t *AddressTranslator) selectVictim() int {
+	candidate := func(i int) bool {
+		return t.frames[i].used && !t.frames[i].pageTable
+	}
+
+	if t.Config.Policy == PageClock {
+		for scanned := 0; scanned < 2*len(t.frames); scanned++ {
+			i := t.clockPointer
+			t.clockPointer = (t.clockPointer + 1) % len(t.frames)
+			if !candidate(i) {
+				continue
+			}
+			if !t.frames[i].referenced {
+				return i
+			}
+			t.frames[i].referenced = false
+		}
+		return -1
+	}
+
+	victim := -1
+	for i := range t.frames {
+		if !candidate(i) {
+			continue
+		}
+		key, best := t.frames[i].lastUse, 0
+		if victim >= 0 {
+			best = t.frames[victim].lastUse
+		}
+		if t.Config.Policy == PageFIFO {
+			key = t.frames[i].loadTime
+			if victim >= 0 {
+				best = t.frames[victim].loadTime
+			}
+		}
+		if victim < 0 || key < best {
+			victim = i
+		}
+	}
+	return victim
+}
+
+// searchTLB 在 TLB 中查找页号
+func (t *AddressTranslator) searchTLB(vpn int) (int, bool) {
+	for i := range t.TLB {
+		if t.TLB[i].Valid && t.TLB[i].PageNumber == vpn {
+			t.TLB[i].AccessTime = t.CurrentTime
+			return t.TLB[i].FrameNumber, true
+		}
+	}
+	return -1, false
+}
+
+// updateTLB 把页号到页框号的映射装入 TLB（LRU 替换）
+func (t *AddressTranslator) updateTLB(vpn, frame int) {
+	if len(t.TLB) == 0 {
+		return
+	}
+	victim := 0
+	for i := range t.TLB {
+		if !t.TLB[i].Valid {
+			victim = i
+			break
+		}
+		if t.TLB[i].AccessTime < t.TLB[victim].AccessTime {
+			victim = i
+		}
+	}
+	t.TLB[victim] = TLBEntry{Valid: true, PageNumber: vpn, FrameNumber: frame, AccessTime: t.CurrentTime}
+}
+
+// memoryCost 一次经过 Cache 的访存周期数
+func (t *AddressTranslator) memoryCost(cacheHit bool) int {
+	if cacheHit {
+		return t.Config.CacheLatency
+	}
+	return t.Config.CacheLatency + t.Config.MemoryLatency
+}
+
+func hitText(hit bool) string {
+	if hit {
+		return "命中"
+	}
+	return "缺失"
+}
+
+// PrintResult 打印一次访问的各个步骤
+func (res TranslationResult) PrintResult() {
+	fmt.Printf("\n%s 虚拟地址 0x%X → 物理地址 0x%X，共 %d 周期\n",
+		res.Type, res.VirtualAddress, res.PhysicalAddress, res.Cycles)
+	for _, step := range res.Steps {
+		fmt.Println("  " + step)
+	}
+}
+
+// GetStatistics 获取统计信息
+func (t *AddressTranslator) GetStatistics() string {
+	if t.Accesses == 0 {
+		return "\n地址转换统计：尚无访问\n"
+	}
+	n := float64(t.Accesses)
+	var sb strings.Builder
+	sb.WriteString("\n地址转换统计：\n")
+	sb.WriteString(fmt.Sprintf("  访问次数:     %d\n", t.Accesses))
+	sb.WriteString(fmt.Sprintf("  TLB 命中率:   %.2f%%\n", float64(t.TLBHits)/n*100))
+	sb.WriteString(fmt.Sprintf("  页表项访问:   %d 次（Cache 命中 %d 次）\n", t.WalkAccesses, t.WalkCacheHits))
+	sb.WriteString(fmt.Sprintf("  缺页次数:     %d（缺页率 %.2f%%），脏页写回 %d 次\n",
+		t.PageFaults, float64(t.PageFaults)/n*100, t.DiskWrites))
+	sb.WriteString(fmt.Sprintf("  数据 Cache:   命中率 %.2f%%（含页表项访问）\n",
+		float64(t.Cache.Hits)/float64(t.Cache.AccessCount)*100))
+	sb.WriteString(fmt.Sprintf("  总周期数:     %d，平均每次访问 %.2f 周期\n", t.TotalCycles, float64(t.TotalCycles)/n))
+	sb.WriteString(fmt.Sprintf("    查 TLB %d + 页表遍历 %d + 缺页处理 %d + 访问数据 %d\n",
+		t.TLBCycles, t.WalkCycles, t.FaultCycles, t.DataCycles))
+	return sb.String()
+}
+
+// AddressTranslationExample 地址转换全过程示例
+// 408 考点：TLB、多级页表、缺页处理与 Cache 组成的完整访存过程及其时间开销
+func AddressTranslationExample() {
+	fmt.Println("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Println("  TLB → 多级页表 → Cache 完整访存通路")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	config := TranslatorConfig{
+		PageSize:         4096,
+		LevelBits:        []int{4, 4},
+		NumFrames:        10,
+		TLBSize:          4,
+		Policy:           PageLRU,
+		Cache:            CacheConfig{CacheSize: 1024, BlockSize: 64, Associativity: 2, MappingType: SetAssociative},
+		TLBLatency:       1,
+		CacheLatency:     2,
+		MemoryLatency:    50,
+		PageFaultLatency: 5000,
+	}
+	t, err := NewAddressTranslator(config)
+	if err != nil {
+		fmt.Println("错误:", err)
+		return
+	}
+	fmt.Printf("\n配置：20 位虚拟地址（二级页表各 4 位 + 12 位页内偏移），%d 个页框，TLB %d 项，LRU 换页\n",
+		config.NumFrames, config.TLBSize)
+	fmt.Printf("      1KB 二路组相联物理 Cache；TLB %d、Cache %d、主存 %d、缺页 %d 周期\n",
+		config.TLBLatency, config.CacheLatency, config.MemoryLatency, config.PageFaultLatency)
+
+	accesses := []MemoryAccess{
+		{Read, 0x01040}, {Read, 0x01044}, {Write, 0x01080}, // 同一页：第一次缺页，之后 TLB 命中
+		{Read, 0x020C0}, {Read, 0x13100}, {Write, 0x14140}, // 新页；0x13100 需要新的二级页表
+		{Read, 0x15180}, {Read, 0x161C0}, {Read, 0x01048}, // TLB 只有 4 项，页面 0x01 的 TLB 项已被替换，但仍在内存中
+		{Read, 0x27040}, {Read, 0x28080}, {Read, 0x290C0}, // 页框用完后按 LRU 换页，页面 0x14 被写过，换出时写回磁盘
+		{Read, 0x14140}, {Read, 0x290C4},
+	}
+
+	fmt.Println("\n  序号  操作  虚拟地址  TLB   页表项访问   缺页  物理地址  Cache  周期")
+	var results []TranslationResult
+	for i, a := range accesses {
+		res, err := t.Access(a.Address, a.Type)
+		if err != nil {
+			fmt.Println("错误:", err)
+			return
+		}
+		results = append(results, res)
+		walk := "-"
+		if !res.TLBHit {
+			walk = fmt.Sprintf("%d（命中 %d）", res.WalkAccesses, res.WalkCacheHits)
+		}
+		fault := "否"
+		if res.PageFault {
+			fault = "是"
+		}
+		fmt.Printf("  %4d  %s  0x%05X   %s  %s  %s    0x%05X   %s   %d\n",
+			i+1, padCJK(a.Type.String(), 4), a.Address, padCJK(hitText(res.TLBHit), 4),
+			padCJK(walk, 11), fault, res.PhysicalAddress, hitText(res.CacheHit), res.Cycles)
+	}
+
+	fmt.Println("\n【逐步过程：缺页、TLB 命中、TLB 缺失但页表命中、换出脏页】")
+	results[0].PrintResult()
+	results[1].PrintResult()
+	results[8].PrintResult()
+	results[11].PrintResult()
+
+	fmt.Print(t.GetStatistics())
+	fmt.Println("\n说明：TLB 命中时只需一次 Cache 访问；TLB 缺失时每级页表多一次访存，页表项常在 Cache 中命中；")
+	fmt.Println("      缺页的代价比其他所有开销高出几个数量级，决定了平均访存时间")
+}
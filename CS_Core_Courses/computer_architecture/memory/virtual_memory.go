@@ -7,12 +7,14 @@ import (
 // PageTableEntry 页表项
 // 408 考点：页表项的结构（页框号、有效位、修改位、访问位）
 type PageTableEntry struct {
-	FrameNumber    int  // 页框号（物理页号）
-	Valid          bool // 有效位：该页是否在内存中
-	Modified       bool // 修改位（脏位）：该页是否被修改过
-	Referenced     bool // 访问位：该页最近是否被访问过
-	LoadTime       int  // 装入时间：用于 FIFO
-	LastAccessTime int  // 最后访问时间：用于 LRU
+	FrameNumber    int   // 页框号（物理页号）
+	Valid          bool  // 有效位：该页是否在内存中
+	Modified       bool  // 修改位（脏位）：该页是否被修改过
+	Referenced     bool  // 访问位：该页最近是否被访问过
+	LoadTime       int   // 装入时间：用于 FIFO
+	LastAccessTime int   // 最后访问时间：用于 LRU、工作集
+	Frequency      int   // 装入后的访问次数：用于 LFU
+	Age            uint8 // 老化计数器：用于 Aging
 }
 
 // TLBEntry TLB 表项
@@ -28,10 +30,20 @@ type TLBEntry struct {
 type PageReplacementPolicy int
 
 const (
-	PageFIFO    PageReplacementPolicy = iota // 先进先出
-	PageLRU                                  // 最近最少使用
-	PageClock                                // 时钟算法（NRU）
-	PageOptimal                              // 最佳置换算法
+	PageFIFO          PageReplacementPolicy = iota // 先进先出
+	PageLRU                                        // 最近最少使用
+	PageClock                                      // 时钟算法（NRU）
+	PageOptimal                                    // 最佳置换算法
+	PageEnhancedClock                              // 改进型时钟算法（访问位 + 修改位）
+	PageLFU                                        // 最不经常使用
+	PageAging                                      // 老化算法（LRU 的近似）
+	PageWSClock                                    // 工作集时钟算法
+)
+
+// 默认参数
+const (
+	DefaultWorkingSetWindow = 4 // 工作集窗口 Δ（访问次数）
+	DefaultAgingInterval    = 1 // 老化算法的时钟中断间隔（访问次数）
 )
 
 // VirtualMemorySimulator 虚拟存储器模拟器
@@ -52,6 +64,9 @@ type VirtualMemorySimulator struct {
 	AccessCount        int                   // 总访问次数
 	FutureAccesses     []int                 // 未来访问序列（用于 OPT 算法）
 	CurrentAccessIndex int                   // 当前访问索引
+	WriteBacks         int                   // 换出脏页的写回次数
+	WorkingSetWindow   int                   // 工作集窗口 Δ（用于 WSClock）
+	AgingInterval      int                   // 时钟中断间隔（用于 Aging，每次中断计数器右移一位）
 }
 
 // NewVirtualMemorySimulator 创建虚拟存储器模拟器
@@ -91,6 +106,9 @@ func NewVirtualMemorySimulator(numPages, numFrames, tlbSize int, policy PageRepl
 		TLBHits:        0,
 		TLBMisses:      0,
 		AccessCount:    0,
+
+		WorkingSetWindow: DefaultWorkingSetWindow,
+		AgingInterval:    DefaultAgingInterval,
 	}
 }
 
@@ -100,9 +118,20 @@ func (vms *VirtualMemorySimulator) SetFutureAccesses(accesses []int) {
 	vms.CurrentAccessIndex = 0
 }
 
-// Access 访问虚拟地址
-// 408 考点：地址转换过程（TLB → 页表 → 缺页处理）
+// Access 读访问虚拟地址
 func (vms *VirtualMemorySimulator) Access(virtualAddress int) string {
+	return vms.AccessWithType(virtualAddress, Read)
+}
+
+// Write 写访问虚拟地址，置页面的修改位
+func (vms *VirtualMemorySimulator) Write(virtualAddress int) string {
+	return vms.AccessWithType(virtualAddress, Write)
+}
+
+// AccessWithType 按访问类型访问虚拟地址
+// 408 考点：地址转换过程（TLB → 页表 → 缺页处理）；写访问置修改位，换出脏页需写回磁盘
+func (vms *VirtualMemorySimulator) AccessWithType(virtualAddress int, accessType AccessType) string {
+	defer vms.afterAccess()
 	vms.AccessCount++
 	vms.CurrentTime++
 	if vms.Policy == PageOptimal {
@@ -123,8 +152,7 @@ func (vms *VirtualMemorySimulator) Access(virtualAddress int) string {
 		physicalAddress := frameNumber*vms.PageSize + offset
 		result += fmt.Sprintf("  ✓ TLB 命中! 页框号: %d, 物理地址: 0x%04X\n",
 			frameNumber, physicalAddress)
-		vms.PageTable[pageNumber].Referenced = true
-		vms.PageTable[pageNumber].LastAccessTime = vms.CurrentTime
+		vms.touch(pageNumber, accessType)
 		return result
 	}
 
@@ -144,8 +172,7 @@ func (vms *VirtualMemorySimulator) Access(virtualAddress int) string {
 		result += "  → 更新 TLB\n"
 
 		// 更新访问信息
-		vms.PageTable[pageNumber].Referenced = true
-		vms.PageTable[pageNumber].LastAccessTime = vms.CurrentTime
+		vms.touch(pageNumber, accessType)
 	} else {
 		// 4. 缺页中断
 		vms.PageFaults++
@@ -167,10 +194,12 @@ func (vms *VirtualMemorySimulator) Access(virtualAddress int) string {
 			// 如果页面被修改过，需要写回磁盘
 			if vms.PageTable[oldPage].Modified {
 				result += fmt.Sprintf("  → 页面 %d 已修改，写回磁盘\n", oldPage)
+				vms.WriteBacks++
 			}
 			// 更新旧页表项
 			vms.PageTable[oldPage].Valid = false
 			vms.PageTable[oldPage].FrameNumber = -1
+			vms.invalidateTLB(oldPage)
 		}
 
 		// 调入新页面
@@ -178,9 +207,10 @@ func (vms *VirtualMemorySimulator) Access(virtualAddress int) string {
 		vms.PageTable[pageNumber].Valid = true
 		vms.PageTable[pageNumber].FrameNumber = frameNumber
 		vms.PageTable[pageNumber].Modified = false
-		vms.PageTable[pageNumber].Referenced = true
 		vms.PageTable[pageNumber].LoadTime = vms.CurrentTime
-		vms.PageTable[pageNumber].LastAccessTime = vms.CurrentTime
+		vms.PageTable[pageNumber].Frequency = 0
+		vms.PageTable[pageNumber].Age = 0
+		vms.touch(pageNumber, accessType)
 
 		physicalAddress := frameNumber*vms.PageSize + offset
 		result += fmt.Sprintf("  → 页面 %d 调入页框 %d, 物理地址: 0x%04X\n",
@@ -194,6 +224,57 @@ func (vms *VirtualMemorySimulator) Access(virtualAddress int) string {
 	return result
 }
 
+// touch 记录一次对驻留页面的访问：置访问位，写访问同时置修改位
+func (vms *VirtualMemorySimulator) touch(pageNumber int, accessType AccessType) {
+	entry := &vms.PageTable[pageNumber]
+	entry.Referenced = true
+	entry.LastAccessTime = vms.CurrentTime
+	entry.Frequency++
+	if accessType == Write {
+		entry.Modified = true
+	}
+}
+
+// afterAccess 每次访问结束时的处理：老化算法按时钟中断更新计数器
+// 408 考点：老化算法中每次时钟中断把计数器右移一位，访问位移入最高位后清零
+func (vms *VirtualMemorySimulator) afterAccess() {
+	if vms.Policy != PageAging || vms.AgingInterval <= 0 || vms.CurrentTime%vms.AgingInterval != 0 {
+		return
+	}
+	for _, pageNum := range vms.PhysicalMemory {
+		if pageNum == -1 {
+			continue
+		}
+		entry := &vms.PageTable[pageNum]
+		entry.Age >>= 1
+		if entry.Referenced {
+			entry.Age |= 0x80
+		}
+		entry.Referenced = false
+	}
+}
+
+// invalidateTLB 页面换出后使其 TLB 项失效
+func (vms *VirtualMemorySimulator) invalidateTLB(pageNumber int) {
+	for i := range vms.TLB {
+		if vms.TLB[i].Valid && vms.TLB[i].PageNumber == pageNumber {
+			vms.TLB[i].Valid = false
+		}
+	}
+}
+
+// WorkingSet 返回当前工作集：最近 Δ 次访问中访问过的页面（按页号升序）
+// 408 考点：工作集 W(t, Δ) 是在时刻 t 之前 Δ 个时间单位内访问过的页面集合
+func (vms *VirtualMemorySimulator) WorkingSet() []int {
+	var pages []int
+	for pageNum, entry := range vms.PageTable {
+		if entry.LastAccessTime > 0 && vms.CurrentTime-entry.LastAccessTime < vms.WorkingSetWindow {
+			pages = append(pages, pageNum)
+		}
+	}
+	return pages
+}
+
 // parseVirtualAddress 解析虚拟地址
 func (vms *VirtualMemorySimulator) parseVirtualAddress(address int) (pageNumber, offset int) {
 	offset = address % vms.PageSize
@@ -238,7 +319,7 @@ func (vms *VirtualMemorySimulator) updateTLB(pageNumber, frameNumber int) {
 }
 
 // selectVictimFrame 选择被替换的页框
-// 408 考点：四种页面替换算法
+// 408 考点：常见页面替换算法
 func (vms *VirtualMemorySimulator) selectVictimFrame() int {
 	// 首先查找空闲页框
 	for i := range vms.PhysicalMemory {
@@ -257,6 +338,14 @@ func (vms *VirtualMemorySimulator) selectVictimFrame() int {
 		return vms.selectClock()
 	case PageOptimal:
 		return vms.selectOptimal()
+	case PageEnhancedClock:
+		return vms.selectEnhancedClock()
+	case PageLFU:
+		return vms.selectLFU()
+	case PageAging:
+		return vms.selectAging()
+	case PageWSClock:
+		return vms.selectWSClock()
 	default:
 		return 0
 	}
@@ -313,6 +402,84 @@ func (vms *VirtualMemorySimulator) selectClock() int {
 	}
 }
 
+// selectEnhancedClock 改进型 Clock 算法：按 (访问位 A, 修改位 M) 分为四类
+// 408 考点：第一轮找 (0,0)，不修改访问位；第二轮找 (0,1)，扫过的页面访问位清 0；
+// 仍未找到则重复，优先换出未修改的页面以减少写回磁盘
+func (vms *VirtualMemorySimulator) selectEnhancedClock() int {
+	for {
+		for i := 0; i < vms.NumFrames; i++ {
+			frame := (vms.ClockPointer + i) % vms.NumFrames
+			entry := vms.PageTable[vms.PhysicalMemory[frame]]
+			if !entry.Referenced && !entry.Modified {
+				vms.ClockPointer = (frame + 1) % vms.NumFrames
+				return frame
+			}
+		}
+		for i := 0; i < vms.NumFrames; i++ {
+			frame := (vms.ClockPointer + i) % vms.NumFrames
+			entry := &vms.PageTable[vms.PhysicalMemory[frame]]
+			if !entry.Referenced && entry.Modified {
+				vms.ClockPointer = (frame + 1) % vms.NumFrames
+				return frame
+			}
+			entry.Referenced = false
+		}
+	}
+}
+
+// selectLFU LFU 算法：选择装入后访问次数最少的页面，次数相同时换出最早装入的
+func (vms *VirtualMemorySimulator) selectLFU() int {
+	victim := 0
+	for i, pageNum := range vms.PhysicalMemory {
+		entry, best := vms.PageTable[pageNum], vms.PageTable[vms.PhysicalMemory[victim]]
+		if entry.Frequency < best.Frequency ||
+			(entry.Frequency == best.Frequency && entry.LoadTime < best.LoadTime) {
+			victim = i
+		}
+	}
+	return victim
+}
+
+// selectAging 老化算法：选择计数器最小的页面
+// 计数器相同时优先换出本周期内未被访问的页面，再按页框顺序
+func (vms *VirtualMemorySimulator) selectAging() int {
+	victim := 0
+	for i, pageNum := range vms.PhysicalMemory {
+		entry, best := vms.PageTable[pageNum], vms.PageTable[vms.PhysicalMemory[victim]]
+		if entry.Age < best.Age || (entry.Age == best.Age && !entry.Referenced && best.Referenced) {
+			victim = i
+		}
+	}
+	return victim
+}
+
+// selectWSClock 工作集时钟算法：换出不在工作集中（超过 Δ 未访问）且未修改的页面
+// 扫过的访问位为 1 的页面清 0；不在工作集中的脏页先调度写回（清修改位）再继续扫描；
+// 转两圈仍未找到时换出最久未访问的页面
+func (vms *VirtualMemorySimulator) selectWSClock() int {
+	for scanned := 0; scanned < 2*vms.NumFrames; scanned++ {
+		frame := vms.ClockPointer
+		vms.ClockPointer = (vms.ClockPointer + 1) % vms.NumFrames
+		entry := &vms.PageTable[vms.PhysicalMemory[frame]]
+		if entry.Referenced {
+			entry.Referenced = false
+			continue
+		}
+		if vms.CurrentTime-entry.LastAccessTime <= vms.WorkingSetWindow {
+			continue
+		}
+		if !entry.Modified {
+			return frame
+		}
+		entry.Modified = false
+		vms.WriteBacks++
+	}
+
+	victim := vms.selectLRU()
+	vms.ClockPointer = (victim + 1) % vms.NumFrames
+	return victim
+}
+
 // selectOptimal 最佳置换算法（OPT）：选择未来最长时间不访问的页面
 // 408 考点：理论最优算法，实际无法实现
 func (vms *VirtualMemorySimulator) selectOptimal() int {
@@ -374,11 +541,12 @@ func (vms *VirtualMemorySimulator) GetStatistics() string {
   TLB 大小:     %d
   页面大小:     %d 字节
   替换算法:     %s
+  脏页写回次数: %d
 `,
 		vms.AccessCount, vms.PageFaults, pageFaultRate,
 		vms.TLBHits, vms.TLBMisses, tlbHitRate,
 		vms.NumPages, vms.NumFrames, vms.TLBSize, vms.PageSize,
-		getPolicyNameVM(vms.Policy))
+		getPolicyNameVM(vms.Policy), vms.WriteBacks)
 }
 
 func getPolicyNameVM(policy PageReplacementPolicy) string {
@@ -391,6 +559,14 @@ func getPolicyNameVM(policy PageReplacementPolicy) string {
 		return "Clock (时钟算法/NRU)"
 	case PageOptimal:
 		return "OPT (最佳置换)"
+	case PageEnhancedClock:
+		return "改进型 Clock (A, M)"
+	case PageLFU:
+		return "LFU (最不经常使用)"
+	case PageAging:
+		return "Aging (老化算法)"
+	case PageWSClock:
+		return "WSClock (工作集时钟)"
 	default:
 		return "未知"
 	}
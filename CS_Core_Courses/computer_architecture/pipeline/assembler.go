@@ -0,0 +1,440 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 汇编器：把 MIPS 风格的汇编文本翻译为 []Instruction，
+// 便于直接把教材/真题中的指令序列交给 NewPipelineSimulator 模拟
+//
+// 支持的写法：
+//
+//	loop:  lw   $t1, 0($t0)     # 注释以 # 或 ; 或 // 开头
+//	       add  R3, R1, R2
+//	       beq  $t0, $t2, done
+//	       j    loop
+//	done:  sw   $s0, 8($a0)
+//
+// 寄存器可写作 R1 / r1 / $1 / $t0，统一规范为 "R<编号>" 以便检测相关性；
+// R0（$zero）恒为 0，读写它都不产生数据相关。
+//
+// 模拟器按给定顺序执行指令（相当于一条动态指令轨迹），条件分支默认不跳转，
+// 需要模拟跳转时可在汇编后设置对应指令的 Taken 字段；j 总是跳转。
+
+// opFormat 指令的操作数格式
+type opFormat int
+
+const (
+	fmtNone   opFormat = iota // nop
+	fmtR                      // rd, rs, rt
+	fmtI                      // rt, rs, imm
+	fmtLUI                    // rt, imm
+	fmtLoad                   // rt, offset(rs)
+	fmtStore                  // rt, offset(rs)
+	fmtBranch                 // rs, rt, label
+	fmtJump                   // label
+)
+
+// opcodes 支持的助记符及其格式
+var opcodes = map[string]opFormat{
+	"nop":   fmtNone,
+	"add":   fmtR,
+	"addu":  fmtR,
+	"sub":   fmtR,
+	"subu":  fmtR,
+	"and":   fmtR,
+	"or":    fmtR,
+	"xor":   fmtR,
+	"nor":   fmtR,
+	"slt":   fmtR,
+	"sltu":  fmtR,
+	"mul":   fmtR,
+	"div":   fmtR,
+	"addi":  fmtI,
+	"addiu": fmtI,
+	"andi":  fmtI,
+	"ori":   fmtI,
+	"xori":  fmtI,
+	"slti":  fmtI,
+	"lui":   fmtLUI,
+	"lw":    fmtLoad,
+	"sw":    fmtStore,
+	"beq":   fmtBranch,
+	"bne":   fmtBranch,
+	"j":     fmtJump,
+}
+
+// opTypes 需要多周期功能部件的指令（mul/div 采用三操作数写法）
+var opTypes = map[string]InstructionType{
+	"mul": TypeMul,
+	"div": TypeDiv,
+}
+
+// operandCount 各格式的操作数个数
+var operandCount = map[opFormat]int{
+	fmtNone:   0,
+	fmtR:      3,
+	fmtI:      3,
+	fmtLUI:    2,
+	fmtLoad:   2,
+	fmtStore:  2,
+	fmtBranch: 3,
+	fmtJump:   1,
+}
+
+// registerNames MIPS 寄存器别名 -> 编号
+var registerNames = map[string]int{
+	"zero": 0, "at": 1, "v0": 2, "v1": 3,
+	"a0": 4, "a1": 5, "a2": 6, "a3": 7,
+	"t0": 8, "t1": 9, "t2": 10, "t3": 11, "t4": 12, "t5": 13, "t6": 14, "t7": 15,
+	"s0": 16, "s1": 17, "s2": 18, "s3": 19, "s4": 20, "s5": 21, "s6": 22, "s7": 23,
+	"t8": 24, "t9": 25, "k0": 26, "k1": 27,
+	"gp": 28, "sp": 29, "fp": 30, "ra": 31,
+}
+
+// String 返回指令类型名称
+func (t InstructionType) String() string {
+	switch t {
+	case TypeALU:
+		return "ALU"
+	case TypeLoad:
+		return "Load"
+	case TypeStore:
+		return "Store"
+	case TypeBranch:
+		return "Branch"
+	case TypeMul:
+		return "Mul"
+	case TypeDiv:
+		return "Div"
+	}
+	return "??"
+}
+
+// pendingTarget 等待回填检查的分支目标
+type pendingTarget struct {
+	line  int
+	label string
+}
+
+// CodeBase 汇编代码的起始地址。不从 0 开始，因为 Instruction.PC 为 0 表示未指定地址
+const CodeBase = 0x1000
+
+// Assemble 把汇编文本翻译为指令序列
+// 指令依次编号为 I1, I2, ...，PC 从 CodeBase 开始每条加 4。
+// 格式错误不会中止翻译，所有错误带行号合并返回；有错误时指令序列为 nil
+func Assemble(source string) ([]Instruction, error) {
+	var (
+		instructions []Instruction
+		errs         []error
+		targets      []pendingTarget
+	)
+	labels := make(map[string]int) // 标签 -> 定义所在行号
+
+	for i, raw := range strings.Split(source, "\n") {
+		lineNo := i + 1
+		fail := func(format string, args ...any) {
+			errs = append(errs, fmt.Errorf("第 %d 行: %s", lineNo, fmt.Sprintf(format, args...)))
+		}
+
+		text := strings.TrimSpace(stripComment(raw))
+
+		// 行首可以有一个或多个标签
+		for {
+			colon := strings.Index(text, ":")
+			if colon < 0 {
+				break
+			}
+			label := strings.TrimSpace(text[:colon])
+			if !isIdentifier(label) {
+				fail("无效的标签 %q", label)
+			} else if prev, ok := labels[label]; ok {
+				fail("标签 %q 重复定义（第 %d 行已定义）", label, prev)
+			} else {
+				labels[label] = lineNo
+			}
+			text = strings.TrimSpace(text[colon+1:])
+		}
+		if text == "" {
+			continue
+		}
+
+		instr, err := parseInstruction(text)
+		if err != nil {
+			fail("%v", err)
+			continue
+		}
+		if instr.Target != "" {
+			targets = append(targets, pendingTarget{line: lineNo, label: instr.Target})
+		}
+
+		instr.ID = len(instructions) + 1
+		instr.Name = fmt.Sprintf("I%d", instr.ID)
+		instr.PC = CodeBase + (instr.ID-1)*4
+		instructions = append(instructions, instr)
+	}
+
+	for _, t := range targets {
+		if _, ok := labels[t.label]; !ok {
+			errs = append(errs, fmt.Errorf("第 %d 行: 未定义的标签 %q", t.line, t.label))
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return instructions, nil
+}
+
+// parseInstruction 解析一条去掉标签和注释的指令
+func parseInstruction(text string) (Instruction, error) {
+	mnemonic := strings.Fields(text)[0]
+	rest := text[len(mnemonic):]
+	mnemonic = strings.ToLower(mnemonic)
+
+	format, ok := opcodes[mnemonic]
+	if !ok {
+		return Instruction{}, fmt.Errorf("不支持的指令 %q", mnemonic)
+	}
+
+	operands := splitOperands(rest)
+	if want := operandCount[format]; len(operands) != want {
+		return Instruction{}, fmt.Errorf("%s 需要 %d 个操作数，实际为 %d 个", mnemonic, want, len(operands))
+	}
+
+	instr := Instruction{Type: TypeALU, Text: mnemonic}
+	if len(operands) > 0 {
+		instr.Text += " " + strings.Join(operands, ", ")
+	}
+
+	var err error
+	switch format {
+	case fmtNone:
+	case fmtR:
+		var rd, rs, rt string
+		if rd, err = parseRegister(operands[0]); err != nil {
+			break
+		}
+		if rs, err = parseRegister(operands[1]); err != nil {
+			break
+		}
+		if rt, err = parseRegister(operands[2]); err != nil {
+			break
+		}
+		instr.DestReg = rd
+		instr.SrcRegs = sourceRegs(rs, rt)
+		if t, ok := opTypes[mnemonic]; ok {
+			instr.Type = t
+		}
+	case fmtI:
+		var rt, rs string
+		if rt, err = parseRegister(operands[0]); err != nil {
+			break
+		}
+		if rs, err = parseRegister(operands[1]); err != nil {
+			break
+		}
+		if _, err = parseImmediate(operands[2]); err != nil {
+			break
+		}
+		instr.DestReg = rt
+		instr.SrcRegs = sourceRegs(rs)
+	case fmtLUI:
+		var rt string
+		if rt, err = parseRegister(operands[0]); err != nil {
+			break
+		}
+		if _, err = parseImmediate(operands[1]); err != nil {
+			break
+		}
+		instr.DestReg = rt
+	case fmtLoad, fmtStore:
+		var rt, base string
+		if rt, err = parseRegister(operands[0]); err != nil {
+			break
+		}
+		if base, err = parseMemOperand(operands[1]); err != nil {
+			break
+		}
+		instr.UseMem = true
+		if format == fmtLoad {
+			instr.Type = TypeLoad
+			instr.DestReg = rt
+			instr.SrcRegs = sourceRegs(base)
+		} else {
+			instr.Type = TypeStore
+			instr.SrcRegs = sourceRegs(rt, base)
+		}
+	case fmtBranch:
+		var rs, rt string
+		if rs, err = parseRegister(operands[0]); err != nil {
+			break
+		}
+		if rt, err = parseRegister(operands[1]); err != nil {
+			break
+		}
+		if !isIdentifier(operands[2]) {
+			err = fmt.Errorf("无效的跳转目标 %q", operands[2])
+			break
+		}
+		instr.Type = TypeBranch
+		instr.IsBranch = true
+		instr.SrcRegs = sourceRegs(rs, rt)
+		instr.Target = operands[2]
+	case fmtJump:
+		if !isIdentifier(operands[0]) {
+			err = fmt.Errorf("无效的跳转目标 %q", operands[0])
+			break
+		}
+		instr.Type = TypeBranch
+		instr.IsBranch = true
+		instr.Taken = true // 无条件跳转
+		instr.Target = operands[0]
+	}
+	if err != nil {
+		return Instruction{}, err
+	}
+
+	if instr.DestReg == "R0" {
+		instr.DestReg = "" // 写 R0 无效，不产生相关
+	}
+	return instr, nil
+}
+
+// stripComment 去掉 #、; 或 // 开头的注释
+func stripComment(line string) string {
+	for _, marker := range []string{"#", ";", "//"} {
+		if idx := strings.Index(line, marker); idx >= 0 {
+			line = line[:idx]
+		}
+	}
+	return line
+}
+
+// splitOperands 按逗号和空白拆分操作数
+func splitOperands(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+}
+
+// parseRegister 解析寄存器并规范为 "R<编号>"
+func parseRegister(s string) (string, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	switch {
+	case strings.HasPrefix(name, "$"):
+		name = name[1:]
+		if num, ok := registerNames[name]; ok {
+			return fmt.Sprintf("R%d", num), nil
+		}
+	case strings.HasPrefix(name, "r"):
+		name = name[1:]
+	default:
+		return "", fmt.Errorf("无效的寄存器 %q", s)
+	}
+
+	num, err := strconv.Atoi(name)
+	if err != nil || num < 0 || num > 31 {
+		return "", fmt.Errorf("无效的寄存器 %q", s)
+	}
+	return fmt.Sprintf("R%d", num), nil
+}
+
+// parseImmediate 解析立即数（支持十进制、0x 十六进制和负数）
+func parseImmediate(s string) (int, error) {
+	v, err := strconv.ParseInt(s, 0, 32)
+	if err != nil {
+		return 0, fmt.Errorf("无效的立即数 %q", s)
+	}
+	return int(v), nil
+}
+
+// parseMemOperand 解析 offset(base) 形式的访存操作数，返回基址寄存器
+func parseMemOperand(s string) (string, error) {
+	open := strings.Index(s, "(")
+	if open < 0 || !strings.HasSuffix(s, ")") {
+		return "", fmt.Errorf("访存操作数应为 offset(base) 形式，实际为 %q", s)
+	}
+	if offset := s[:open]; offset != "" {
+		if _, err := parseImmediate(offset); err != nil {
+			return "", err
+		}
+	}
+	return parseRegister(s[open+1 : len(s)-1])
+}
+
+// sourceRegs 返回会产生数据相关的源寄存器（去掉 R0）
+func sourceRegs(regs ...string) []string {
+	src := make([]string, 0, len(regs))
+	for _, reg := range regs {
+		if reg != "R0" {
+			src = append(src, reg)
+		}
+	}
+	return src
+}
+
+// isIdentifier 标签名：字母或下划线开头，由字母、数字、下划线、点组成
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		isLetter := r == '_' || r == '.' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		if !isLetter && (i == 0 || r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// AssemblerExample 汇编器示例：把真题风格的汇编程序直接交给流水线模拟
+func AssemblerExample() {
+	fmt.Println("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Println("  汇编程序 → 流水线模拟")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	source := `
+# 数组求和的一次循环（$t0 指向数组，$s0 为累加和）
+loop:   lw   $t1, 0($t0)      # 取数组元素
+        add  $s0, $s0, $t1    # load-use 相关
+        addi $t0, $t0, 4      # 指针后移
+        sub  $t2, $t3, $t0    # 剩余字节数
+        beq  $t2, $zero, done
+        j    loop
+done:   sw   $s0, 0($a0)      # 保存结果
+`
+	fmt.Println("\n汇编源码：")
+	fmt.Println(strings.Trim(source, "\n"))
+
+	instructions, err := Assemble(source)
+	if err != nil {
+		fmt.Println("汇编失败:", err)
+		return
+	}
+
+	fmt.Println("\n汇编结果：")
+	fmt.Println("  编号 PC     类型    指令                     源寄存器   目的寄存器")
+	for _, instr := range instructions {
+		fmt.Printf("  %-4s 0x%-4X %-7s %-24s %-10s %s\n",
+			instr.Name, instr.PC, instr.Type, instr.Text,
+			strings.Join(instr.SrcRegs, ","), instr.DestReg)
+	}
+
+	simulator := NewPipelineSimulator(instructions, true)
+	simulator.Run()
+	simulator.PrintTimeline()
+	fmt.Println(simulator.GetStatistics())
+
+	// 格式错误时给出带行号的错误信息
+	fmt.Println("错误输入示例：")
+	bad := "add $t0, $t1\nlw $t2, 4[$t0]\njal $t3, $t4, $t5\nbeq $t0, $t1, nowhere"
+	fmt.Println(bad)
+	if _, err := Assemble(bad); err != nil {
+		fmt.Println("\n汇编失败：")
+		fmt.Println(err)
+	}
+}
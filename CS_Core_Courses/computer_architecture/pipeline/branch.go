@@ -0,0 +1,200 @@
+package pipeline
+
+import "fmt"
+
+// BranchPredictor 分支预测器
+// 408 考点：静态预测与动态预测（1 位、2 位饱和计数器）
+type BranchPredictor interface {
+	Predict(pc int) bool       // 预测分支是否跳转
+	Update(pc int, taken bool) // 分支结果确定后更新预测状态
+	Name() string              // 预测器名称
+}
+
+// StaticPredictor 静态预测：总是预测跳转或总是预测不跳转
+type StaticPredictor struct {
+	PredictTaken bool
+}
+
+// NewStaticPredictor 创建静态预测器
+func NewStaticPredictor(predictTaken bool) *StaticPredictor {
+	return &StaticPredictor{PredictTaken: predictTaken}
+}
+
+func (p *StaticPredictor) Predict(pc int) bool {
+	return p.PredictTaken
+}
+
+func (p *StaticPredictor) Update(pc int, taken bool) {}
+
+func (p *StaticPredictor) Name() string {
+	if p.PredictTaken {
+		return "静态预测 (总是跳转)"
+	}
+	return "静态预测 (总是不跳转)"
+}
+
+// OneBitPredictor 1 位动态预测：记住该分支上一次的结果
+// 缺点：循环分支在进入和退出循环时各错一次
+type OneBitPredictor struct {
+	TableSize int          // 预测表项数，0 表示不限（每个分支独占一项）
+	table     map[int]bool // 表项 -> 上一次是否跳转
+}
+
+// NewOneBitPredictor 创建 1 位预测器
+func NewOneBitPredictor(tableSize int) *OneBitPredictor {
+	return &OneBitPredictor{TableSize: tableSize, table: make(map[int]bool)}
+}
+
+func (p *OneBitPredictor) index(pc int) int {
+	if p.TableSize > 0 {
+		return pc % p.TableSize
+	}
+	return pc
+}
+
+func (p *OneBitPredictor) Predict(pc int) bool {
+	return p.table[p.index(pc)] // 未见过的分支预测不跳转
+}
+
+func (p *OneBitPredictor) Update(pc int, taken bool) {
+	p.table[p.index(pc)] = taken
+}
+
+func (p *OneBitPredictor) Name() string {
+	return "1 位动态预测"
+}
+
+// 2 位饱和计数器状态
+const (
+	StronglyNotTaken = 0 // 00 强不跳转
+	WeaklyNotTaken   = 1 // 01 弱不跳转
+	WeaklyTaken      = 2 // 10 弱跳转
+	StronglyTaken    = 3 // 11 强跳转
+)
+
+// TwoBitPredictor 2 位饱和计数器预测
+// 计数器 >= 2 预测跳转；连续两次预测错误才会改变预测方向
+type TwoBitPredictor struct {
+	TableSize    int         // 预测表项数，0 表示不限
+	InitialState int         // 计数器初始值（默认弱不跳转）
+	counters     map[int]int // 表项 -> 计数器
+}
+
+// NewTwoBitPredictor 创建 2 位预测器，计数器初始为弱不跳转
+func NewTwoBitPredictor(tableSize int) *TwoBitPredictor {
+	return &TwoBitPredictor{
+		TableSize:    tableSize,
+		InitialState: WeaklyNotTaken,
+		counters:     make(map[int]int),
+	}
+}
+
+func (p *TwoBitPredictor) index(pc int) int {
+	if p.TableSize > 0 {
+		return pc % p.TableSize
+	}
+	return pc
+}
+
+// Counter 返回分支当前的计数器值
+func (p *TwoBitPredictor) Counter(pc int) int {
+	if c, ok := p.counters[p.index(pc)]; ok {
+		return c
+	}
+	return p.InitialState
+}
+
+func (p *TwoBitPredictor) Predict(pc int) bool {
+	return p.Counter(pc) >= WeaklyTaken
+}
+
+func (p *TwoBitPredictor) Update(pc int, taken bool) {
+	c := p.Counter(pc)
+	if taken && c < StronglyTaken {
+		c++
+	} else if !taken && c > StronglyNotTaken {
+		c--
+	}
+	p.counters[p.index(pc)] = c
+}
+
+func (p *TwoBitPredictor) Name() string {
+	return "2 位饱和计数器预测"
+}
+
+// BTBEntry 分支目标缓冲表项
+type BTBEntry struct {
+	Valid  bool
+	PC     int    // 分支指令地址（标记）
+	Target string // 跳转目标
+}
+
+// BTB 分支目标缓冲 (Branch Target Buffer)，直接映射
+// 取指阶段命中 BTB 才能立即按预测跳转，否则要等到译码阶段算出目标地址
+type BTB struct {
+	Entries []BTBEntry
+	Hits    int
+	Misses  int
+}
+
+// NewBTB 创建指定表项数的 BTB
+func NewBTB(entries int) *BTB {
+	if entries <= 0 {
+		entries = 1
+	}
+	return &BTB{Entries: make([]BTBEntry, entries)}
+}
+
+// Lookup 查找分支目标
+func (b *BTB) Lookup(pc int) (string, bool) {
+	entry := b.Entries[pc%len(b.Entries)]
+	if entry.Valid && entry.PC == pc {
+		b.Hits++
+		return entry.Target, true
+	}
+	b.Misses++
+	return "", false
+}
+
+// Insert 记录跳转分支的目标
+func (b *BTB) Insert(pc int, target string) {
+	b.Entries[pc%len(b.Entries)] = BTBEntry{Valid: true, PC: pc, Target: target}
+}
+
+// BranchConfig 控制冲突建模配置
+// 408 考点：分支在第 k 段确定结果时，预测错误需冲刷 k 个周期内取入的指令
+type BranchConfig struct {
+	ResolveStage PipelineStage   // 分支在哪一段结束时确定结果（ID/EX/MEM），IF 视为默认的 EX
+	FlushPenalty int             // 预测错误时冲刷的周期数，0 表示由 ResolveStage 推算
+	Predictor    BranchPredictor // 分支预测器，nil 表示静态预测不跳转
+	BTB          *BTB            // 分支目标缓冲，nil 表示预测跳转时要在 ID 段算出目标（1 个周期代价）
+}
+
+// resolveStage 返回实际使用的分支确定阶段
+func (bc *BranchConfig) resolveStage() PipelineStage {
+	if bc.ResolveStage <= IF || bc.ResolveStage > MEM {
+		return EX
+	}
+	return bc.ResolveStage
+}
+
+// predictor 返回实际使用的预测器
+func (bc *BranchConfig) predictor() BranchPredictor {
+	if bc.Predictor == nil {
+		bc.Predictor = NewStaticPredictor(false)
+	}
+	return bc.Predictor
+}
+
+// Describe 返回配置说明
+func (bc *BranchConfig) Describe() string {
+	penalty := fmt.Sprintf("%d 周期 (在 %s 段确定)", int(bc.resolveStage()), bc.resolveStage())
+	if bc.FlushPenalty > 0 {
+		penalty = fmt.Sprintf("%d 周期", bc.FlushPenalty)
+	}
+	btb := "无"
+	if bc.BTB != nil {
+		btb = fmt.Sprintf("%d 项", len(bc.BTB.Entries))
+	}
+	return fmt.Sprintf("%s, 误预测代价 %s, BTB %s", bc.predictor().Name(), penalty, btb)
+}
@@ -1,10 +1,30 @@
 package pipeline
 
-import "fmt"
+import (
+	"fmt"
+
+	"CS_Core_Courses/registry"
+)
+
+func init() {
+	registry.Register(&registry.Module{
+		Name:    "arch/pipeline",
+		Section: "3.4",
+		Title:   "流水线",
+		Run:     RunAllPipelineExamples,
+		Examples: []registry.Example{
+			{Name: "pipeline", Run: PipelineExample},
+			{Name: "assembler", Run: AssemblerExample},
+			{Name: "tomasulo", Run: TomasuloExample},
+		},
+	})
+}
 
 func RunAllPipelineExamples() {
 	fmt.Println("\n╔══════════════════════════════════════╗")
 	fmt.Println("║    计算机组成原理 - 流水线模块       ║")
 	fmt.Println("╚══════════════════════════════════════╝")
 	PipelineExample()
+	AssemblerExample()
+	TomasuloExample()
 }
@@ -3,6 +3,8 @@ package pipeline
 import (
 	"fmt"
 	"strings"
+
+	"CS_Core_Courses/trace"
 )
 
 // PipelineStage 流水线阶段
@@ -32,6 +34,8 @@ const (
 	TypeLoad                          // 加载指令
 	TypeStore                         // 存储指令
 	TypeBranch                        // 分支指令
+	TypeMul                           // 乘法（多周期）
+	TypeDiv                           // 除法（多周期）
 )
 
 // Instruction 指令定义
@@ -44,16 +48,64 @@ type Instruction struct {
 	DestReg  string          // 目的寄存器
 	UseMem   bool            // 是否访存
 	IsBranch bool            // 是否是分支指令
+	PC       int             // 指令地址（分支预测表/BTB 索引，为 0 时使用 ID）
+	Taken    bool            // 分支实际是否跳转（仅分支指令有意义）
+	Target   string          // 分支目标（标签）
+	Text     string          // 汇编文本（由 Assemble 生成时填写）
+}
+
+// isBranch 是否为分支指令
+func (instr Instruction) isBranch() bool {
+	return instr.IsBranch || instr.Type == TypeBranch
+}
+
+// usesMemory 是否在 MEM 段访问数据存储器
+func (instr Instruction) usesMemory() bool {
+	return instr.UseMem || instr.Type == TypeLoad || instr.Type == TypeStore
+}
+
+// branchPC 返回用于索引预测表的地址
+func (instr Instruction) branchPC() int {
+	if instr.PC != 0 {
+		return instr.PC
+	}
+	return instr.ID
+}
+
+// FlushRow 时空图中因分支预测错误被冲刷的错误路径取指
+type FlushRow struct {
+	Branch int   // 预测错误的分支指令下标
+	Cycles []int // 错误路径指令占用取指段的周期
 }
 
 // PipelineSimulator 流水线模拟器
 type PipelineSimulator struct {
-	Instructions     []Instruction  // 指令序列
-	Timeline         [][]string     // 时间线（每个时钟周期每条指令的状态）
-	RegisterState    map[string]int // 寄存器状态（记录哪条指令最后写入）
-	Stalls           int            // 暂停周期数
-	Cycles           int            // 总周期数
-	EnableForwarding bool           // 是否启用转发
+	Instructions     []Instruction     // 指令序列
+	Timeline         [][]string        // 时间线（每个时钟周期每条指令的状态）
+	RegisterState    map[string]int    // 寄存器状态（记录哪条指令最后写入）
+	Stalls           int               // 时空图中暂停槽位（X）的个数，被连带阻塞的后续指令也计入
+	DataStalls       int               // 数据冲突引起的暂停周期数（只计引起暂停的指令）
+	StructuralStalls int               // 结构冲突引起的暂停周期数（下面三项之和）
+	MemoryStalls     int               // 其中：存储器端口冲突
+	WritePortStalls  int               // 其中：寄存器写端口冲突
+	UnitStalls       int               // 其中：功能部件忙
+	Cycles           int               // 总周期数
+	EnableForwarding bool              // 是否启用转发
+	Branch           *BranchConfig     // 控制冲突配置，nil 表示不考虑控制冲突
+	Structural       *StructuralConfig // 结构冲突配置，nil 表示分离存储器、多写端口
+	Branches         int               // 分支指令数
+	Mispredictions   int               // 分支预测错误次数
+	FlushedSlots     int               // 被冲刷的取指槽位数
+	BranchStalls     int               // 预测跳转但需在 ID 段计算目标而损失的周期数
+	FlushRows        []FlushRow        // 被冲刷的错误路径（用于时空图）
+	Tracer           trace.Tracer      // 事件输出（nil 时使用默认控制台）
+}
+
+// stageTimes 一条指令进入各段的时钟周期（从 0 开始）
+// 指令在某段停留到进入下一段为止，多出的周期即为暂停；
+// want 为本可以取指的周期（取指被访存挡住时早于 fetch），execDone 为 EX 段执行完成的周期
+type stageTimes struct {
+	want, fetch, decode, exec, execDone, mem, wb int
 }
 
 // NewPipelineSimulator 创建流水线模拟器
@@ -68,181 +120,376 @@ func NewPipelineSimulator(instructions []Instruction, enableForwarding bool) *Pi
 	}
 }
 
+// emit 发送流水线事件
+// 流水线的逐周期事件只用于记录，控制台输出仍由 PrintTimeline 负责
+func (ps *PipelineSimulator) emit(cycle int, kind string, instr int, stage string) {
+	trace.Emit(ps.Tracer, trace.Event{
+		Cycle:     cycle + 1,
+		Component: "pipeline",
+		Kind:      kind,
+		Fields: map[string]any{
+			"instr": ps.Instructions[instr].Name,
+			"index": instr,
+			"stage": stage,
+		},
+	})
+}
+
 // Run 运行流水线模拟
 // 408 考点：模拟流水线执行过程，检测冲突
+//
+// 按程序顺序依次计算每条指令进入各段的周期：
+//   - IF、ID 段只能容纳一条指令，指令按序进入 EX 段
+//   - 数据冲突时指令停在 ID 段等待操作数，后续指令随之停在 IF 段
+//   - 功能部件忙时指令停在 ID 段；访存或写回端口被占用时停在 EX/MEM 段
+//   - 分支预测错误时，正确路径的取指推迟到分支确定之后
+//
+// 暂停按引起暂停的指令分类统计，后续指令被连带阻塞的周期不重复计入
 func (ps *PipelineSimulator) Run() {
 	numInstructions := len(ps.Instructions)
 	if numInstructions == 0 {
 		return
 	}
 
-	// 初始化时间线
-	// 指令状态：IF, ID, EX, MEM, WB, stall(暂停), done(完成), -(未开始)
-	maxCycles := numInstructions*5 + 20 // 预估最大周期数
-	ps.Timeline = make([][]string, numInstructions)
-	for i := range ps.Timeline {
-		ps.Timeline[i] = make([]string, maxCycles)
-		for j := range ps.Timeline[i] {
-			ps.Timeline[i][j] = "-"
+	ps.Stalls = 0
+	ps.DataStalls = 0
+	ps.StructuralStalls = 0
+	ps.MemoryStalls = 0
+	ps.WritePortStalls = 0
+	ps.UnitStalls = 0
+	ps.Branches = 0
+	ps.Mispredictions = 0
+	ps.FlushedSlots = 0
+	ps.BranchStalls = 0
+	ps.FlushRows = nil
+	ps.RegisterState = make(map[string]int)
+
+	sc := ps.Structural
+	times := make([]stageTimes, numInstructions)
+	memBusy := make(map[int]bool)      // 数据访存占用存储器的周期
+	writeBusy := make(map[int]bool)    // 占用寄存器写端口的周期
+	lastInUnit := make(map[string]int) // 功能部件 -> 最近一条使用它的指令
+	nextFetch := 0
+	for i, instr := range ps.Instructions {
+		t := &times[i]
+
+		// IF：前一条离开 IF 段后才能取指；统一存储器被数据访存占用时推迟取指
+		t.fetch = nextFetch
+		if i > 0 {
+			prev := times[i-1]
+			t.fetch = max(t.fetch, prev.fetch+1, prev.decode)
+		}
+		t.want = t.fetch
+		if sc != nil && sc.UnifiedMemory {
+			for memBusy[t.fetch] {
+				t.fetch++
+			}
+			ps.MemoryStalls += t.fetch - t.want
 		}
-	}
 
-	// 跟踪每条指令当前所在阶段
-	instrStage := make([]PipelineStage, numInstructions)
-	for i := range instrStage {
-		instrStage[i] = -1 // -1 表示未开始
-	}
+		// ID：前一条进入 EX 段后才能译码（按序发射）
+		t.decode = t.fetch + 1
+		if i > 0 {
+			t.decode = max(t.decode, times[i-1].exec)
+		}
 
-	cycle := 0
-	completed := 0
-
-	// 模拟流水线执行
-	for completed < numInstructions {
-		// 反向遍历（从 WB 到 IF），避免同一周期内的冲突
-		for stage := WB; stage >= IF; stage-- {
-			for i := 0; i < numInstructions; i++ {
-				if instrStage[i] == stage {
-					canProgress := true
-
-					// 检查是否可以进入下一阶段
-					if stage == ID {
-						// 在 ID 阶段检查数据冲突
-						canProgress = ps.checkDataHazard(i, cycle, instrStage)
-					} else if stage == IF {
-						// 检查下一阶段（ID）是否有指令占用
-						// 简化处理：如果前一条指令被暂停，当前指令也暂停
-						if i > 0 && instrStage[i-1] == IF {
-							canProgress = false
-						}
-					}
-
-					if canProgress {
-						// 进入下一阶段
-						if stage == WB {
-							// 完成执行
-							ps.Timeline[i][cycle] = "WB"
-							instrStage[i] = -1
-							completed++
-
-							// 更新寄存器状态
-							if ps.Instructions[i].DestReg != "" {
-								ps.RegisterState[ps.Instructions[i].DestReg] = i
-							}
-						} else {
-							ps.Timeline[i][cycle] = stage.String()
-							instrStage[i] = stage + 1
-						}
-					} else {
-						// 暂停（插入气泡）
-						ps.Timeline[i][cycle] = "stall"
-						ps.Stalls++
-					}
-				}
+		// EX：先等功能部件空闲（结构冲突），再等操作数就绪（数据冲突）
+		unit := unitOf(instr.Type)
+		issue := t.decode + 1
+		if j, ok := lastInUnit[unit]; ok {
+			free := times[j].mem // 前一条离开 EX 段后部件才空闲
+			if sc != nil && sc.PipelinedUnits && sc.latency(instr.Type) > 1 {
+				free = times[j].exec + 1
 			}
+			ps.UnitStalls += max(issue, free) - issue
+			issue = max(issue, free)
+		}
+		t.exec = max(issue, ps.operandsReady(i, times))
+		ps.DataStalls += t.exec - issue
+		t.execDone = t.exec + sc.latency(instr.Type)
+
+		// MEM：数据存储器每周期只能服务一次访存；同一部件的指令按序离开
+		t.mem = t.execDone
+		if j, ok := lastInUnit[unit]; ok {
+			t.mem = max(t.mem, times[j].mem+1)
+		}
+		if instr.usesMemory() {
+			ready := t.mem
+			for memBusy[t.mem] {
+				t.mem++
+			}
+			ps.MemoryStalls += t.mem - ready
+			memBusy[t.mem] = true
 		}
 
-		// 启动新指令
-		for i := 0; i < numInstructions; i++ {
-			if instrStage[i] == -1 {
-				// 检查是否可以开始（IF 阶段是否空闲）
-				canStart := true
-				if i > 0 {
-					// 确保上一条指令已经离开 IF 阶段
-					prevStage := instrStage[i-1]
-					if prevStage == 0 || prevStage == -1 {
-						canStart = cycle > 0 // 第一个周期除外
-					}
-				}
-
-				if canStart {
-					ps.Timeline[i][cycle] = "IF"
-					instrStage[i] = IF + 1
-					break // 每个周期只启动一条指令
+		// WB：同一寄存器必须按程序顺序写回（WAW），单写端口时每周期只能有一条指令写寄存器
+		t.wb = t.mem + 1
+		if instr.DestReg != "" {
+			if j, ok := ps.RegisterState[instr.DestReg]; ok && times[j].wb >= t.wb {
+				ps.DataStalls += times[j].wb + 1 - t.wb
+				t.wb = times[j].wb + 1
+			}
+		}
+		if instr.DestReg != "" {
+			if sc != nil && sc.SingleWritePort {
+				ready := t.wb
+				for writeBusy[t.wb] {
+					t.wb++
 				}
+				ps.WritePortStalls += t.wb - ready
 			}
+			writeBusy[t.wb] = true
 		}
+		lastInUnit[unit] = i
 
-		cycle++
-		if cycle >= maxCycles {
-			break
+		nextFetch = t.fetch + 1
+		if ps.Branch != nil && instr.isBranch() {
+			nextFetch = ps.resolveBranch(i, *t)
+		}
+
+		if instr.DestReg != "" {
+			ps.RegisterState[instr.DestReg] = i
 		}
 	}
 
-	ps.Cycles = cycle
+	ps.StructuralStalls = ps.MemoryStalls + ps.WritePortStalls + ps.UnitStalls
+	ps.Cycles = 0
+	for _, t := range times {
+		ps.Cycles = max(ps.Cycles, t.wb+1)
+	}
+	ps.buildTimeline(times)
 }
 
-// checkDataHazard 检查数据冲突
+// operandsReady 返回指令 i 最早可以进入 EX 段的周期
 // 408 考点：RAW (Read After Write) 数据相关
-func (ps *PipelineSimulator) checkDataHazard(instrIndex int, cycle int, instrStage []PipelineStage) bool {
-	currentInstr := ps.Instructions[instrIndex]
-
-	// 检查当前指令的源寄存器
-	for _, srcReg := range currentInstr.SrcRegs {
+//   - 不转发：寄存器前半周期写、后半周期读，ID 段可与写回指令的 WB 段重叠
+//   - 转发：运算结果在 EX 段执行完成后可用；Load 结果在 MEM 段结束后可用（load-use 需 1 个气泡）
+func (ps *PipelineSimulator) operandsReady(i int, times []stageTimes) int {
+	ready := 0
+	for _, srcReg := range ps.Instructions[i].SrcRegs {
 		if srcReg == "" {
 			continue
 		}
 
-		// 查找前面的指令是否会写入该寄存器
-		for i := 0; i < instrIndex; i++ {
-			prevInstr := ps.Instructions[i]
+		// 查找最近一条写该寄存器的前序指令
+		for j := i - 1; j >= 0; j-- {
+			if ps.Instructions[j].DestReg != srcReg {
+				continue
+			}
+			producer := times[j]
+			if !ps.EnableForwarding {
+				ready = max(ready, producer.wb+1)
+			} else if ps.Instructions[j].Type == TypeLoad {
+				ready = max(ready, producer.mem+1)
+			} else {
+				ready = max(ready, producer.execDone)
+			}
+			break
+		}
+	}
+	return ready
+}
+
+// resolveBranch 处理分支指令，返回下一条指令最早的取指周期
+// 408 考点：控制冲突。预测正确无代价；预测错误需冲刷在分支确定前取入的指令
+func (ps *PipelineSimulator) resolveBranch(i int, t stageTimes) int {
+	bc := ps.Branch
+	instr := ps.Instructions[i]
+	pc := instr.branchPC()
+	predictor := bc.predictor()
+	predicted := predictor.Predict(pc)
+	ps.Branches++
+
+	// 分支确定的周期 = 确定阶段的最后一个周期
+	var resolved int
+	switch bc.resolveStage() {
+	case ID:
+		resolved = t.exec - 1
+	case MEM:
+		resolved = t.wb - 1
+	default:
+		resolved = t.mem - 1
+	}
 
-			// 如果前面的指令写入当前指令需要读取的寄存器
-			if prevInstr.DestReg == srcReg {
-				prevStage := instrStage[i]
+	next := t.fetch + 1
+	switch {
+	case predicted != instr.Taken:
+		// 预测错误：冲刷错误路径，从正确地址重新取指
+		ps.Mispredictions++
+		next = resolved + 1
+		if bc.FlushPenalty > 0 {
+			next = t.fetch + 1 + bc.FlushPenalty
+		}
+		row := FlushRow{Branch: i}
+		for c := t.fetch + 1; c < next; c++ {
+			row.Cycles = append(row.Cycles, c)
+		}
+		ps.FlushRows = append(ps.FlushRows, row)
+		ps.FlushedSlots += len(row.Cycles)
+		trace.Emit(ps.Tracer, trace.Event{
+			Cycle:     resolved + 1,
+			Component: "pipeline",
+			Kind:      "mispredict",
+			Fields: map[string]any{
+				"instr":     instr.Name,
+				"index":     i,
+				"predicted": predicted,
+				"taken":     instr.Taken,
+				"flushed":   len(row.Cycles),
+			},
+		})
+	case instr.Taken:
+		// 预测跳转且正确：BTB 命中时取指段即可跳转，否则要等 ID 段算出目标地址
+		hit := false
+		if bc.BTB != nil {
+			_, hit = bc.BTB.Lookup(pc)
+		}
+		if !hit {
+			next = t.decode + 1
+			ps.BranchStalls += next - (t.fetch + 1)
+		}
+	}
 
-				if ps.EnableForwarding {
-					// 启用转发：如果前面指令在 EX 或之后阶段，可以转发
-					if prevStage >= 0 && prevStage <= EX {
-						// 前面指令还在 IF/ID/EX，需要等待
-						return false
-					}
+	predictor.Update(pc, instr.Taken)
+	if instr.Taken && bc.BTB != nil {
+		bc.BTB.Insert(pc, instr.Target)
+	}
+	return next
+}
+
+// buildTimeline 根据各段进入时间生成时空图，并按周期发送事件
+func (ps *PipelineSimulator) buildTimeline(times []stageTimes) {
+	ps.Timeline = make([][]string, len(ps.Instructions))
+	for i, t := range times {
+		row := make([]string, ps.Cycles)
+		for c := range row {
+			row[c] = "-"
+		}
+
+		// 每段的第一个周期显示段名，之后停留的周期为暂停
+		mark := func(stage string, start, end int) {
+			for c := start; c < end; c++ {
+				if c == start {
+					row[c] = stage
 				} else {
-					// 不启用转发：必须等待前面指令完全完成
-					if prevStage >= 0 {
-						return false
-					}
+					row[c] = "stall"
+					ps.Stalls++
 				}
 			}
 		}
+
+		// 取指被数据访存挡住的周期
+		for c := t.want; c < t.fetch; c++ {
+			row[c] = "stall"
+			ps.Stalls++
+		}
+		mark(IF.String(), t.fetch, t.decode)
+		mark(ID.String(), t.decode, t.exec)
+
+		// 多周期执行显示为 E1, E2, ...；执行完成后等待进入 MEM 段的周期为暂停
+		for c := t.exec; c < t.execDone; c++ {
+			if t.execDone-t.exec == 1 {
+				row[c] = EX.String()
+			} else {
+				row[c] = fmt.Sprintf("E%d", c-t.exec+1)
+			}
+		}
+		for c := t.execDone; c < t.mem; c++ {
+			row[c] = "stall"
+			ps.Stalls++
+		}
+		mark(MEM.String(), t.mem, t.wb)
+		mark(WB.String(), t.wb, t.wb+1)
+		ps.Timeline[i] = row
+	}
+
+	for c := 0; c < ps.Cycles; c++ {
+		for i := range ps.Instructions {
+			switch cell := ps.Timeline[i][c]; cell {
+			case "-":
+			case "stall":
+				ps.emit(c, "stall", i, ps.stageAt(i, c, times).String())
+			case "IF":
+				ps.emit(c, "fetch", i, cell)
+			case "WB":
+				ps.emit(c, "complete", i, cell)
+			default:
+				ps.emit(c, "stage", i, cell)
+			}
+		}
 	}
+}
 
-	return true
+// stageAt 返回指令 i 在周期 c 所处的段
+func (ps *PipelineSimulator) stageAt(i, c int, times []stageTimes) PipelineStage {
+	t := times[i]
+	switch {
+	case c < t.decode:
+		return IF
+	case c < t.exec:
+		return ID
+	case c < t.mem:
+		return EX
+	case c < t.wb:
+		return MEM
+	default:
+		return WB
+	}
 }
 
 // PrintTimeline 打印流水线时空图
 // 408 考点：流水线时空图是考试常见题型
 func (ps *PipelineSimulator) PrintTimeline() {
 	fmt.Println("\n流水线时空图：")
-	fmt.Println("（stall 表示暂停，- 表示未开始/已完成）")
+	fmt.Println("（X 表示暂停，- 表示未开始/已完成，FL 表示被冲刷的错误路径取指）")
 
 	// 打印表头
 	fmt.Print("指令\\时钟 |")
 	for c := 0; c < ps.Cycles; c++ {
-		fmt.Printf(" %2d |", c+1)
+		fmt.Printf(" %3d |", c+1)
 	}
 	fmt.Println()
 
 	// 打印分隔线
 	fmt.Print("----------|")
 	for c := 0; c < ps.Cycles; c++ {
-		fmt.Print("----|")
+		fmt.Print("-----|")
 	}
 	fmt.Println()
 
-	// 打印每条指令的时间线
+	// 打印每条指令的时间线，预测错误的分支后面跟一行被冲刷的取指
 	for i, instr := range ps.Instructions {
 		fmt.Printf("%-9s |", instr.Name)
 		for c := 0; c < ps.Cycles; c++ {
 			stage := ps.Timeline[i][c]
 			if stage == "-" {
-				fmt.Print("  - |")
+				fmt.Print("   - |")
 			} else if stage == "stall" {
-				fmt.Print("  X |") // X 表示暂停
+				fmt.Print("   X |") // X 表示暂停
 			} else {
-				fmt.Printf(" %2s |", stage)
+				fmt.Printf(" %3s |", stage)
 			}
 		}
 		fmt.Println()
+
+		for _, row := range ps.FlushRows {
+			if row.Branch != i {
+				continue
+			}
+			flushed := make(map[int]bool)
+			for _, c := range row.Cycles {
+				flushed[c] = true
+			}
+			fmt.Printf("%-9s |", "  flush")
+			for c := 0; c < ps.Cycles; c++ {
+				if flushed[c] {
+					fmt.Print("  FL |")
+				} else {
+					fmt.Print("     |")
+				}
+			}
+			fmt.Println()
+		}
 	}
 }
 
@@ -254,8 +501,11 @@ func (ps *PipelineSimulator) GetStatistics() string {
 		return "无统计信息"
 	}
 
-	// 非流水线执行时间（假设每条指令 5 个时钟周期）
-	nonPipelineTime := numInstructions * 5
+	// 非流水线执行时间（每条指令 5 个时钟周期，多周期指令的 EX 段按其延迟计）
+	nonPipelineTime := 0
+	for _, instr := range ps.Instructions {
+		nonPipelineTime += 4 + ps.Structural.latency(instr.Type)
+	}
 
 	// 加速比
 	speedup := float64(nonPipelineTime) / float64(ps.Cycles)
@@ -283,7 +533,10 @@ func (ps *PipelineSimulator) GetStatistics() string {
 流水线统计信息：
   指令数量:       %d
   执行周期数:     %d
-  暂停周期数:     %d
+  暂停槽位数:     %d（时空图中 X 的个数，含被连带阻塞的后续指令）
+  引起暂停的冲突（只计引起暂停的指令，与槽位数不必相等）：
+    数据冲突:     %d 周期
+    结构冲突:     %d 周期
   
 性能指标：
   非流水线时间:   %d 周期
@@ -295,11 +548,40 @@ func (ps *PipelineSimulator) GetStatistics() string {
   
 转发机制:         %s
 `,
-		numInstructions, ps.Cycles, ps.Stalls,
+		numInstructions, ps.Cycles, ps.Stalls, ps.DataStalls, ps.StructuralStalls,
 		nonPipelineTime, ps.Cycles, idealCycles,
 		speedup, throughput, efficiency,
 		getForwardingStatus(ps.EnableForwarding))
 
+	if ps.Structural != nil {
+		result += fmt.Sprintf(`
+结构冲突：
+  硬件配置:       %s
+  存储器端口冲突: %d 周期
+  写端口冲突:     %d 周期
+  功能部件忙:     %d 周期
+`,
+			ps.Structural.Describe(), ps.MemoryStalls, ps.WritePortStalls, ps.UnitStalls)
+	}
+
+	if ps.Branch != nil {
+		accuracy := 100.0
+		if ps.Branches > 0 {
+			accuracy = float64(ps.Branches-ps.Mispredictions) / float64(ps.Branches) * 100
+		}
+		result += fmt.Sprintf(`
+控制冲突：
+  分支预测:       %s
+  分支指令数:     %d
+  预测错误次数:   %d
+  预测准确率:     %.2f%%
+  冲刷槽位数:     %d
+  目标地址等待:   %d 周期
+`,
+			ps.Branch.Describe(), ps.Branches, ps.Mispredictions, accuracy,
+			ps.FlushedSlots, ps.BranchStalls)
+	}
+
 	return result
 }
 
@@ -370,9 +652,93 @@ func PipelineExample() {
 	simulator3.PrintTimeline()
 	fmt.Println(simulator3.GetStatistics())
 
+	// 4. 控制冲突：循环分支在不同预测策略下的代价
+	fmt.Println("\n【场景 4：控制冲突与分支预测】")
+	fmt.Println("说明：循环体执行 3 次，分支在 EX 段确定，预测错误冲刷 2 个周期")
+	loopTrace := buildLoopTrace(3)
+	predictors := []struct {
+		title string
+		cfg   *BranchConfig
+	}{
+		{"静态预测不跳转", &BranchConfig{ResolveStage: EX, Predictor: NewStaticPredictor(false)}},
+		{"静态预测跳转（无 BTB）", &BranchConfig{ResolveStage: EX, Predictor: NewStaticPredictor(true)}},
+		{"2 位饱和计数器 + BTB", &BranchConfig{ResolveStage: EX, Predictor: NewTwoBitPredictor(0), BTB: NewBTB(16)}},
+	}
+	for _, p := range predictors {
+		fmt.Printf("\n>>> %s\n", p.title)
+		simulator := NewPipelineSimulator(loopTrace, true)
+		simulator.Branch = p.cfg
+		simulator.Run()
+		simulator.PrintTimeline()
+		fmt.Println(simulator.GetStatistics())
+	}
+
+	// 5. 结构冲突与多周期功能部件
+	fmt.Println("\n【场景 5：结构冲突与多周期功能部件】")
+	fmt.Println("说明：乘法 4 周期、除法 10 周期，整数/乘法/除法各有独立部件，短指令可以先完成")
+	program, err := Assemble(`
+		lw   R1, 0(R2)
+		mul  R3, R4, R5
+		mul  R6, R7, R8
+		add  R9, R1, R10
+		sw   R3, 4(R2)
+		div  R11, R12, R13
+		sub  R14, R6, R9
+		add  R15, R11, R14
+	`)
+	if err != nil {
+		fmt.Println("汇编失败:", err)
+		return
+	}
+	hardware := []struct {
+		title string
+		cfg   *StructuralConfig
+	}{
+		{"分离的指令/数据存储器，多个写端口", &StructuralConfig{}},
+		{"单端口统一存储器，单写端口", &StructuralConfig{UnifiedMemory: true, SingleWritePort: true}},
+		{"单端口统一存储器，单写端口，乘除法部件流水化", &StructuralConfig{UnifiedMemory: true, SingleWritePort: true, PipelinedUnits: true}},
+	}
+	for _, h := range hardware {
+		fmt.Printf("\n>>> %s\n", h.title)
+		simulator := NewPipelineSimulator(program, true)
+		simulator.Structural = h.cfg
+		simulator.Run()
+		simulator.PrintTimeline()
+		fmt.Println(simulator.GetStatistics())
+	}
+
 	fmt.Println("\n" + pipeline408Summary())
 }
 
+// buildLoopTrace 生成循环执行的动态指令序列
+//
+//	loop: lw   R1, 0(R2)
+//	      add  R3, R3, R1
+//	      addi R2, R2, 4
+//	      bne  R2, R4, loop   ; 前 n-1 次跳转，最后一次不跳转
+//	      sw   R3, 0(R5)
+func buildLoopTrace(iterations int) []Instruction {
+	trace := make([]Instruction, 0, iterations*4+1)
+	id := 1
+	for it := 0; it < iterations; it++ {
+		body := []Instruction{
+			{Name: "lw", Type: TypeLoad, SrcRegs: []string{"R2"}, DestReg: "R1", UseMem: true, PC: CodeBase},
+			{Name: "add", Type: TypeALU, SrcRegs: []string{"R3", "R1"}, DestReg: "R3", PC: CodeBase + 4},
+			{Name: "addi", Type: TypeALU, SrcRegs: []string{"R2"}, DestReg: "R2", PC: CodeBase + 8},
+			{Name: "bne", Type: TypeBranch, SrcRegs: []string{"R2", "R4"}, IsBranch: true, PC: CodeBase + 12,
+				Taken: it < iterations-1, Target: "loop"},
+		}
+		for _, instr := range body {
+			instr.ID = id
+			instr.Name = fmt.Sprintf("%s#%d", instr.Name, it+1)
+			trace = append(trace, instr)
+			id++
+		}
+	}
+	trace = append(trace, Instruction{ID: id, Name: "sw", Type: TypeStore, SrcRegs: []string{"R3", "R5"}, UseMem: true, PC: CodeBase + 16})
+	return trace
+}
+
 // pipeline408Summary 408 考试总结
 func pipeline408Summary() string {
 	return `
@@ -0,0 +1,73 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultLatencies 多周期功能部件的默认 EX 段周期数，未列出的指令类型为 1 个周期
+var DefaultLatencies = map[InstructionType]int{
+	TypeMul: 4,
+	TypeDiv: 10,
+}
+
+// StructuralConfig 结构冲突建模配置
+// 408 考点：结构冲突是多条指令同一时刻争用同一硬件资源
+//   - 指令和数据共用单端口存储器时，IF 段与 Load/Store 的 MEM 段不能在同一周期进行
+//   - 寄存器堆只有一个写端口时，同一周期只能有一条指令写回
+//   - 多周期功能部件（乘法、除法）未流水化时，下一条同类指令要等部件空闲
+//
+// 整数运算、乘法、除法各有独立的功能部件，因此短指令可能先于前面的长指令完成（乱序完成）
+type StructuralConfig struct {
+	UnifiedMemory   bool                    // 单端口统一存储器（否则为分离的指令/数据存储器）
+	SingleWritePort bool                    // 寄存器堆只有一个写端口
+	Latencies       map[InstructionType]int // 各类指令 EX 段的周期数，未设置时使用 DefaultLatencies
+	PipelinedUnits  bool                    // 多周期功能部件是否流水化（每周期可接收一条新指令）
+}
+
+// latency 返回指令类型的 EX 段周期数（配置可以为 nil）
+func (sc *StructuralConfig) latency(t InstructionType) int {
+	if sc != nil {
+		if l, ok := sc.Latencies[t]; ok && l > 0 {
+			return l
+		}
+	}
+	if l, ok := DefaultLatencies[t]; ok {
+		return l
+	}
+	return 1
+}
+
+// unitOf 返回执行该类指令的功能部件
+func unitOf(t InstructionType) string {
+	switch t {
+	case TypeMul:
+		return "乘法部件"
+	case TypeDiv:
+		return "除法部件"
+	}
+	return "整数部件"
+}
+
+// Describe 返回配置说明
+func (sc *StructuralConfig) Describe() string {
+	memory := "分离的指令/数据存储器"
+	if sc.UnifiedMemory {
+		memory = "单端口统一存储器"
+	}
+	writePort := "多个写端口"
+	if sc.SingleWritePort {
+		writePort = "单写端口"
+	}
+	pipelined := "未流水化"
+	if sc.PipelinedUnits {
+		pipelined = "流水化"
+	}
+
+	latencies := make([]string, 0, 2)
+	for _, t := range []InstructionType{TypeMul, TypeDiv} {
+		latencies = append(latencies, fmt.Sprintf("%s %d 周期", t, sc.latency(t)))
+	}
+	return fmt.Sprintf("%s, %s, 多周期部件%s (%s)",
+		memory, writePort, pipelined, strings.Join(latencies, ", "))
+}
@@ -0,0 +1,635 @@
+package pipeline
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"CS_Core_Courses/trace"
+)
+
+// DefaultTomasuloLatencies Tomasulo 模拟器默认的执行周期数
+var DefaultTomasuloLatencies = map[InstructionType]int{
+	TypeALU:    2,
+	TypeBranch: 1,
+	TypeLoad:   2,
+	TypeStore:  2,
+	TypeMul:    6,
+	TypeDiv:    12,
+}
+
+// TomasuloConfig Tomasulo 算法的硬件配置
+type TomasuloConfig struct {
+	AddStations  int                     // 加法保留站数（ALU、分支指令），默认 3
+	MulStations  int                     // 乘除法保留站数，默认 2
+	LoadBuffers  int                     // Load 缓冲数，默认 3
+	StoreBuffers int                     // Store 缓冲数，默认 3
+	ROBSize      int                     // 重排序缓冲项数，0 表示不使用 ROB
+	Latencies    map[InstructionType]int // 各类指令的执行周期数，未设置时使用 DefaultTomasuloLatencies
+}
+
+// withDefaults 补全未设置的配置项
+func (tc TomasuloConfig) withDefaults() TomasuloConfig {
+	if tc.AddStations <= 0 {
+		tc.AddStations = 3
+	}
+	if tc.MulStations <= 0 {
+		tc.MulStations = 2
+	}
+	if tc.LoadBuffers <= 0 {
+		tc.LoadBuffers = 3
+	}
+	if tc.StoreBuffers <= 0 {
+		tc.StoreBuffers = 3
+	}
+	return tc
+}
+
+// latency 返回指令类型的执行周期数
+func (tc TomasuloConfig) latency(t InstructionType) int {
+	if l, ok := tc.Latencies[t]; ok && l > 0 {
+		return l
+	}
+	if l, ok := DefaultTomasuloLatencies[t]; ok {
+		return l
+	}
+	return 1
+}
+
+// TomasuloEntry 一条指令的执行状态（指令状态表的一行），周期从 1 开始，0 表示尚未发生
+type TomasuloEntry struct {
+	Instr       Instruction
+	Station     string // 所在保留站
+	ROB         int    // ROB 项号（不使用 ROB 时为 0）
+	Issue       int    // 发射周期
+	ExecStart   int    // 开始执行周期
+	ExecEnd     int    // 执行完成周期
+	WriteResult int    // 写结果周期（经 CDB 广播；Store 为写存储器）
+	Commit      int    // 提交周期（仅使用 ROB 时）
+
+	producers []int // 各源操作数的生产者指令下标，-1 表示发射时已就绪
+	released  bool  // 保留站是否已释放
+}
+
+// StationState 某周期结束时一个保留站的内容
+type StationState struct {
+	Name   string
+	Busy   bool
+	Op     string
+	Vj, Vk string // 已就绪的源操作数
+	Qj, Qk string // 等待中的源操作数来自哪个保留站/ROB 项
+	Dest   string // 结果去向（使用 ROB 时为 ROB 项号）
+}
+
+// ROBState 某周期结束时一个 ROB 项的内容
+type ROBState struct {
+	Entry int
+	Instr string
+	State string // 发射 / 执行 / 写结果
+	Dest  string
+}
+
+// TomasuloState 某周期结束时的硬件状态快照
+type TomasuloState struct {
+	Cycle          int
+	Stations       []StationState
+	RegisterStatus map[string]string // 寄存器 -> 将写它的保留站/ROB 项
+	ROB            []ROBState
+}
+
+// station 保留站
+type station struct {
+	name     string
+	entry    int // 占用它的指令下标，-1 表示空闲
+	freeFrom int // 释放后从哪个周期起可以重新使用
+}
+
+// TomasuloSimulator Tomasulo 动态调度模拟器
+// 408 考点（扩展）：
+//   - 保留站 + 寄存器状态表实现寄存器重命名，消除 WAR、WAW 相关
+//   - 操作数就绪即可执行，指令乱序执行、乱序完成
+//   - 公共数据总线 (CDB) 每周期广播一个结果，等待该结果的保留站同时获得数据
+//   - 使用重排序缓冲 (ROB) 时按序提交，可支持推测执行和精确异常
+//
+// 简化假设：每个保留站有独立的执行能力；访存指令按程序顺序开始执行；
+// Store 的源操作数全部就绪后才执行，写存储器不占用 CDB；
+// 不使用 ROB 时遇到分支停止发射，直到分支执行完成；使用 ROB 时分支按推测执行处理（假设预测正确）
+type TomasuloSimulator struct {
+	Instructions []Instruction
+	Config       TomasuloConfig
+	Entries      []TomasuloEntry // 指令状态表
+	States       []TomasuloState // 每周期结束时的状态快照
+	Cycles       int             // 总周期数
+	CDBBusy      int             // CDB 被占用的周期数
+	StationStall int             // 保留站已满导致的发射停顿周期
+	ROBStall     int             // ROB 已满导致的发射停顿周期
+	BranchStall  int             // 等待分支结果导致的发射停顿周期
+	Incomplete   bool            // 达到周期上限时仍有指令未完成（结果只是部分模拟）
+	Tracer       trace.Tracer    // 事件输出（nil 时使用默认控制台）
+
+	stations map[string][]*station // 类别 -> 保留站
+}
+
+// NewTomasuloSimulator 创建 Tomasulo 模拟器
+func NewTomasuloSimulator(instructions []Instruction, config TomasuloConfig) *TomasuloSimulator {
+	return &TomasuloSimulator{
+		Instructions: instructions,
+		Config:       config.withDefaults(),
+	}
+}
+
+// stationClass 指令使用的保留站类别
+func stationClass(t InstructionType) string {
+	switch t {
+	case TypeLoad:
+		return "Load"
+	case TypeStore:
+		return "Store"
+	case TypeMul, TypeDiv:
+		return "Mult"
+	}
+	return "Add"
+}
+
+// useROB 是否使用重排序缓冲
+func (ts *TomasuloSimulator) useROB() bool {
+	return ts.Config.ROBSize > 0
+}
+
+// emit 发送 Tomasulo 事件
+func (ts *TomasuloSimulator) emit(cycle int, kind string, i int) {
+	trace.Emit(ts.Tracer, trace.Event{
+		Cycle:     cycle,
+		Component: "tomasulo",
+		Kind:      kind,
+		Fields: map[string]any{
+			"instr":   ts.Instructions[i].Name,
+			"index":   i,
+			"station": ts.Entries[i].Station,
+		},
+	})
+}
+
+// Run 逐周期模拟。每个周期依次处理：提交 → 写结果 → 开始执行 → 发射，
+// 同一周期内后面的阶段看到的是前面阶段更新后的状态，但操作数要到下一周期才能使用
+func (ts *TomasuloSimulator) Run() {
+	n := len(ts.Instructions)
+	ts.Entries = make([]TomasuloEntry, n)
+	ts.States = nil
+	ts.Cycles = 0
+	ts.CDBBusy = 0
+	ts.StationStall = 0
+	ts.ROBStall = 0
+	ts.BranchStall = 0
+	for i, instr := range ts.Instructions {
+		ts.Entries[i] = TomasuloEntry{Instr: instr}
+	}
+
+	ts.stations = make(map[string][]*station)
+	for _, class := range []struct {
+		name  string
+		count int
+	}{
+		{"Add", ts.Config.AddStations},
+		{"Mult", ts.Config.MulStations},
+		{"Load", ts.Config.LoadBuffers},
+		{"Store", ts.Config.StoreBuffers},
+	} {
+		for k := 1; k <= class.count; k++ {
+			ts.stations[class.name] = append(ts.stations[class.name],
+				&station{name: fmt.Sprintf("%s%d", class.name, k), entry: -1})
+		}
+	}
+
+	regStatus := make(map[string]int) // 寄存器 -> 将写它的指令下标
+	rob := make([]int, 0)             // ROB 中的指令下标（队首最老）
+	robAllocated := 0
+	next := 0           // 下一条待发射指令
+	pendingBranch := -1 // 不使用 ROB 时尚未完成的分支
+
+	// 防止配置错误导致死循环
+	limit := 1000 + n*100
+	for _, l := range DefaultTomasuloLatencies {
+		limit += n * l
+	}
+	for _, l := range ts.Config.Latencies {
+		limit += n * l
+	}
+
+	for cycle := 1; !ts.finished() && cycle <= limit; cycle++ {
+		ts.Cycles = cycle
+
+		// 1. 提交：ROB 队首已写结果的指令按序提交，每周期一条
+		if ts.useROB() && len(rob) > 0 {
+			head := rob[0]
+			if ts.completed(head, cycle) {
+				e := &ts.Entries[head]
+				e.Commit = cycle
+				rob = rob[1:]
+				if dest := e.Instr.DestReg; dest != "" && regStatus[dest] == head {
+					delete(regStatus, dest)
+				}
+				ts.emit(cycle, "commit", head)
+			}
+		}
+
+		// 2. 写结果：执行完成的指令经 CDB 广播结果，每周期只有一条能使用 CDB（最老者优先）
+		cdbUsed := false
+		for i := 0; i < next; i++ {
+			e := &ts.Entries[i]
+			if e.ExecEnd == 0 || e.ExecEnd >= cycle || e.released {
+				continue
+			}
+			switch {
+			case e.Instr.isBranch():
+				// 分支只需确定结果，不写寄存器
+			case e.Instr.Type == TypeStore:
+				e.WriteResult = cycle
+			default:
+				if cdbUsed {
+					continue
+				}
+				cdbUsed = true
+				ts.CDBBusy++
+				e.WriteResult = cycle
+				if dest := e.Instr.DestReg; !ts.useROB() && dest != "" && regStatus[dest] == i {
+					delete(regStatus, dest)
+				}
+			}
+			ts.release(i, cycle)
+			if e.WriteResult > 0 {
+				ts.emit(cycle, "write", i)
+			}
+		}
+
+		// 3. 开始执行：操作数全部就绪的指令开始执行；访存指令按程序顺序开始
+		memBlocked := false
+		for i := 0; i < next; i++ {
+			e := &ts.Entries[i]
+			if e.ExecStart != 0 {
+				continue
+			}
+			isMem := e.Instr.usesMemory()
+			if isMem && memBlocked {
+				continue
+			}
+			if e.Issue < cycle && ts.operandsReady(i, cycle) {
+				e.ExecStart = cycle
+				e.ExecEnd = cycle + ts.Config.latency(e.Instr.Type) - 1
+				ts.emit(cycle, "execute", i)
+			} else if isMem {
+				memBlocked = true
+			}
+		}
+
+		// 4. 发射：按程序顺序每周期发射一条，需要空闲的保留站（和 ROB 项）
+		if next < n {
+			instr := ts.Instructions[next]
+			st := ts.freeStation(stationClass(instr.Type), cycle)
+			switch {
+			case pendingBranch >= 0 && !ts.completed(pendingBranch, cycle):
+				ts.BranchStall++
+			case st == nil:
+				ts.StationStall++
+			case ts.useROB() && len(rob) >= ts.Config.ROBSize:
+				ts.ROBStall++
+			default:
+				e := &ts.Entries[next]
+				e.Issue = cycle
+				e.Station = st.name
+				st.entry = next
+				if ts.useROB() {
+					robAllocated++
+					e.ROB = (robAllocated-1)%ts.Config.ROBSize + 1
+					rob = append(rob, next)
+				}
+				for _, src := range instr.SrcRegs {
+					producer := -1
+					if p, ok := regStatus[src]; ok && src != "" {
+						producer = p
+					}
+					e.producers = append(e.producers, producer)
+				}
+				if instr.DestReg != "" {
+					regStatus[instr.DestReg] = next
+				}
+				pendingBranch = -1
+				if instr.isBranch() && !ts.useROB() {
+					pendingBranch = next
+				}
+				ts.emit(cycle, "issue", next)
+				next++
+			}
+		}
+
+		ts.States = append(ts.States, ts.snapshot(cycle, regStatus, rob))
+	}
+	ts.Incomplete = !ts.finished()
+}
+
+// finished 所有指令是否都已完成（使用 ROB 时为已提交）
+func (ts *TomasuloSimulator) finished() bool {
+	for i, e := range ts.Entries {
+		if ts.useROB() {
+			if e.Commit == 0 {
+				return false
+			}
+		} else if !e.released || (e.WriteResult == 0 && !ts.Instructions[i].isBranch()) {
+			return false
+		}
+	}
+	return true
+}
+
+// completed 指令的结果在 cycle 周期是否已可用（分支为已确定）
+func (ts *TomasuloSimulator) completed(i, cycle int) bool {
+	e := ts.Entries[i]
+	if e.Instr.isBranch() {
+		return e.ExecEnd > 0 && e.ExecEnd < cycle
+	}
+	return e.WriteResult > 0 && e.WriteResult < cycle
+}
+
+// operandsReady 指令 i 的源操作数在 cycle 周期是否全部就绪
+func (ts *TomasuloSimulator) operandsReady(i, cycle int) bool {
+	for _, p := range ts.Entries[i].producers {
+		if p >= 0 && !ts.completed(p, cycle) {
+			return false
+		}
+	}
+	return true
+}
+
+// freeStation 返回指定类别中可用的保留站
+func (ts *TomasuloSimulator) freeStation(class string, cycle int) *station {
+	for _, st := range ts.stations[class] {
+		if st.entry < 0 && st.freeFrom <= cycle {
+			return st
+		}
+	}
+	return nil
+}
+
+// release 释放指令占用的保留站，下一周期起可以重新分配
+func (ts *TomasuloSimulator) release(i, cycle int) {
+	e := &ts.Entries[i]
+	e.released = true
+	for _, st := range ts.stations[stationClass(e.Instr.Type)] {
+		if st.entry == i {
+			st.entry = -1
+			st.freeFrom = cycle + 1
+		}
+	}
+}
+
+// tag 返回指令结果的标记：使用 ROB 时为 ROB 项号，否则为保留站名
+func (ts *TomasuloSimulator) tag(i int) string {
+	e := ts.Entries[i]
+	if ts.useROB() {
+		return fmt.Sprintf("#%d", e.ROB)
+	}
+	return e.Station
+}
+
+// label 返回指令的显示文本；手工构造的指令可能既无 Text 也无 Name
+func (ts *TomasuloSimulator) label(i int) string {
+	instr := ts.Instructions[i]
+	if strings.TrimSpace(instr.Text) != "" {
+		return instr.Text
+	}
+	if strings.TrimSpace(instr.Name) != "" {
+		return instr.Name
+	}
+	return fmt.Sprintf("I%d", instr.ID)
+}
+
+// snapshot 记录周期结束时的保留站、寄存器状态表和 ROB
+func (ts *TomasuloSimulator) snapshot(cycle int, regStatus map[string]int, rob []int) TomasuloState {
+	state := TomasuloState{Cycle: cycle, RegisterStatus: make(map[string]string)}
+
+	for _, class := range []string{"Load", "Store", "Add", "Mult"} {
+		for _, st := range ts.stations[class] {
+			ss := StationState{Name: st.name}
+			if st.entry >= 0 {
+				e := ts.Entries[st.entry]
+				ss.Busy = true
+				ss.Op = strings.Fields(ts.label(st.entry))[0]
+				ss.Dest = e.Instr.DestReg
+				if ts.useROB() {
+					ss.Dest = ts.tag(st.entry)
+				}
+				for k, p := range e.producers {
+					var v, q string
+					if p >= 0 && !ts.completed(p, cycle+1) {
+						q = ts.tag(p)
+					} else {
+						v = e.Instr.SrcRegs[k]
+					}
+					switch k {
+					case 0:
+						ss.Vj, ss.Qj = v, q
+					case 1:
+						ss.Vk, ss.Qk = v, q
+					}
+				}
+			}
+			state.Stations = append(state.Stations, ss)
+		}
+	}
+
+	for reg, i := range regStatus {
+		state.RegisterStatus[reg] = ts.tag(i)
+	}
+
+	for _, i := range rob {
+		e := ts.Entries[i]
+		status := "发射"
+		switch {
+		case ts.completed(i, cycle+1):
+			status = "写结果"
+		case e.ExecStart > 0:
+			status = "执行"
+		}
+		state.ROB = append(state.ROB, ROBState{
+			Entry: e.ROB,
+			Instr: ts.label(i),
+			State: status,
+			Dest:  e.Instr.DestReg,
+		})
+	}
+	return state
+}
+
+// PrintStatusTable 打印指令状态表（各阶段所在周期）
+// 408 考点（扩展）：与教材中的 Tomasulo 指令状态表格式一致
+func (ts *TomasuloSimulator) PrintStatusTable() {
+	fmt.Println("\n指令状态表：")
+	header := "  指令                   | 保留站    | 发射 | 执行      | 写结果"
+	width := 63
+	if ts.useROB() {
+		header += " | 提交"
+		width += 7
+	}
+	fmt.Println(header)
+	fmt.Println("  " + strings.Repeat("-", width))
+
+	for i, e := range ts.Entries {
+		exec := "-"
+		if e.ExecStart > 0 {
+			exec = fmt.Sprintf("%d", e.ExecStart)
+			if e.ExecEnd > e.ExecStart {
+				exec = fmt.Sprintf("%d-%d", e.ExecStart, e.ExecEnd)
+			}
+		}
+		station := e.Station
+		if ts.useROB() {
+			station = fmt.Sprintf("%s/%s", e.Station, ts.tag(i))
+		}
+		line := fmt.Sprintf("  %-22s | %-9s | %4s | %-9s | %6s",
+			ts.label(i), station, cycleText(e.Issue), exec, cycleText(e.WriteResult))
+		if ts.useROB() {
+			line += fmt.Sprintf(" | %4s", cycleText(e.Commit))
+		}
+		fmt.Println(line)
+	}
+	if ts.Incomplete {
+		fmt.Printf("  (达到周期上限 %d，仍有指令未完成，上表只是部分结果)\n", ts.Cycles)
+	}
+}
+
+// PrintState 打印某周期结束时的保留站、寄存器状态表和 ROB
+func (ts *TomasuloSimulator) PrintState(cycle int) {
+	if cycle < 1 || cycle > len(ts.States) {
+		fmt.Printf("周期 %d 不在模拟范围内\n", cycle)
+		return
+	}
+	state := ts.States[cycle-1]
+
+	fmt.Printf("\n第 %d 周期结束时的保留站：\n", cycle)
+	fmt.Println("  名称    | 忙   | 操作  | Vj   | Vk   | Qj     | Qk     | 目的")
+	for _, ss := range state.Stations {
+		if !ss.Busy {
+			fmt.Printf("  %-7s | %-4s |\n", ss.Name, "no")
+			continue
+		}
+		fmt.Printf("  %-7s | %-4s | %-5s | %-4s | %-4s | %-6s | %-6s | %s\n",
+			ss.Name, "yes", ss.Op, ss.Vj, ss.Vk, ss.Qj, ss.Qk, ss.Dest)
+	}
+
+	regs := make([]string, 0, len(state.RegisterStatus))
+	for reg := range state.RegisterStatus {
+		regs = append(regs, reg)
+	}
+	sort.Slice(regs, func(a, b int) bool { return regNumber(regs[a]) < regNumber(regs[b]) })
+	fmt.Println("\n寄存器状态表：")
+	if len(regs) == 0 {
+		fmt.Println("  (所有寄存器的值均已就绪)")
+	}
+	for _, reg := range regs {
+		fmt.Printf("  %s ← %s\n", reg, state.RegisterStatus[reg])
+	}
+
+	if ts.useROB() {
+		fmt.Println("\n重排序缓冲 (队首在上)：")
+		if len(state.ROB) == 0 {
+			fmt.Println("  (空)")
+		}
+		for _, r := range state.ROB {
+			fmt.Printf("  #%-2d %-22s %-6s %s\n", r.Entry, r.Instr, r.State, r.Dest)
+		}
+	}
+}
+
+// GetStatistics 获取统计信息
+func (ts *TomasuloSimulator) GetStatistics() string {
+	n := len(ts.Instructions)
+	if n == 0 || ts.Cycles == 0 {
+		return "无统计信息"
+	}
+	rob := "不使用"
+	if ts.useROB() {
+		rob = fmt.Sprintf("%d 项", ts.Config.ROBSize)
+	}
+	return fmt.Sprintf(`
+Tomasulo 统计信息：
+  指令数量:       %d
+  执行周期数:     %d
+  IPC:            %.3f
+  保留站:         Add×%d, Mult×%d, Load×%d, Store×%d
+  重排序缓冲:     %s
+  CDB 利用率:     %.2f%%
+
+发射停顿：
+  保留站已满:     %d 周期
+  ROB 已满:       %d 周期
+  等待分支结果:   %d 周期
+`,
+		n, ts.Cycles, float64(n)/float64(ts.Cycles),
+		ts.Config.AddStations, ts.Config.MulStations, ts.Config.LoadBuffers, ts.Config.StoreBuffers,
+		rob, float64(ts.CDBBusy)/float64(ts.Cycles)*100,
+		ts.StationStall, ts.ROBStall, ts.BranchStall) + ts.incompleteNote()
+}
+
+// incompleteNote 模拟未完成时附加在统计信息后的提示
+func (ts *TomasuloSimulator) incompleteNote() string {
+	if !ts.Incomplete {
+		return ""
+	}
+	return fmt.Sprintf("\n注意：达到周期上限 %d 时仍有指令未完成，以上统计只是部分结果\n", ts.Cycles)
+}
+
+// cycleText 周期为 0 时显示 "-"
+func cycleText(cycle int) string {
+	if cycle == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d", cycle)
+}
+
+// regNumber 返回 "R<编号>" 的编号，用于排序
+func regNumber(reg string) int {
+	var num int
+	if _, err := fmt.Sscanf(reg, "R%d", &num); err != nil {
+		return 1 << 30
+	}
+	return num
+}
+
+// TomasuloExample Tomasulo 动态调度示例
+func TomasuloExample() {
+	fmt.Println("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Println("  Tomasulo 动态调度")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	// 教材经典例子（浮点寄存器换成通用寄存器）
+	source := `
+lw   R6, 34(R12)
+lw   R2, 45(R13)
+mul  R10, R2, R4
+sub  R8, R6, R2
+div  R11, R10, R6
+add  R6, R8, R2     # 与 div 读 R6 构成 WAR，重命名后不必等待
+`
+	fmt.Println("\n指令序列：")
+	fmt.Println(strings.Trim(source, "\n"))
+	instructions, err := Assemble(source)
+	if err != nil {
+		fmt.Println("汇编失败:", err)
+		return
+	}
+	fmt.Println("\n执行周期：Load/Store 2，加减 2，乘法 6，除法 12")
+
+	fmt.Println("\n【不使用 ROB：乱序执行、乱序完成】")
+	simulator := NewTomasuloSimulator(instructions, TomasuloConfig{})
+	simulator.Run()
+	simulator.PrintStatusTable()
+	simulator.PrintState(6)
+	fmt.Println(simulator.GetStatistics())
+
+	fmt.Println("\n【使用 6 项 ROB：乱序执行、按序提交】")
+	simulator = NewTomasuloSimulator(instructions, TomasuloConfig{ROBSize: 6})
+	simulator.Run()
+	simulator.PrintStatusTable()
+	simulator.PrintState(12)
+	fmt.Println(simulator.GetStatistics())
+}
@@ -1,6 +1,20 @@
 package application
 
+import "CS_Core_Courses/registry"
+
+func init() {
+	registry.Register(&registry.Module{
+		Name:    "net/application",
+		Section: "4.1",
+		Title:   "应用层",
+		Run:     RunAllApplicationExamples,
+		Examples: []registry.Example{
+			{Name: "http", Run: HTTPExample},
+		},
+	})
+}
+
 // RunAllApplicationExamples 运行所有应用层协议的示例
 func RunAllApplicationExamples() {
 	HTTPExample()
-}
\ No newline at end of file
+}
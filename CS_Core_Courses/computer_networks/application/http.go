@@ -357,7 +357,7 @@ func (client *SimpleHTTPClient) Do(method HTTPMethod, url, body string) (*HTTPRe
 	}
 
 	// 创建TCP连接
-	address := fmt.Sprintf("%s:%s", urlInfo.Host, urlInfo.Port)
+	address := net.JoinHostPort(urlInfo.Host, urlInfo.Port)
 	conn, err := net.DialTimeout("tcp", address, client.Timeout)
 	if err != nil {
 		return nil, err
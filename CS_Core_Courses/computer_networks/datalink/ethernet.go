@@ -5,6 +5,8 @@ import (
 	"math/rand"
 	"strings"
 	"time"
+
+	"CS_Core_Courses/trace"
 )
 
 // EthernetFrame 以太网帧结构
@@ -77,9 +79,10 @@ func getFrameTypeName(frameType uint16) string {
 // CSMACD CSMA/CD 协议模拟
 // 对应 408 考点: 载波侦听多路访问/冲突检测
 type CSMACD struct {
-	Channel      bool       // 信道状态: true=忙, false=空闲
-	Stations     []*Station // 所有站点
-	CollisionLog []string   // 冲突日志
+	Channel      bool         // 信道状态: true=忙, false=空闲
+	Stations     []*Station   // 所有站点
+	CollisionLog []string     // 冲突日志
+	Tracer       trace.Tracer // 事件输出（nil 时使用默认控制台）
 }
 
 // Station 站点
@@ -121,26 +124,30 @@ func (c *CSMACD) SendFrame(stationName string, destMAC string, data []byte) bool
 	}
 
 	if station == nil {
-		fmt.Printf("错误: 站点 %s 不存在\n", stationName)
+		c.emit(0, "error", fmt.Sprintf("错误: 站点 %s 不存在", stationName),
+			map[string]any{"station": stationName})
 		return false
 	}
 
-	fmt.Printf("\n[%s] 准备发送数据...\n", station.Name)
+	c.emit(0, "prepare", fmt.Sprintf("\n[%s] 准备发送数据...", station.Name),
+		map[string]any{"station": station.Name, "dest": destMAC, "bytes": len(data)})
 
 	// CSMA/CD 流程
 	for attempt := 0; attempt <= station.MaxRetries; attempt++ {
 		// 1. 载波侦听 (Carrier Sense)
-		fmt.Printf("[%s] 第 %d 次尝试: 侦听信道...", station.Name, attempt+1)
 		if c.Channel {
-			fmt.Println(" 信道忙,等待...")
+			c.emit(attempt+1, "busy", fmt.Sprintf("[%s] 第 %d 次尝试: 侦听信道... 信道忙,等待...", station.Name, attempt+1),
+				map[string]any{"station": station.Name})
 			time.Sleep(10 * time.Millisecond)
 			continue
 		}
-		fmt.Println(" 信道空闲")
+		c.emit(attempt+1, "idle", fmt.Sprintf("[%s] 第 %d 次尝试: 侦听信道... 信道空闲", station.Name, attempt+1),
+			map[string]any{"station": station.Name})
 
 		// 2. 发送数据
 		c.Channel = true
-		fmt.Printf("[%s] 开始发送数据...\n", station.Name)
+		c.emit(attempt+1, "transmit", fmt.Sprintf("[%s] 开始发送数据...", station.Name),
+			map[string]any{"station": station.Name})
 
 		// 模拟冲突检测 (Collision Detection)
 		collision := c.simulateCollision()
@@ -149,7 +156,8 @@ func (c *CSMACD) SendFrame(stationName string, destMAC string, data []byte) bool
 			c.Channel = false
 			c.CollisionLog = append(c.CollisionLog,
 				fmt.Sprintf("[冲突] %s 在第 %d 次尝试时检测到冲突", station.Name, attempt+1))
-			fmt.Printf("[%s] ✗ 检测到冲突!\n", station.Name)
+			c.emit(attempt+1, "collision", fmt.Sprintf("[%s] ✗ 检测到冲突!", station.Name),
+				map[string]any{"station": station.Name})
 
 			// 截断二进制指数退避 (Truncated Binary Exponential Backoff)
 			k := attempt
@@ -158,8 +166,9 @@ func (c *CSMACD) SendFrame(stationName string, destMAC string, data []byte) bool
 			}
 			maxSlots := (1 << k) - 1 // 2^k - 1
 			backoffSlots := rand.Intn(maxSlots + 1)
-			fmt.Printf("[%s] 执行退避算法: k=%d, 随机退避 %d 个时隙\n",
-				station.Name, k, backoffSlots)
+			c.emit(attempt+1, "backoff", fmt.Sprintf("[%s] 执行退避算法: k=%d, 随机退避 %d 个时隙",
+				station.Name, k, backoffSlots),
+				map[string]any{"station": station.Name, "k": k, "slots": backoffSlots})
 
 			// 模拟退避时间
 			time.Sleep(time.Duration(backoffSlots*10) * time.Millisecond)
@@ -169,16 +178,28 @@ func (c *CSMACD) SendFrame(stationName string, destMAC string, data []byte) bool
 		// 3. 发送成功
 		c.Channel = false
 		frame := NewEthernetFrame(destMAC, station.MAC, 0x0800, data)
-		fmt.Printf("[%s] ✓ 发送成功!\n", station.Name)
-		fmt.Println(frame)
+		c.emit(attempt+1, "success", fmt.Sprintf("[%s] ✓ 发送成功!\n%s", station.Name, frame),
+			map[string]any{"station": station.Name, "attempts": attempt + 1})
 		return true
 	}
 
 	// 超过最大重传次数
-	fmt.Printf("[%s] ✗ 超过最大重传次数 (%d),发送失败\n", station.Name, station.MaxRetries)
+	c.emit(station.MaxRetries+1, "abort", fmt.Sprintf("[%s] ✗ 超过最大重传次数 (%d),发送失败", station.Name, station.MaxRetries),
+		map[string]any{"station": station.Name})
 	return false
 }
 
+// emit 发送 CSMA/CD 事件，attempt 为当前尝试次数（从 1 开始，0 表示尚未尝试）
+func (c *CSMACD) emit(attempt int, kind, message string, fields map[string]any) {
+	trace.Emit(c.Tracer, trace.Event{
+		Cycle:     attempt,
+		Component: "csmacd",
+		Kind:      kind,
+		Message:   message,
+		Fields:    fields,
+	})
+}
+
 // simulateCollision 模拟冲突 (30% 概率)
 func (c *CSMACD) simulateCollision() bool {
 	return rand.Float32() < 0.3
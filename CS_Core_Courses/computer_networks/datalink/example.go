@@ -1,6 +1,24 @@
 package datalink
 
-import "fmt"
+import (
+	"fmt"
+
+	"CS_Core_Courses/registry"
+)
+
+func init() {
+	registry.Register(&registry.Module{
+		Name:    "net/datalink",
+		Section: "4.4",
+		Title:   "数据链路层",
+		Run:     RunAllDatalinkExamples,
+		Examples: []registry.Example{
+			{Name: "ethernet", Run: EthernetExample},
+			{Name: "error_detection", Run: ErrorDetectionExample},
+			{Name: "sliding_window", Run: SlidingWindowExample},
+		},
+	})
+}
 
 func RunAllDatalinkExamples() {
 	fmt.Println("\n╔══════════════════════════════════════╗")
@@ -3,6 +3,8 @@ package datalink
 import (
 	"fmt"
 	"strings"
+
+	"CS_Core_Courses/trace"
 )
 
 // Frame 数据帧
@@ -60,13 +62,14 @@ func (sw *StopAndWait) ACK() {
 // GoBackN 回退 N 帧协议
 // 对应 408 考点: GBN 协议,发送窗口 > 1,接收窗口 = 1
 type GoBackN struct {
-	WindowSize  int      // 窗口大小
-	SeqNumBits  int      // 序号位数
-	MaxSeqNum   int      // 最大序号 (2^n - 1)
-	SendBase    int      // 发送窗口基序号
-	NextSeqNum  int      // 下一个待发送序号
-	ExpectedSeq int      // 接收方期望序号
-	SentFrames  []*Frame // 已发送但未确认的帧
+	WindowSize  int          // 窗口大小
+	SeqNumBits  int          // 序号位数
+	MaxSeqNum   int          // 最大序号 (2^n - 1)
+	SendBase    int          // 发送窗口基序号
+	NextSeqNum  int          // 下一个待发送序号
+	ExpectedSeq int          // 接收方期望序号
+	SentFrames  []*Frame     // 已发送但未确认的帧
+	Tracer      trace.Tracer // 事件输出（nil 时使用默认控制台）
 }
 
 // NewGoBackN 创建 GBN 协议
@@ -97,15 +100,16 @@ func (gbn *GoBackN) CanSend() bool {
 // Send 发送帧
 func (gbn *GoBackN) Send(data string) bool {
 	if !gbn.CanSend() {
-		fmt.Printf("发送方: 窗口已满 [%d, %d), 无法发送\n",
-			gbn.SendBase, gbn.SendBase+gbn.WindowSize)
+		gbn.emit("window_full", fmt.Sprintf("发送方: 窗口已满 [%d, %d), 无法发送",
+			gbn.SendBase, gbn.SendBase+gbn.WindowSize), nil)
 		return false
 	}
 
 	frame := &Frame{SeqNum: gbn.NextSeqNum % (gbn.MaxSeqNum + 1), Data: data}
 	gbn.SentFrames = append(gbn.SentFrames, frame)
-	fmt.Printf("发送方: 发送 %s, 窗口 [%d, %d)\n",
-		frame, gbn.SendBase, gbn.SendBase+gbn.WindowSize)
+	gbn.emit("send", fmt.Sprintf("发送方: 发送 %s, 窗口 [%d, %d)",
+		frame, gbn.SendBase, gbn.SendBase+gbn.WindowSize),
+		map[string]any{"seq": frame.SeqNum, "data": frame.Data})
 	gbn.NextSeqNum++
 	return true
 }
@@ -114,7 +118,8 @@ func (gbn *GoBackN) Send(data string) bool {
 func (gbn *GoBackN) Receive(frame *Frame) int {
 	expectedSeq := gbn.ExpectedSeq % (gbn.MaxSeqNum + 1)
 	if frame.SeqNum == expectedSeq {
-		fmt.Printf("接收方: 正确接收 %s, 发送 ACK %d\n", frame, expectedSeq)
+		gbn.emit("receive", fmt.Sprintf("接收方: 正确接收 %s, 发送 ACK %d", frame, expectedSeq),
+			map[string]any{"seq": frame.SeqNum, "ack": expectedSeq})
 		gbn.ExpectedSeq++
 		return expectedSeq
 	}
@@ -123,15 +128,16 @@ func (gbn *GoBackN) Receive(frame *Frame) int {
 	if lastACK < 0 {
 		lastACK = gbn.MaxSeqNum
 	}
-	fmt.Printf("接收方: 收到失序帧 %s (期望 %d), 丢弃, 重发 ACK %d\n",
-		frame, expectedSeq, lastACK)
+	gbn.emit("discard", fmt.Sprintf("接收方: 收到失序帧 %s (期望 %d), 丢弃, 重发 ACK %d",
+		frame, expectedSeq, lastACK),
+		map[string]any{"seq": frame.SeqNum, "expected": expectedSeq, "ack": lastACK})
 	return lastACK
 }
 
 // ACK 确认
 func (gbn *GoBackN) ACK(ackNum int) {
 	// 累积确认: ACK n 表示 n 及之前的所有帧都正确接收
-	fmt.Printf("发送方: 收到 ACK %d (累积确认)\n", ackNum)
+	gbn.emit("ack", fmt.Sprintf("发送方: 收到 ACK %d (累积确认)", ackNum), map[string]any{"ack": ackNum})
 	// 更新窗口基序号
 	ackedCount := 0
 	for i := gbn.SendBase; i <= ackNum; i++ {
@@ -142,18 +148,31 @@ func (gbn *GoBackN) ACK(ackNum int) {
 	if ackedCount > 0 && ackedCount <= len(gbn.SentFrames) {
 		gbn.SentFrames = gbn.SentFrames[ackedCount:]
 	}
-	fmt.Printf("发送方: 窗口前移到 [%d, %d)\n", gbn.SendBase, gbn.SendBase+gbn.WindowSize)
+	gbn.emit("slide", fmt.Sprintf("发送方: 窗口前移到 [%d, %d)", gbn.SendBase, gbn.SendBase+gbn.WindowSize),
+		map[string]any{"base": gbn.SendBase})
 }
 
 // Timeout 超时,重传所有已发送但未确认的帧
 func (gbn *GoBackN) Timeout() {
-	fmt.Printf("发送方: 超时! 回退重传从 %d 开始的所有帧\n", gbn.SendBase)
+	gbn.emit("timeout", fmt.Sprintf("发送方: 超时! 回退重传从 %d 开始的所有帧", gbn.SendBase),
+		map[string]any{"base": gbn.SendBase})
 	for _, frame := range gbn.SentFrames {
-		fmt.Printf("发送方: 重传 %s\n", frame)
+		gbn.emit("retransmit", fmt.Sprintf("发送方: 重传 %s", frame), map[string]any{"seq": frame.SeqNum})
 	}
 	gbn.NextSeqNum = gbn.SendBase + len(gbn.SentFrames)
 }
 
+// emit 发送 GBN 协议事件，逻辑时间取下一个待发送序号
+func (gbn *GoBackN) emit(kind, message string, fields map[string]any) {
+	trace.Emit(gbn.Tracer, trace.Event{
+		Cycle:     gbn.NextSeqNum,
+		Component: "gbn",
+		Kind:      kind,
+		Message:   message,
+		Fields:    fields,
+	})
+}
+
 // SelectiveRepeat 选择重传协议
 // 对应 408 考点: SR 协议,发送窗口 = 接收窗口,窗口大小 <= 2^(n-1)
 type SelectiveRepeat struct {
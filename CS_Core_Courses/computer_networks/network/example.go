@@ -1,6 +1,24 @@
 package network
 
-import "fmt"
+import (
+	"fmt"
+
+	"CS_Core_Courses/registry"
+)
+
+func init() {
+	registry.Register(&registry.Module{
+		Name:    "net/network",
+		Section: "4.3",
+		Title:   "网络层",
+		Run:     RunAllNetworkExamples,
+		Examples: []registry.Example{
+			{Name: "ip", Run: IPExample},
+			{Name: "routing", Run: RoutingExample},
+			{Name: "arp", Run: ARPExample},
+		},
+	})
+}
 
 func RunAllNetworkExamples() {
 	fmt.Println("\n╔══════════════════════════════════════╗")
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	"CS_Core_Courses/trace"
 )
 
 // DNSRecordType DNS 记录类型
@@ -148,8 +150,9 @@ func (s *DNSServer) Query(name string, recordType DNSRecordType) (*DNSRecord, bo
 
 // DNSResolver DNS 解析器
 type DNSResolver struct {
-	LocalCache *DNSCache  // 本地缓存
-	LocalDNS   *DNSServer // 本地 DNS 服务器
+	LocalCache *DNSCache    // 本地缓存
+	LocalDNS   *DNSServer   // 本地 DNS 服务器
+	Tracer     trace.Tracer // 事件输出（nil 时使用默认控制台）
 }
 
 // NewDNSResolver 创建 DNS 解析器
@@ -163,81 +166,108 @@ func NewDNSResolver(localDNS *DNSServer) *DNSResolver {
 // ResolveRecursive 递归查询
 // 对应 408 考点: 客户端向本地 DNS 服务器发起递归查询
 func (r *DNSResolver) ResolveRecursive(name string, recordType DNSRecordType) (*DNSRecord, bool) {
-	fmt.Printf("\n【递归查询】客户端 → 本地DNS: 查询 %s (%s)\n", name, recordType)
+	r.emit(0, "query", fmt.Sprintf("\n【递归查询】客户端 → 本地DNS: 查询 %s (%s)", name, recordType),
+		map[string]any{"name": name, "type": string(recordType), "mode": "recursive"})
 
 	// 1. 查询本地缓存
-	fmt.Printf("  步骤 1: 查询本地缓存...\n")
+	r.emit(1, "cache_lookup", "  步骤 1: 查询本地缓存...", nil)
 	if record, found := r.LocalCache.Lookup(name, recordType); found {
-		fmt.Printf("  ✓ 命中缓存: %s\n", record)
+		r.emit(1, "cache_hit", fmt.Sprintf("  ✓ 命中缓存: %s", record), recordFields(record))
 		return record, true
 	}
-	fmt.Printf("  ✗ 缓存未命中\n")
+	r.emit(1, "cache_miss", "  ✗ 缓存未命中", nil)
 
 	// 2. 向本地 DNS 服务器查询
-	fmt.Printf("  步骤 2: 向本地DNS服务器 [%s] 查询...\n", r.LocalDNS.Name)
+	r.emit(2, "server_query", fmt.Sprintf("  步骤 2: 向本地DNS服务器 [%s] 查询...", r.LocalDNS.Name),
+		map[string]any{"server": r.LocalDNS.Name})
 	if record, found := r.LocalDNS.Query(name, recordType); found {
-		fmt.Printf("  ✓ 本地DNS服务器返回: %s\n", record)
+		r.emit(2, "answer", fmt.Sprintf("  ✓ 本地DNS服务器返回: %s", record), recordFields(record))
 		r.LocalCache.Add(record) // 加入缓存
 		return record, true
 	}
 
 	// 3. 本地 DNS 服务器负责向根、顶级域、权威 DNS 查询 (递归)
-	fmt.Printf("  步骤 3: 本地DNS服务器递归查询上级服务器...\n")
+	r.emit(3, "server_query", "  步骤 3: 本地DNS服务器递归查询上级服务器...", nil)
 	if r.LocalDNS.Parent != nil {
 		if record, found := r.LocalDNS.Parent.Query(name, recordType); found {
-			fmt.Printf("  ✓ 上级DNS服务器 [%s] 返回: %s\n", r.LocalDNS.Parent.Name, record)
+			r.emit(3, "answer", fmt.Sprintf("  ✓ 上级DNS服务器 [%s] 返回: %s", r.LocalDNS.Parent.Name, record),
+				recordFields(record))
 			r.LocalDNS.AddRecord(record) // 本地 DNS 缓存
 			r.LocalCache.Add(record)     // 客户端缓存
 			return record, true
 		}
 	}
 
-	fmt.Printf("  ✗ 查询失败: 域名 %s 不存在\n", name)
+	r.emit(-1, "fail", fmt.Sprintf("  ✗ 查询失败: 域名 %s 不存在", name), map[string]any{"name": name})
 	return nil, false
 }
 
 // ResolveIterative 迭代查询
 // 对应 408 考点: DNS 服务器之间的迭代查询
 func (r *DNSResolver) ResolveIterative(name string, recordType DNSRecordType) (*DNSRecord, bool) {
-	fmt.Printf("\n【迭代查询】客户端主导查询 %s (%s)\n", name, recordType)
+	r.emit(0, "query", fmt.Sprintf("\n【迭代查询】客户端主导查询 %s (%s)", name, recordType),
+		map[string]any{"name": name, "type": string(recordType), "mode": "iterative"})
 
 	// 1. 查询本地缓存
-	fmt.Printf("  步骤 1: 查询本地缓存...\n")
+	r.emit(1, "cache_lookup", "  步骤 1: 查询本地缓存...", nil)
 	if record, found := r.LocalCache.Lookup(name, recordType); found {
-		fmt.Printf("  ✓ 命中缓存: %s\n", record)
+		r.emit(1, "cache_hit", fmt.Sprintf("  ✓ 命中缓存: %s", record), recordFields(record))
 		return record, true
 	}
-	fmt.Printf("  ✗ 缓存未命中\n")
+	r.emit(1, "cache_miss", "  ✗ 缓存未命中", nil)
 
 	// 2. 向本地 DNS 查询
-	fmt.Printf("  步骤 2: 向本地DNS [%s] 查询...\n", r.LocalDNS.Name)
+	r.emit(2, "server_query", fmt.Sprintf("  步骤 2: 向本地DNS [%s] 查询...", r.LocalDNS.Name),
+		map[string]any{"server": r.LocalDNS.Name})
 	if record, found := r.LocalDNS.Query(name, recordType); found {
-		fmt.Printf("  ✓ 返回: %s\n", record)
+		r.emit(2, "answer", fmt.Sprintf("  ✓ 返回: %s", record), recordFields(record))
 		r.LocalCache.Add(record)
 		return record, true
 	}
-	fmt.Printf("  ✗ 未找到,返回下一级服务器地址\n")
+	r.emit(2, "referral", "  ✗ 未找到,返回下一级服务器地址", nil)
 
 	// 3. 客户端向上级 DNS 查询 (迭代)
 	currentServer := r.LocalDNS.Parent
 	step := 3
 	for currentServer != nil {
-		fmt.Printf("  步骤 %d: 向上级DNS [%s] 查询...\n", step, currentServer.Name)
+		r.emit(step, "server_query", fmt.Sprintf("  步骤 %d: 向上级DNS [%s] 查询...", step, currentServer.Name),
+			map[string]any{"server": currentServer.Name})
 		if record, found := currentServer.Query(name, recordType); found {
-			fmt.Printf("  ✓ 返回: %s\n", record)
+			r.emit(step, "answer", fmt.Sprintf("  ✓ 返回: %s", record), recordFields(record))
 			r.LocalCache.Add(record)
 			r.LocalDNS.AddRecord(record) // 本地 DNS 学习记录
 			return record, true
 		}
-		fmt.Printf("  ✗ 未找到,继续向上查询\n")
+		r.emit(step, "referral", "  ✗ 未找到,继续向上查询", nil)
 		currentServer = currentServer.Parent
 		step++
 	}
 
-	fmt.Printf("  ✗ 查询失败: 域名 %s 不存在\n", name)
+	r.emit(-1, "fail", fmt.Sprintf("  ✗ 查询失败: 域名 %s 不存在", name), map[string]any{"name": name})
 	return nil, false
 }
 
+// emit 发送 DNS 解析事件，step 为查询步骤编号（-1 表示查询结束）
+func (r *DNSResolver) emit(step int, kind, message string, fields map[string]any) {
+	trace.Emit(r.Tracer, trace.Event{
+		Cycle:     step,
+		Component: "dns",
+		Kind:      kind,
+		Message:   message,
+		Fields:    fields,
+	})
+}
+
+// recordFields 把 DNS 记录转换为事件字段
+func recordFields(record *DNSRecord) map[string]any {
+	return map[string]any{
+		"name":  record.Name,
+		"type":  string(record.Type),
+		"value": record.Value,
+		"ttl":   record.TTL,
+	}
+}
+
 // DNSExample DNS 协议示例
 func DNSExample() {
 	fmt.Println("\n" + strings.Repeat("─", 50))
@@ -1,6 +1,22 @@
 package protocols
 
-import "fmt"
+import (
+	"fmt"
+
+	"CS_Core_Courses/registry"
+)
+
+func init() {
+	registry.Register(&registry.Module{
+		Name:    "net/protocols",
+		Section: "4.5",
+		Title:   "网络协议",
+		Run:     RunAllProtocolExamples,
+		Examples: []registry.Example{
+			{Name: "dns", Run: DNSExample},
+		},
+	})
+}
 
 func RunAllProtocolExamples() {
 	fmt.Println("\n╔══════════════════════════════════════╗")
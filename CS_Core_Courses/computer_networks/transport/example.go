@@ -1,7 +1,21 @@
 package transport
 
+import "CS_Core_Courses/registry"
+
+func init() {
+	registry.Register(&registry.Module{
+		Name:    "net/transport",
+		Section: "4.2",
+		Title:   "传输层",
+		Run:     RunAllTransportExamples,
+		Examples: []registry.Example{
+			{Name: "tcp", Run: TCPExample},
+		},
+	})
+}
+
 // RunAllTransportExamples 运行所有传输层协议的示例
 func RunAllTransportExamples() {
 	TCPExample()
 	// 可以添加UDP等其他传输层协议的示例
-}
\ No newline at end of file
+}
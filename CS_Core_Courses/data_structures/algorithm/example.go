@@ -1,6 +1,27 @@
 package algorithm
 
-import "fmt"
+import (
+	"fmt"
+
+	"CS_Core_Courses/registry"
+)
+
+func init() {
+	registry.Register(&registry.Module{
+		Name:    "ds/algorithm",
+		Section: "1.3",
+		Title:   "算法",
+		Run:     RunAllAlgorithmExamples,
+		Examples: []registry.Example{
+			{Name: "sorting", Run: SortingExample},
+			{Name: "searching", Run: SearchingExample},
+			{Name: "dp", Run: DPExample},
+			{Name: "greedy", Run: GreedyExample},
+			{Name: "backtracking", Run: BacktrackingExample},
+			{Name: "string_matching", Run: StringMatchingExample},
+		},
+	})
+}
 
 // RunAllAlgorithmExamples 运行所有算法示例
 func RunAllAlgorithmExamples() {
@@ -1,5 +1,23 @@
 package basic
 
+import "CS_Core_Courses/registry"
+
+func init() {
+	registry.Register(&registry.Module{
+		Name:    "ds/basic",
+		Section: "1.1",
+		Title:   "基础数据结构",
+		Run:     RunAllBasicExamples,
+		Examples: []registry.Example{
+			{Name: "array", Run: ArrayExample},
+			{Name: "linkedlist", Run: LinkedListExample},
+			{Name: "stack", Run: StackExample},
+			{Name: "queue", Run: QueueExample},
+			{Name: "hashtable", Run: HashTableExample},
+		},
+	})
+}
+
 // RunAllBasicExamples 运行所有基础数据结构的示例
 func RunAllBasicExamples() {
 	ArrayExample()
@@ -1,6 +1,23 @@
 package linear
 
-import "fmt"
+import (
+	"fmt"
+
+	"CS_Core_Courses/registry"
+)
+
+func init() {
+	registry.Register(&registry.Module{
+		Name:    "ds/linear",
+		Section: "1.2",
+		Title:   "线性结构进阶",
+		Run:     RunAllLinearExamples,
+		Examples: []registry.Example{
+			{Name: "string", Run: StringADTExample},
+			{Name: "sparse_matrix", Run: SparseMatrixExample},
+		},
+	})
+}
 
 // RunAllLinearExamples 运行所有线性结构进阶示例
 func RunAllLinearExamples() {
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"CS_Core_Courses/operating_system/filesystem"
+)
+
+// cmdFsh 在磁盘映像上启动文件系统 shell
+func cmdFsh(args []string) int {
+	fs := flag.NewFlagSet("fsh", flag.ContinueOnError)
+	format := fs.Bool("format", false, "先格式化映像（映像不存在时总是格式化）")
+	blocks := fs.Int("blocks", 1024, "格式化时的总块数（每块 4KB）")
+	inodes := fs.Int("inodes", 128, "格式化时的 inode 数")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "用法: %s fsh [选项] <映像文件>\n\n", programName)
+		fmt.Fprintln(os.Stderr, "从标准输入读取命令（ls、cd、mkdir、cat、echo、rm、ln、stat、tree 等，输入 help 查看）；")
+		fmt.Fprintln(os.Stderr, "所有修改都保存在映像文件中，下次打开同一映像时内容不变")
+		fmt.Fprintln(os.Stderr, "\n选项:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	image := fs.Arg(0)
+	var disk *filesystem.DiskFS
+	_, err := os.Stat(image)
+	if *format || errors.Is(err, os.ErrNotExist) {
+		disk, err = filesystem.Format(image, *blocks, *inodes)
+	} else if err == nil {
+		disk, err = filesystem.Mount(image)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "错误:", err)
+		return exitFailure
+	}
+
+	// 标准输入是终端时显示提示符，否则（管道或重定向的脚本）回显每条命令
+	interactive := false
+	if info, err := os.Stdin.Stat(); err == nil {
+		interactive = info.Mode()&os.ModeCharDevice != 0
+	}
+	err = filesystem.NewShell(disk, os.Stdout).Run(os.Stdin, interactive)
+	if uerr := disk.Unmount(); err == nil {
+		err = uerr
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "错误:", err)
+		return exitFailure
+	}
+	return exitOK
+}
@@ -2,27 +2,184 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
-	"CS_Core_Courses/computer_architecture/cpu"
-	"CS_Core_Courses/computer_architecture/instruction_set"
-	archmemory "CS_Core_Courses/computer_architecture/memory"
-	"CS_Core_Courses/computer_architecture/pipeline"
-	"CS_Core_Courses/computer_networks/application"
-	"CS_Core_Courses/computer_networks/datalink"
-	"CS_Core_Courses/computer_networks/network"
-	"CS_Core_Courses/computer_networks/protocols"
-	"CS_Core_Courses/computer_networks/transport"
-	"CS_Core_Courses/data_structures/algorithm"
-	"CS_Core_Courses/data_structures/basic"
-	"CS_Core_Courses/data_structures/linear"
-	"CS_Core_Courses/operating_system/filesystem"
-	osmemory "CS_Core_Courses/operating_system/memory"
-	"CS_Core_Courses/operating_system/process"
-	"CS_Core_Courses/operating_system/scheduling"
+	// 以下包在 init() 中向 registry 注册自己的示例
+	_ "CS_Core_Courses/computer_architecture/cpu"
+	_ "CS_Core_Courses/computer_architecture/instruction_set"
+	_ "CS_Core_Courses/computer_architecture/memory"
+	_ "CS_Core_Courses/computer_architecture/pipeline"
+	_ "CS_Core_Courses/computer_networks/application"
+	_ "CS_Core_Courses/computer_networks/datalink"
+	_ "CS_Core_Courses/computer_networks/network"
+	_ "CS_Core_Courses/computer_networks/protocols"
+	_ "CS_Core_Courses/computer_networks/transport"
+	_ "CS_Core_Courses/data_structures/algorithm"
+	_ "CS_Core_Courses/data_structures/basic"
+	_ "CS_Core_Courses/data_structures/linear"
+	_ "CS_Core_Courses/operating_system/filesystem"
+	_ "CS_Core_Courses/operating_system/memory"
+	_ "CS_Core_Courses/operating_system/process"
+	_ "CS_Core_Courses/operating_system/scheduling"
+	"CS_Core_Courses/registry"
 )
 
+const programName = "cs408"
+
+// 退出码
+const (
+	exitOK      = 0
+	exitFailure = 1 // 示例运行失败
+	exitUsage   = 2 // 命令行参数错误
+)
+
+// groupTitles 章节大类标题（对应 Section 的第一段）
+var groupTitles = map[string]string{
+	"1": "数据结构与算法",
+	"2": "操作系统",
+	"3": "计算机组成原理",
+	"4": "计算机网络",
+}
+
 func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+// run 解析子命令并返回退出码
+func run(args []string) int {
+	if len(args) == 0 {
+		printUsage()
+		return exitUsage
+	}
+
+	switch args[0] {
+	case "list", "ls":
+		return cmdList()
+	case "run":
+		return cmdRun(args[1:])
+	case "all":
+		return cmdAll()
+	case "memtrace":
+		return cmdMemTrace(args[1:])
+	case "pagesim":
+		return cmdPageSim(args[1:])
+	case "allocsim":
+		return cmdAllocSim(args[1:])
+	case "fsh":
+		return cmdFsh(args[1:])
+	case "help", "-h", "--help":
+		printUsage()
+		return exitOK
+	default:
+		fmt.Fprintf(os.Stderr, "未知命令 %q\n\n", args[0])
+		printUsage()
+		return exitUsage
+	}
+}
+
+func printUsage() {
+	fmt.Fprintf(os.Stderr, `计算机科学核心课程学习项目（考研408统考）
+
+用法:
+  %[1]s list                              列出所有模块及其示例
+  %[1]s run <模块>... [--only 示例,...]   运行指定模块（可只运行部分示例）
+  %[1]s all                               依次运行全部模块
+  %[1]s memtrace [选项] <轨迹文件>        用访存轨迹驱动 Cache / 虚拟存储器模拟器
+  %[1]s pagesim [选项] <页面引用串>       对比页面替换算法并检测 Belady 异常
+  %[1]s allocsim [选项] [请求文件]        对比动态分区放置策略的碎片与失败次数
+  %[1]s fsh [选项] <映像文件>             在磁盘映像文件系统上运行交互式 shell
+  %[1]s help                              显示本帮助
+
+模块可以写完整标识（如 os/memory）或唯一的短名称（如 pipeline）。
+
+示例:
+  %[1]s run pipeline
+  %[1]s run os/memory --only paging
+  %[1]s run ds/algorithm --only sorting,dp
+  %[1]s memtrace -sim vm -frames 32 app.trace
+  %[1]s pagesim -frames 1-6 -show 4 "1 2 3 4 1 2 5 1 2 3 4 5"
+  %[1]s allocsim -size 4096 -seed 7 -n 500
+  %[1]s fsh disk.img
+`, programName)
+}
+
+// cmdList 列出所有已注册模块
+func cmdList() int {
+	currentGroup := ""
+	for _, m := range registry.Modules() {
+		group := strings.SplitN(m.Section, ".", 2)[0]
+		if group != currentGroup {
+			currentGroup = group
+			fmt.Printf("\n【模块 %s: %s】\n", group, groupTitles[group])
+		}
+		fmt.Printf("  %-4s %-22s %s\n", m.Section, m.Name, m.Title)
+		fmt.Printf("       示例: %s\n", strings.Join(m.ExampleNames(), ", "))
+	}
+	fmt.Println()
+	return exitOK
+}
+
+// cmdRun 运行指定模块
+func cmdRun(args []string) int {
+	var names, only []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--only" || arg == "-only":
+			if i+1 >= len(args) {
+				fmt.Fprintln(os.Stderr, "--only 需要参数")
+				return exitUsage
+			}
+			i++
+			only = append(only, splitList(args[i])...)
+		case strings.HasPrefix(arg, "--only="):
+			only = append(only, splitList(strings.TrimPrefix(arg, "--only="))...)
+		case strings.HasPrefix(arg, "-"):
+			fmt.Fprintf(os.Stderr, "未知选项 %q\n", arg)
+			return exitUsage
+		default:
+			names = append(names, arg)
+		}
+	}
+
+	if len(names) == 0 {
+		fmt.Fprintln(os.Stderr, "请指定要运行的模块（使用 list 查看可用模块）")
+		return exitUsage
+	}
+	if len(only) > 0 && len(names) > 1 {
+		fmt.Fprintln(os.Stderr, "--only 只能与单个模块一起使用")
+		return exitUsage
+	}
+
+	// 先解析全部模块名，参数有误时不运行任何示例
+	selected := make([]*registry.Module, 0, len(names))
+	for _, name := range names {
+		m, err := registry.Lookup(name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUsage
+		}
+		selected = append(selected, m)
+	}
+
+	failed := 0
+	for _, m := range selected {
+		fmt.Printf("\n--- %s %s ---\n", m.Section, m.Title)
+		if err := registry.RunModule(m, only); err != nil {
+			fmt.Fprintln(os.Stderr, "错误:", err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return exitFailure
+	}
+	return exitOK
+}
+
+// cmdAll 依次运行全部模块（原先 main 的默认行为）
+func cmdAll() int {
 	fmt.Println(strings.Repeat("=", 60))
 	fmt.Println("欢迎来到计算机科学核心课程学习项目!")
 	fmt.Println("考研408统考 · 全模块学习平台")
@@ -39,99 +196,29 @@ func main() {
 	fmt.Println("以下将运行各模块的示例代码:")
 	fmt.Println(strings.Repeat("-", 60))
 
-	// ============================
-	// 1. 数据结构与算法
-	// ============================
-	fmt.Println("\n【模块 1: 数据结构与算法】")
-	fmt.Println(strings.Repeat("=", 40))
-
-	// 1.1 基础数据结构
-	fmt.Println("\n--- 1.1 基础数据结构 ---")
-	basic.RunAllBasicExamples()
-
-	// 1.2 线性结构进阶（串、稀疏矩阵）
-	fmt.Println("\n--- 1.2 线性结构进阶 ---")
-	linear.RunAllLinearExamples()
-
-	// 1.3 算法（排序、查找、DP、贪心、回溯、KMP）
-	fmt.Println("\n--- 1.3 算法 ---")
-	algorithm.RunAllAlgorithmExamples()
-
-	// ============================
-	// 2. 操作系统
-	// ============================
-	fmt.Println("\n【模块 2: 操作系统】")
-	fmt.Println(strings.Repeat("=", 40))
-
-	// 2.1 进程管理
-	fmt.Println("\n--- 2.1 进程管理 ---")
-	process.RunAllProcessExamples()
-
-	// 2.2 内存管理（基础 + 分页/分段扩展）
-	fmt.Println("\n--- 2.2 内存管理 ---")
-	osmemory.RunAllMemoryMgmtExamples()
-
-	// 2.3 文件系统
-	fmt.Println("\n--- 2.3 文件系统 ---")
-	filesystem.RunAllFilesystemExamples()
-
-	// 2.4 磁盘调度与死锁
-	fmt.Println("\n--- 2.4 磁盘调度与死锁 ---")
-	scheduling.RunAllSchedulingExamples()
-
-	// ============================
-	// 3. 计算机组成原理
-	// ============================
-	fmt.Println("\n【模块 3: 计算机组成原理】")
-	fmt.Println(strings.Repeat("=", 40))
-
-	// 3.1 CPU（寄存器、ALU）
-	fmt.Println("\n--- 3.1 CPU ---")
-	cpu.RunAllCPUExamples()
-
-	// 3.2 存储器层次（Cache、虚拟内存）
-	fmt.Println("\n--- 3.2 存储器层次 ---")
-	archmemory.RunAllMemoryExamples()
-
-	// 3.3 指令系统
-	fmt.Println("\n--- 3.3 指令系统 ---")
-	instruction_set.RunAllInstructionSetExamples()
-
-	// 3.4 流水线
-	fmt.Println("\n--- 3.4 流水线 ---")
-	pipeline.RunAllPipelineExamples()
-
-	// ============================
-	// 4. 计算机网络
-	// ============================
-	fmt.Println("\n【模块 4: 计算机网络】")
-	fmt.Println(strings.Repeat("=", 40))
-
-	// 4.1 应用层（HTTP）
-	fmt.Println("\n--- 4.1 应用层 ---")
-	application.RunAllApplicationExamples()
-
-	// 4.2 传输层（TCP）
-	fmt.Println("\n--- 4.2 传输层 ---")
-	transport.RunAllTransportExamples()
-
-	// 4.3 网络层（IP、路由、ARP）
-	fmt.Println("\n--- 4.3 网络层 ---")
-	network.RunAllNetworkExamples()
-
-	// 4.4 数据链路层（以太网、差错检测、滑动窗口）
-	fmt.Println("\n--- 4.4 数据链路层 ---")
-	datalink.RunAllDatalinkExamples()
-
-	// 4.5 网络协议（DNS等）
-	fmt.Println("\n--- 4.5 网络协议 ---")
-	protocols.RunAllProtocolExamples()
-
-	// ============================
-	// 结束
-	// ============================
+	failed := 0
+	currentGroup := ""
+	for _, m := range registry.Modules() {
+		group := strings.SplitN(m.Section, ".", 2)[0]
+		if group != currentGroup {
+			currentGroup = group
+			fmt.Printf("\n【模块 %s: %s】\n", group, groupTitles[group])
+			fmt.Println(strings.Repeat("=", 40))
+		}
+
+		fmt.Printf("\n--- %s %s ---\n", m.Section, m.Title)
+		if err := registry.RunModule(m, nil); err != nil {
+			fmt.Fprintln(os.Stderr, "错误:", err)
+			failed++
+		}
+	}
+
 	fmt.Println("\n" + strings.Repeat("=", 60))
-	fmt.Println("所有示例代码运行完成!")
+	if failed > 0 {
+		fmt.Printf("示例运行结束，%d 个模块失败\n", failed)
+	} else {
+		fmt.Println("所有示例代码运行完成!")
+	}
 	fmt.Println(strings.Repeat("=", 60))
 	fmt.Println()
 
@@ -150,4 +237,20 @@ func main() {
 	fmt.Println()
 
 	fmt.Println("Happy Learning!")
+
+	if failed > 0 {
+		return exitFailure
+	}
+	return exitOK
+}
+
+// splitList 拆分逗号分隔的列表并去掉空项
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
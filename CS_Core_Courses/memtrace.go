@@ -0,0 +1,134 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"CS_Core_Courses/computer_architecture/memory"
+	"CS_Core_Courses/trace"
+)
+
+// cmdMemTrace 用访存轨迹文件驱动 Cache 或虚拟存储器模拟器
+func cmdMemTrace(args []string) int {
+	fs := flag.NewFlagSet("memtrace", flag.ContinueOnError)
+	sim := fs.String("sim", "cache", "模拟器：cache 或 vm")
+	cacheSize := fs.Int("size", 32*1024, "Cache 大小（字节）")
+	blockSize := fs.Int("block", 64, "Cache 块大小（字节）")
+	assoc := fs.Int("assoc", 8, "Cache 相联度（1 为直接映射，0 为全相联）")
+	writeThrough := fs.Bool("write-through", false, "Cache 使用写直达（默认写回）")
+	pages := fs.Int("pages", 1024, "虚拟页数（页面大小 4KB）")
+	frames := fs.Int("frames", 64, "物理页框数")
+	tlbSize := fs.Int("tlb", 16, "TLB 表项数")
+	policy := fs.String("policy", "lru", "页面替换算法：fifo、lru、clock、eclock、lfu、aging 或 wsclock")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "用法: %s memtrace [选项] <轨迹文件|->\n\n", programName)
+		fmt.Fprintln(os.Stderr, "轨迹格式：Dinero（\"r 7fff5a8c 4\"）或 Valgrind lackey（\" L 7fff5a8c,4\"），\"-\" 表示标准输入")
+		fmt.Fprintln(os.Stderr, "\n选项:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+	if *sim != "cache" && *sim != "vm" {
+		fmt.Fprintf(os.Stderr, "未知的模拟器 %q（应为 cache 或 vm）\n", *sim)
+		return exitUsage
+	}
+
+	var input io.Reader = os.Stdin
+	if name := fs.Arg(0); name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "错误:", err)
+			return exitFailure
+		}
+		defer f.Close()
+		input = f
+	}
+
+	var (
+		summary *memory.TraceSummary
+		err     error
+		stats   func() string
+	)
+	switch *sim {
+	case "cache":
+		if err := checkCacheGeometry(*cacheSize, *blockSize, *assoc); err != nil {
+			fmt.Fprintln(os.Stderr, "错误:", err)
+			return exitUsage
+		}
+		config := memory.CacheConfig{
+			CacheSize:     *cacheSize,
+			BlockSize:     *blockSize,
+			Associativity: *assoc,
+			MappingType:   memory.SetAssociative,
+		}
+		switch *assoc {
+		case 0:
+			config.MappingType = memory.FullyAssociative
+		case 1:
+			config.MappingType = memory.DirectMapped
+		}
+		if *writeThrough {
+			config.WritePolicy = memory.WriteThrough
+		}
+		cache := memory.NewCacheSimulator(config)
+		cache.Tracer = trace.Discard
+		summary, err = memory.RunCacheTrace(cache, input)
+		stats = cache.GetStatistics
+	case "vm":
+		p, ok := pagePolicies[strings.ToLower(*policy)]
+		if !ok || p == memory.PageOptimal {
+			fmt.Fprintf(os.Stderr, "未知的页面替换算法 %q\n", *policy)
+			return exitUsage
+		}
+		if *pages < 1 || *frames < 1 || *tlbSize < 1 {
+			fmt.Fprintf(os.Stderr, "错误: 页数、页框数和 TLB 表项数都应至少为 1（-pages %d -frames %d -tlb %d）\n",
+				*pages, *frames, *tlbSize)
+			return exitUsage
+		}
+		vms := memory.NewVirtualMemorySimulator(*pages, *frames, *tlbSize, p)
+		summary, err = memory.RunVirtualMemoryTrace(vms, input)
+		stats = vms.GetStatistics
+	}
+
+	if summary != nil {
+		fmt.Print(summary.Report())
+		fmt.Println(stats())
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "错误:", err)
+		return exitFailure
+	}
+	return exitOK
+}
+
+// checkCacheGeometry 检查 Cache 参数：大小、块大小和相联度（0 表示全相联）都应为 2 的幂，
+// 且至少能放下一组
+func checkCacheGeometry(size, block, assoc int) error {
+	if !isPowerOfTwo(size) || !isPowerOfTwo(block) {
+		return fmt.Errorf("Cache 大小 %d 和块大小 %d 都应为正的 2 的幂", size, block)
+	}
+	if assoc != 0 && !isPowerOfTwo(assoc) {
+		return fmt.Errorf("相联度 %d 应为 0（全相联）或正的 2 的幂", assoc)
+	}
+	ways := assoc
+	if ways == 0 {
+		ways = 1
+	}
+	if size < block*ways {
+		return fmt.Errorf("Cache 大小 %d 小于块大小 × 相联度（%d × %d）", size, block, ways)
+	}
+	return nil
+}
+
+// isPowerOfTwo 判断是否为 2 的幂
+func isPowerOfTwo(n int) bool {
+	return n > 0 && n&(n-1) == 0
+}
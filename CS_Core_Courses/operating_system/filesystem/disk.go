@@ -0,0 +1,743 @@
+package filesystem
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ============================================================
+// 磁盘映像布局
+// 408考点：文件系统在磁盘上的布局（超级块、空闲块位示图、inode 区、数据区）、
+//         混合索引分配（直接块 + 一次/二次/三次间接块）
+//
+//   块 0               超级块
+//   块 1 .. b          块位示图（第 i 位为 1 表示块 i 已分配）
+//   块 b+1 .. b+t      inode 表（每块 InodesPerBlock 个 inode）
+//   块 b+t+1 .. N-1    数据块（文件内容、目录项、间接块）
+// ============================================================
+
+const (
+	diskMagic      = 0x34303846                   // 超级块魔数 "408F"
+	inodeSize      = 128                          // 磁盘 inode 的大小（字节）
+	InodesPerBlock = BlockSize / inodeSize        // 每个 inode 表块容纳的 inode 数
+	bitsPerBlock   = BlockSize * 8                // 每个位示图块管理的块数
+	noBlock        = -1                           // 块指针未分配
+	superblockNum  = 0                            // 超级块所在块号
+	ptrSize        = BlockSize / IndirectPerBlock // 块指针的字节数
+)
+
+// 文件系统错误
+var (
+	ErrNotExist     = errors.New("没有那个文件或目录")
+	ErrExist        = errors.New("文件已存在")
+	ErrNotDir       = errors.New("不是目录")
+	ErrIsDir        = errors.New("是一个目录")
+	ErrNotEmpty     = errors.New("目录非空")
+	ErrNoSpace      = errors.New("磁盘空间不足")
+	ErrNoInodes     = errors.New("没有空闲 inode")
+	ErrNameTooLong  = errors.New("文件名过长")
+	ErrFileTooLarge = errors.New("文件过大")
+	ErrInvalid      = errors.New("无效参数")
+	ErrNotMounted   = errors.New("文件系统未挂载")
+	ErrBusy         = errors.New("设备或资源忙")
+	ErrLoop         = errors.New("符号链接的层数过多")
+)
+
+// PathError 记录出错的操作和路径
+type PathError struct {
+	Op   string
+	Path string
+	Err  error
+}
+
+func (e *PathError) Error() string { return e.Op + " " + e.Path + ": " + e.Err.Error() }
+
+func (e *PathError) Unwrap() error { return e.Err }
+
+// Superblock 超级块：记录文件系统各区域的位置和空闲资源数
+type Superblock struct {
+	Magic        int
+	TotalBlocks  int // 磁盘总块数
+	TotalInodes  int // inode 总数
+	BitmapStart  int // 位示图起始块号
+	BitmapBlocks int // 位示图块数
+	InodeStart   int // inode 表起始块号
+	InodeBlocks  int // inode 表块数
+	DataStart    int // 数据区起始块号
+	FreeBlocks   int // 空闲数据块数
+	FreeInodes   int // 空闲 inode 数
+	MountCount   int // 挂载次数
+}
+
+// valid 检查超级块是否与 Format 得到的布局一致，且不超出大小为 size 字节的映像
+func (sb *Superblock) valid(size int64) bool {
+	return sb.Magic == diskMagic &&
+		sb.TotalBlocks > 0 && int64(sb.TotalBlocks)*BlockSize <= size &&
+		sb.BitmapStart == 1 &&
+		sb.BitmapBlocks == (sb.TotalBlocks+bitsPerBlock-1)/bitsPerBlock &&
+		sb.InodeStart == sb.BitmapStart+sb.BitmapBlocks &&
+		sb.InodeBlocks > 0 && sb.TotalInodes == sb.InodeBlocks*InodesPerBlock &&
+		sb.DataStart == sb.InodeStart+sb.InodeBlocks && sb.DataStart < sb.TotalBlocks &&
+		sb.FreeBlocks >= 0 && sb.FreeBlocks <= sb.TotalBlocks-sb.DataStart &&
+		sb.FreeInodes >= 0 && sb.FreeInodes <= sb.TotalInodes
+}
+
+// fields 超级块在磁盘上依次存放的字段
+func (sb *Superblock) fields() []*int {
+	return []*int{&sb.Magic, &sb.TotalBlocks, &sb.TotalInodes, &sb.BitmapStart, &sb.BitmapBlocks,
+		&sb.InodeStart, &sb.InodeBlocks, &sb.DataStart, &sb.FreeBlocks, &sb.FreeInodes, &sb.MountCount}
+}
+
+// Print 打印超级块和磁盘布局
+func (sb *Superblock) Print() {
+	sb.Fprint(os.Stdout)
+}
+
+// Fprint 把超级块和磁盘布局输出到 w
+func (sb *Superblock) Fprint(w io.Writer) {
+	fmt.Fprintf(w, "超级块: 魔数 0x%X，块大小 %d B，共 %d 块 / %d 个 inode\n",
+		sb.Magic, BlockSize, sb.TotalBlocks, sb.TotalInodes)
+	fmt.Fprintln(w, "磁盘布局:")
+	fmt.Fprintf(w, "  %-12s 超级块\n", fmt.Sprintf("块 %d", superblockNum))
+	fmt.Fprintf(w, "  %-12s 块位示图（%d 块）\n", blockRange(sb.BitmapStart, sb.BitmapBlocks), sb.BitmapBlocks)
+	fmt.Fprintf(w, "  %-12s inode 表（%d 块，每块 %d 个 inode）\n", blockRange(sb.InodeStart, sb.InodeBlocks), sb.InodeBlocks, InodesPerBlock)
+	fmt.Fprintf(w, "  %-12s 数据块（%d 块）\n", blockRange(sb.DataStart, sb.TotalBlocks-sb.DataStart), sb.TotalBlocks-sb.DataStart)
+	fmt.Fprintf(w, "空闲: %d 块，%d 个 inode；挂载次数 %d\n", sb.FreeBlocks, sb.FreeInodes, sb.MountCount)
+}
+
+func blockRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprintf("块 %d", start)
+	}
+	return fmt.Sprintf("块 %d-%d", start, start+count-1)
+}
+
+// DiskFS 保存在磁盘映像文件中的文件系统
+// 所有修改都立即写回映像（直写），卸载后重新挂载内容不变
+type DiskFS struct {
+	Path   string     // 映像文件路径
+	Super  Superblock // 超级块（内存副本）
+	image  *os.File
+	Umask  int    // 创建文件时屏蔽的权限位，默认 022
+	bitmap []byte // 块位示图（内存副本）
+	cwd    int    // 当前工作目录的 inode 号（零值即根目录）
+	user   User   // 调用者身份，默认 root
+}
+
+// Format 创建磁盘映像并格式化（相当于 mkfs），返回已挂载的文件系统
+// inode 数会向上取整到整块
+func Format(path string, totalBlocks, totalInodes int) (*DiskFS, error) {
+	if totalBlocks <= 0 || totalInodes <= 0 {
+		return nil, &PathError{"format", path, ErrInvalid}
+	}
+	sb := Superblock{Magic: diskMagic, TotalBlocks: totalBlocks, BitmapStart: 1, MountCount: 1}
+	sb.BitmapBlocks = (totalBlocks + bitsPerBlock - 1) / bitsPerBlock
+	sb.InodeStart = sb.BitmapStart + sb.BitmapBlocks
+	sb.InodeBlocks = (totalInodes + InodesPerBlock - 1) / InodesPerBlock
+	sb.TotalInodes = sb.InodeBlocks * InodesPerBlock
+	sb.DataStart = sb.InodeStart + sb.InodeBlocks
+	if sb.DataStart >= totalBlocks {
+		return nil, fmt.Errorf("format %s: %d 块放不下超级块、位示图和 inode 表", path, totalBlocks)
+	}
+	sb.FreeBlocks = totalBlocks - sb.DataStart
+	sb.FreeInodes = sb.TotalInodes
+
+	image, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := image.Truncate(int64(totalBlocks) * BlockSize); err != nil {
+		image.Close()
+		return nil, err
+	}
+
+	fs := &DiskFS{Path: path, Super: sb, Umask: 0022, image: image, bitmap: make([]byte, sb.BitmapBlocks*BlockSize), user: RootUser}
+	for b := 0; b < sb.DataStart; b++ {
+		fs.bitmap[b/8] |= 1 << (b % 8) // 元数据块不参与分配
+	}
+	for i := 0; i < sb.BitmapBlocks; i++ {
+		if err := fs.writeBitmapBlock(i); err != nil {
+			image.Close()
+			return nil, err
+		}
+	}
+	if err := fs.makeRoot(); err != nil {
+		image.Close()
+		return nil, err
+	}
+	return fs, nil
+}
+
+// Mount 挂载已有的磁盘映像
+func Mount(path string) (*DiskFS, error) {
+	image, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	fs := &DiskFS{Path: path, Umask: 0022, image: image, user: RootUser}
+	buf := make([]byte, BlockSize)
+	if err := fs.readBlock(superblockNum, buf); err != nil {
+		image.Close()
+		return nil, err
+	}
+	for i, f := range fs.Super.fields() {
+		*f = int(binary.LittleEndian.Uint32(buf[i*4:]))
+	}
+	info, err := image.Stat()
+	if err != nil {
+		image.Close()
+		return nil, err
+	}
+	// 先按映像大小核对超级块中的布局，再据此分配位示图、读取各区域
+	if !fs.Super.valid(info.Size()) {
+		image.Close()
+		return nil, fmt.Errorf("mount %s: 不是有效的文件系统映像", path)
+	}
+
+	fs.bitmap = make([]byte, fs.Super.BitmapBlocks*BlockSize)
+	for i := 0; i < fs.Super.BitmapBlocks; i++ {
+		if err := fs.readBlock(fs.Super.BitmapStart+i, fs.bitmap[i*BlockSize:(i+1)*BlockSize]); err != nil {
+			image.Close()
+			return nil, err
+		}
+	}
+	fs.Super.MountCount++
+	if err := fs.writeSuper(); err != nil {
+		image.Close()
+		return nil, err
+	}
+	return fs, nil
+}
+
+// Unmount 把超级块写回并关闭映像文件
+func (fs *DiskFS) Unmount() error {
+	if fs.image == nil {
+		return ErrNotMounted
+	}
+	err := fs.writeSuper()
+	if cerr := fs.image.Close(); err == nil {
+		err = cerr
+	}
+	fs.image = nil
+	return err
+}
+
+// ---------- 块读写 ----------
+
+func (fs *DiskFS) readBlock(n int, buf []byte) error {
+	if fs.image == nil {
+		return ErrNotMounted
+	}
+	_, err := fs.image.ReadAt(buf[:BlockSize], int64(n)*BlockSize)
+	return err
+}
+
+func (fs *DiskFS) writeBlock(n int, buf []byte) error {
+	if fs.image == nil {
+		return ErrNotMounted
+	}
+	_, err := fs.image.WriteAt(buf[:BlockSize], int64(n)*BlockSize)
+	return err
+}
+
+func (fs *DiskFS) writeSuper() error {
+	buf := make([]byte, BlockSize)
+	for i, f := range fs.Super.fields() {
+		binary.LittleEndian.PutUint32(buf[i*4:], uint32(*f))
+	}
+	return fs.writeBlock(superblockNum, buf)
+}
+
+// ---------- 空闲块管理（位示图） ----------
+
+func (fs *DiskFS) blockUsed(n int) bool {
+	return fs.bitmap[n/8]&(1<<(n%8)) != 0
+}
+
+// writeBitmapBlock 把位示图的第 i 块写回磁盘
+func (fs *DiskFS) writeBitmapBlock(i int) error {
+	return fs.writeBlock(fs.Super.BitmapStart+i, fs.bitmap[i*BlockSize:(i+1)*BlockSize])
+}
+
+// allocBlock 在数据区中按首次适应分配一个块，并把块内容填为 fill
+func (fs *DiskFS) allocBlock(fill byte) (int, error) {
+	for n := fs.Super.DataStart; n < fs.Super.TotalBlocks; n++ {
+		if fs.blockUsed(n) {
+			continue
+		}
+		buf := make([]byte, BlockSize)
+		for i := range buf {
+			buf[i] = fill
+		}
+		if err := fs.writeBlock(n, buf); err != nil {
+			return noBlock, err
+		}
+		fs.bitmap[n/8] |= 1 << (n % 8)
+		fs.Super.FreeBlocks--
+		if err := fs.writeBitmapBlock(n / bitsPerBlock); err != nil {
+			return noBlock, err
+		}
+		return n, fs.writeSuper()
+	}
+	return noBlock, ErrNoSpace
+}
+
+// freeBlock 在位示图中释放一个块
+func (fs *DiskFS) freeBlock(n int) error {
+	if n < fs.Super.DataStart || n >= fs.Super.TotalBlocks || !fs.blockUsed(n) {
+		return fmt.Errorf("释放块 %d: 块不在数据区或未分配", n)
+	}
+	fs.bitmap[n/8] &^= 1 << (n % 8)
+	fs.Super.FreeBlocks++
+	if err := fs.writeBitmapBlock(n / bitsPerBlock); err != nil {
+		return err
+	}
+	return fs.writeSuper()
+}
+
+// ---------- inode 表 ----------
+
+// inodeLocation 计算 inode 所在的块号和块内偏移
+func (fs *DiskFS) inodeLocation(num int) (block, offset int) {
+	return fs.Super.InodeStart + num/InodesPerBlock, num % InodesPerBlock * inodeSize
+}
+
+// readInode 从 inode 表读出 inode；硬链接数为 0 表示该 inode 空闲
+func (fs *DiskFS) readInode(num int) (*Inode, error) {
+	if num < 0 || num >= fs.Super.TotalInodes {
+		return nil, fmt.Errorf("inode %d 超出范围", num)
+	}
+	block, offset := fs.inodeLocation(num)
+	buf := make([]byte, BlockSize)
+	if err := fs.readBlock(block, buf); err != nil {
+		return nil, err
+	}
+	return decodeInode(num, buf[offset:offset+inodeSize]), nil
+}
+
+// writeInode 把 inode 写回 inode 表
+func (fs *DiskFS) writeInode(inode *Inode) error {
+	block, offset := fs.inodeLocation(inode.InodeNumber)
+	buf := make([]byte, BlockSize)
+	if err := fs.readBlock(block, buf); err != nil {
+		return err
+	}
+	encodeInode(inode, buf[offset:offset+inodeSize])
+	return fs.writeBlock(block, buf)
+}
+
+// allocInode 分配一个空闲 inode，属主和属组取调用者的身份
+func (fs *DiskFS) allocInode(fileType FileType, mode uint16) (*Inode, error) {
+	for num := 0; num < fs.Super.TotalInodes; num++ {
+		old, err := fs.readInode(num)
+		if err != nil {
+			return nil, err
+		}
+		if old.LinkCount > 0 {
+			continue
+		}
+		inode := NewInode(num, fileType)
+		inode.setMode(mode)
+		inode.UID, inode.GID = fs.user.UID, fs.user.GID
+		if err := fs.writeInode(inode); err != nil {
+			return nil, err
+		}
+		fs.Super.FreeInodes--
+		return inode, fs.writeSuper()
+	}
+	return nil, ErrNoInodes
+}
+
+// releaseInode 释放 inode 占用的全部数据块和间接块，并把 inode 标记为空闲
+func (fs *DiskFS) releaseInode(inode *Inode) error {
+	if err := fs.truncate(inode, 0); err != nil {
+		return err
+	}
+	inode.LinkCount = 0
+	if err := fs.writeInode(inode); err != nil {
+		return err
+	}
+	fs.Super.FreeInodes++
+	return fs.writeSuper()
+}
+
+// 磁盘 inode 格式（小端）:
+//
+//	0   类型(1) 1 权限位(2) 4 硬链接数(4) 8 文件大小(8)
+//	16  直接块指针 12×4   64 一次间接  68 二次间接  72 三次间接
+//	76  属主用户号(4)     80 属组号(4)
+func encodeInode(inode *Inode, buf []byte) {
+	for i := range buf {
+		buf[i] = 0
+	}
+	le := binary.LittleEndian
+	buf[0] = byte(inode.Type)
+	le.PutUint16(buf[1:], inode.mode())
+	le.PutUint32(buf[4:], uint32(inode.LinkCount))
+	le.PutUint64(buf[8:], uint64(inode.FileSize))
+	for i, ptr := range inode.DirectPtr {
+		le.PutUint32(buf[16+i*4:], uint32(int32(ptr)))
+	}
+	le.PutUint32(buf[64:], uint32(int32(inode.SingleIndirect)))
+	le.PutUint32(buf[68:], uint32(int32(inode.DoubleIndirect)))
+	le.PutUint32(buf[72:], uint32(int32(inode.TripleIndirect)))
+	le.PutUint32(buf[76:], uint32(inode.UID))
+	le.PutUint32(buf[80:], uint32(inode.GID))
+}
+
+func decodeInode(num int, buf []byte) *Inode {
+	le := binary.LittleEndian
+	inode := &Inode{
+		InodeNumber:    num,
+		Type:           FileType(buf[0]),
+		UID:            int(le.Uint32(buf[76:])),
+		GID:            int(le.Uint32(buf[80:])),
+		LinkCount:      int(le.Uint32(buf[4:])),
+		FileSize:       int64(le.Uint64(buf[8:])),
+		SingleIndirect: int(int32(le.Uint32(buf[64:]))),
+		DoubleIndirect: int(int32(le.Uint32(buf[68:]))),
+		TripleIndirect: int(int32(le.Uint32(buf[72:]))),
+	}
+	for i := range inode.DirectPtr {
+		inode.DirectPtr[i] = int(int32(le.Uint32(buf[16+i*4:])))
+	}
+	inode.setMode(le.Uint16(buf[1:]))
+	return inode
+}
+
+// modeSticky 权限位中的粘着位
+const modeSticky = 01000
+
+// mode 粘着位和属主/组/其他用户的 rwx 组成的权限位
+func (inode *Inode) mode() uint16 {
+	m := inode.Owner.bits()<<6 | inode.Group.bits()<<3 | inode.Other.bits()
+	if inode.Sticky {
+		m |= modeSticky
+	}
+	return m
+}
+
+func (inode *Inode) setMode(mode uint16) {
+	inode.Owner = permissionFromBits(mode >> 6)
+	inode.Group = permissionFromBits(mode >> 3)
+	inode.Other = permissionFromBits(mode)
+	inode.Sticky = mode&modeSticky != 0
+}
+
+// bits 把权限编码为 rwx 三位
+func (p Permission) bits() uint16 {
+	var b uint16
+	if p.Read {
+		b |= 4
+	}
+	if p.Write {
+		b |= 2
+	}
+	if p.Execute {
+		b |= 1
+	}
+	return b
+}
+
+func permissionFromBits(b uint16) Permission {
+	return Permission{Read: b&4 != 0, Write: b&2 != 0, Execute: b&1 != 0}
+}
+
+// ---------- 混合索引：逻辑块号 -> 物理块号 ----------
+
+// indirectSpan 第 level 级间接块能覆盖的数据块数（IndirectPerBlock^level）
+func indirectSpan(level int) int {
+	span := 1
+	for i := 0; i < level; i++ {
+		span *= IndirectPerBlock
+	}
+	return span
+}
+
+// indirectRoots 一次/二次/三次间接块指针，以及各自覆盖的第一个逻辑块号
+func (inode *Inode) indirectRoots() (ptrs [3]*int, bases [3]int) {
+	ptrs = [3]*int{&inode.SingleIndirect, &inode.DoubleIndirect, &inode.TripleIndirect}
+	base := DirectBlocks
+	for level := 1; level <= 3; level++ {
+		bases[level-1] = base
+		base += indirectSpan(level)
+	}
+	return ptrs, bases
+}
+
+func (fs *DiskFS) readPointers(block int) ([]int, error) {
+	buf := make([]byte, BlockSize)
+	if err := fs.readBlock(block, buf); err != nil {
+		return nil, err
+	}
+	ptrs := make([]int, IndirectPerBlock)
+	for i := range ptrs {
+		ptrs[i] = int(int32(binary.LittleEndian.Uint32(buf[i*ptrSize:])))
+	}
+	return ptrs, nil
+}
+
+func (fs *DiskFS) writePointers(block int, ptrs []int) error {
+	buf := make([]byte, BlockSize)
+	for i, p := range ptrs {
+		binary.LittleEndian.PutUint32(buf[i*ptrSize:], uint32(int32(p)))
+	}
+	return fs.writeBlock(block, buf)
+}
+
+// bmap 把文件的第 index 个逻辑块映射为物理块号；块未分配时返回 -1，
+// alloc 为 true 时按需分配数据块和沿途的间接块（调用者负责写回 inode）
+func (fs *DiskFS) bmap(inode *Inode, index int, alloc bool) (int, error) {
+	if index < DirectBlocks {
+		return fs.mapData(&inode.DirectPtr[index], alloc)
+	}
+	roots, bases := inode.indirectRoots()
+	for level := 3; level >= 1; level-- {
+		if index >= bases[level-1] {
+			if level == 3 && index-bases[2] >= indirectSpan(3) {
+				return noBlock, ErrFileTooLarge
+			}
+			return fs.mapIndirect(roots[level-1], level, index-bases[level-1], alloc)
+		}
+	}
+	return noBlock, ErrFileTooLarge
+}
+
+// mapData 返回数据块指针指向的块，必要时分配一个清零的数据块
+func (fs *DiskFS) mapData(ptr *int, alloc bool) (int, error) {
+	if *ptr == noBlock && alloc {
+		b, err := fs.allocBlock(0)
+		if err != nil {
+			return noBlock, err
+		}
+		*ptr = b
+	}
+	return *ptr, nil
+}
+
+// mapIndirect 在 level 级间接块 *ptr 中查找相对逻辑块号 index
+func (fs *DiskFS) mapIndirect(ptr *int, level, index int, alloc bool) (int, error) {
+	if *ptr == noBlock {
+		if !alloc {
+			return noBlock, nil
+		}
+		b, err := fs.allocBlock(0xFF) // 新间接块的所有指针为 -1
+		if err != nil {
+			return noBlock, err
+		}
+		*ptr = b
+	}
+	ptrs, err := fs.readPointers(*ptr)
+	if err != nil {
+		return noBlock, err
+	}
+	span := indirectSpan(level - 1)
+	slot := index / span
+	child := ptrs[slot]
+	var b int
+	if level == 1 {
+		b, err = fs.mapData(&child, alloc)
+	} else {
+		b, err = fs.mapIndirect(&child, level-1, index%span, alloc)
+	}
+	if child != ptrs[slot] {
+		ptrs[slot] = child
+		if werr := fs.writePointers(*ptr, ptrs); err == nil {
+			err = werr
+		}
+	}
+	return b, err
+}
+
+// truncateIndirect 释放 level 级间接块 *ptr 下逻辑块号 >= keep 的数据块，
+// base 为该间接块覆盖的第一个逻辑块号；整块都不再需要时连同间接块一起释放
+func (fs *DiskFS) truncateIndirect(ptr *int, level, base, keep int) error {
+	if *ptr == noBlock || base+indirectSpan(level) <= keep {
+		return nil
+	}
+	ptrs, err := fs.readPointers(*ptr)
+	if err != nil {
+		return err
+	}
+	childSpan := indirectSpan(level - 1)
+	for i := range ptrs {
+		if ptrs[i] == noBlock {
+			continue
+		}
+		childBase := base + i*childSpan
+		if level > 1 {
+			err = fs.truncateIndirect(&ptrs[i], level-1, childBase, keep)
+		} else if childBase >= keep {
+			err = fs.freeBlock(ptrs[i])
+			ptrs[i] = noBlock
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if base >= keep {
+		err = fs.freeBlock(*ptr)
+		*ptr = noBlock
+		return err
+	}
+	return fs.writePointers(*ptr, ptrs)
+}
+
+// blockList 按逻辑顺序列出文件的数据块，以及间接块（索引块）
+func (fs *DiskFS) blockList(inode *Inode) (data, index []int, err error) {
+	for _, b := range inode.DirectPtr {
+		if b != noBlock {
+			data = append(data, b)
+		}
+	}
+	var walk func(block, level int) error
+	walk = func(block, level int) error {
+		index = append(index, block)
+		ptrs, err := fs.readPointers(block)
+		if err != nil {
+			return err
+		}
+		for _, p := range ptrs {
+			if p == noBlock {
+				continue
+			}
+			if level == 1 {
+				data = append(data, p)
+			} else if err := walk(p, level-1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	roots, _ := inode.indirectRoots()
+	for level, root := range roots {
+		if *root != noBlock {
+			if err := walk(*root, level+1); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	return data, index, nil
+}
+
+// ---------- 文件内容读写 ----------
+
+// readAt 从 offset 开始读出最多 n 字节，未分配的块（文件空洞）读出为 0
+func (fs *DiskFS) readAt(inode *Inode, offset int64, n int) ([]byte, error) {
+	if offset < 0 || n < 0 {
+		return nil, ErrInvalid
+	}
+	if offset >= inode.FileSize {
+		return nil, nil
+	}
+	if end := offset + int64(n); end > inode.FileSize {
+		n = int(inode.FileSize - offset)
+	}
+	data := make([]byte, n)
+	buf := make([]byte, BlockSize)
+	for done := 0; done < n; {
+		pos := offset + int64(done)
+		b, err := fs.bmap(inode, int(pos/BlockSize), false)
+		if err != nil {
+			return nil, err
+		}
+		start := int(pos % BlockSize)
+		if b == noBlock {
+			for i := range buf {
+				buf[i] = 0
+			}
+		} else if err := fs.readBlock(b, buf); err != nil {
+			return nil, err
+		}
+		done += copy(data[done:], buf[start:])
+	}
+	return data, nil
+}
+
+// writeAt 从 offset 开始写入 data，按需分配数据块和间接块并扩展文件大小
+func (fs *DiskFS) writeAt(inode *Inode, offset int64, data []byte) error {
+	if offset < 0 {
+		return ErrInvalid
+	}
+	if offset+int64(len(data)) > inode.MaxFileSize() {
+		return ErrFileTooLarge
+	}
+	buf := make([]byte, BlockSize)
+	var err error
+	done := 0
+	for done < len(data) {
+		pos := offset + int64(done)
+		var b int
+		if b, err = fs.bmap(inode, int(pos/BlockSize), true); err != nil {
+			break
+		}
+		start := int(pos % BlockSize)
+		if err = fs.readBlock(b, buf); err != nil {
+			break
+		}
+		n := copy(buf[start:], data[done:])
+		if err = fs.writeBlock(b, buf); err != nil {
+			break
+		}
+		done += n
+	}
+	// 空间不足时保留已写入的部分
+	if end := offset + int64(done); end > inode.FileSize {
+		inode.FileSize = end
+	}
+	if werr := fs.writeInode(inode); err == nil {
+		err = werr
+	}
+	return err
+}
+
+// truncate 把文件截断或扩展到 size 字节，截断时释放多余的数据块和间接块
+func (fs *DiskFS) truncate(inode *Inode, size int64) error {
+	if size < 0 {
+		return ErrInvalid
+	}
+	if size > inode.MaxFileSize() {
+		return ErrFileTooLarge
+	}
+	if size < inode.FileSize {
+		keep := BlocksNeeded(size)
+		for i := keep; i < DirectBlocks; i++ {
+			if inode.DirectPtr[i] != noBlock {
+				if err := fs.freeBlock(inode.DirectPtr[i]); err != nil {
+					return err
+				}
+				inode.DirectPtr[i] = noBlock
+			}
+		}
+		roots, bases := inode.indirectRoots()
+		for level, root := range roots {
+			if err := fs.truncateIndirect(root, level+1, bases[level], keep); err != nil {
+				return err
+			}
+		}
+		// 把最后一块中新文件末尾之后的内容清零，以后扩展文件时读出为 0
+		if tail := int(size % BlockSize); tail != 0 {
+			b, err := fs.bmap(inode, keep-1, false)
+			if err != nil {
+				return err
+			}
+			if b != noBlock {
+				buf := make([]byte, BlockSize)
+				if err := fs.readBlock(b, buf); err != nil {
+					return err
+				}
+				for i := tail; i < BlockSize; i++ {
+					buf[i] = 0
+				}
+				if err := fs.writeBlock(b, buf); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	inode.FileSize = size
+	return fs.writeInode(inode)
+}
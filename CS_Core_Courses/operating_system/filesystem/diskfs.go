@@ -0,0 +1,872 @@
+package filesystem
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ============================================================
+// 磁盘文件系统：目录、路径解析与文件操作
+// 408考点：目录项只记录 文件名 -> inode 号，文件属性和块指针都在 inode 中；
+//         "." 和 ".." 是目录中真实存在的目录项；打开/读写/删除文件的过程
+// ============================================================
+
+const (
+	RootInode    = 0                // 根目录的 inode 号
+	dirEntrySize = 64               // 目录项大小（字节）
+	MaxNameLen   = dirEntrySize - 6 // 文件名的最大字节数
+)
+
+// 目录项格式: inode 号(4，-1 表示空槽) 类型(1) 名字长度(1) 名字(MaxNameLen)
+func encodeDirEntry(buf []byte, name string, num int, fileType FileType) {
+	for i := range buf[:dirEntrySize] {
+		buf[i] = 0
+	}
+	binary.LittleEndian.PutUint32(buf, uint32(int32(num)))
+	buf[4] = byte(fileType)
+	buf[5] = byte(len(name))
+	copy(buf[6:], name)
+}
+
+func decodeDirEntry(buf []byte) (name string, num int, fileType FileType) {
+	num = int(int32(binary.LittleEndian.Uint32(buf)))
+	return string(buf[6 : 6+int(buf[5])]), num, FileType(buf[4])
+}
+
+// makeRoot 创建根目录，根目录的 ".." 指向自己
+func (fs *DiskFS) makeRoot() error {
+	root, err := fs.allocInode(Directory, 0755)
+	if err != nil {
+		return err
+	}
+	if root.InodeNumber != RootInode {
+		return fmt.Errorf("根目录 inode 应为 %d，实际为 %d", RootInode, root.InodeNumber)
+	}
+	return fs.initDir(root, root.InodeNumber)
+}
+
+// initDir 写入新目录的 "." 和 ".." 目录项；目录的硬链接数 = 2 + 子目录数
+func (fs *DiskFS) initDir(dir *Inode, parent int) error {
+	buf := make([]byte, 2*dirEntrySize)
+	encodeDirEntry(buf, ".", dir.InodeNumber, Directory)
+	encodeDirEntry(buf[dirEntrySize:], "..", parent, Directory)
+	dir.LinkCount = 2
+	return fs.writeAt(dir, 0, buf)
+}
+
+// readDir 读出目录中的全部有效目录项（含 "." 和 ".."）
+func (fs *DiskFS) readDir(dir *Inode) ([]DirEntry, error) {
+	if dir.Type != Directory {
+		return nil, ErrNotDir
+	}
+	data, err := fs.readAt(dir, 0, int(dir.FileSize))
+	if err != nil {
+		return nil, err
+	}
+	var entries []DirEntry
+	for off := 0; off+dirEntrySize <= len(data); off += dirEntrySize {
+		name, num, fileType := decodeDirEntry(data[off:])
+		if num != noBlock {
+			entries = append(entries, DirEntry{Name: name, InodeNum: num, IsDir: fileType == Directory})
+		}
+	}
+	return entries, nil
+}
+
+// dirLookup 在目录中按名字查找，返回 inode 号和目录项槽位，找不到返回 -1
+func (fs *DiskFS) dirLookup(dir *Inode, name string) (num, slot int, err error) {
+	if dir.Type != Directory {
+		return -1, -1, ErrNotDir
+	}
+	data, err := fs.readAt(dir, 0, int(dir.FileSize))
+	if err != nil {
+		return -1, -1, err
+	}
+	for off := 0; off+dirEntrySize <= len(data); off += dirEntrySize {
+		if n, num, _ := decodeDirEntry(data[off:]); num != noBlock && n == name {
+			return num, off / dirEntrySize, nil
+		}
+	}
+	return -1, -1, nil
+}
+
+// dirAdd 在目录中加入一项，优先复用已删除目录项留下的空槽
+func (fs *DiskFS) dirAdd(dir *Inode, name string, num int, fileType FileType) error {
+	data, err := fs.readAt(dir, 0, int(dir.FileSize))
+	if err != nil {
+		return err
+	}
+	off := len(data)
+	for o := 0; o+dirEntrySize <= len(data); o += dirEntrySize {
+		if _, n, _ := decodeDirEntry(data[o:]); n == noBlock {
+			off = o
+			break
+		}
+	}
+	buf := make([]byte, dirEntrySize)
+	encodeDirEntry(buf, name, num, fileType)
+	return fs.writeAt(dir, int64(off), buf)
+}
+
+// dirRemove 清空目录项所在的槽位
+func (fs *DiskFS) dirRemove(dir *Inode, slot int) error {
+	buf := make([]byte, dirEntrySize)
+	encodeDirEntry(buf, "", noBlock, 0)
+	return fs.writeAt(dir, int64(slot*dirEntrySize), buf)
+}
+
+// checkName 检查文件名是否可以放入目录项
+func checkName(name string) error {
+	switch {
+	case name == "" || name == "." || name == "..":
+		return ErrInvalid
+	case len(name) > MaxNameLen:
+		return ErrNameTooLong
+	}
+	return nil
+}
+
+// ---------- 路径解析 ----------
+
+// startDir 返回路径解析的起点：绝对路径从根目录开始，相对路径从当前工作目录开始
+func (fs *DiskFS) startDir(path string) (*Inode, error) {
+	if strings.HasPrefix(path, "/") {
+		return fs.readInode(RootInode)
+	}
+	return fs.readInode(fs.cwd)
+}
+
+// walk 从目录 dir 开始逐级查找目录项，经过的每一级目录都需要 x（搜索）权限
+// ".." 不做特殊处理，直接查找目录中的 ".." 目录项；
+// 途中遇到符号链接时把它展开为目标路径继续查找，followLast 为 false 时不展开最后一级
+func (fs *DiskFS) walk(dir *Inode, parts []string, followLast bool) (*Inode, error) {
+	inode := dir
+	links := 0
+	for i := 0; i < len(parts); i++ {
+		if inode.Type == Directory {
+			if err := fs.access(inode, AccessExec); err != nil {
+				return nil, err
+			}
+		}
+		num, _, err := fs.dirLookup(inode, parts[i])
+		if err != nil {
+			return nil, err
+		}
+		if num == -1 {
+			return nil, ErrNotExist
+		}
+		next, err := fs.readInode(num)
+		if err != nil {
+			return nil, err
+		}
+		if next.Type != SymLink || (i == len(parts)-1 && !followLast) {
+			inode = next
+			continue
+		}
+
+		// 展开次数超过上限时认为出现了循环（ELOOP）
+		if links++; links > MaxSymlinkDepth {
+			return nil, ErrLoop
+		}
+		target, err := fs.readlink(next)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(target, "/") {
+			if inode, err = fs.readInode(RootInode); err != nil {
+				return nil, err
+			}
+		} // 相对路径从符号链接所在的目录开始解析
+		parts = append(parsePath(target), parts[i+1:]...)
+		i = -1
+	}
+	return inode, nil
+}
+
+// namei 把路径解析为 inode，展开路径中的所有符号链接
+func (fs *DiskFS) namei(path string) (*Inode, error) {
+	start, err := fs.startDir(path)
+	if err != nil {
+		return nil, err
+	}
+	return fs.walk(start, parsePath(path), true)
+}
+
+// lnamei 与 namei 相同，但最后一级是符号链接时返回符号链接本身
+func (fs *DiskFS) lnamei(path string) (*Inode, error) {
+	start, err := fs.startDir(path)
+	if err != nil {
+		return nil, err
+	}
+	return fs.walk(start, parsePath(path), false)
+}
+
+// nameiParent 解析路径的父目录，返回父目录 inode 和最后一级文件名
+func (fs *DiskFS) nameiParent(path string) (*Inode, string, error) {
+	parts := parsePath(path)
+	if len(parts) == 0 {
+		return nil, "", ErrInvalid // 根目录没有父目录项
+	}
+	start, err := fs.startDir(path)
+	if err != nil {
+		return nil, "", err
+	}
+	parent, err := fs.walk(start, parts[:len(parts)-1], true)
+	if err != nil {
+		return nil, "", err
+	}
+	if parent.Type != Directory {
+		return nil, "", ErrNotDir
+	}
+	name := parts[len(parts)-1]
+	return parent, name, checkName(name)
+}
+
+// parentOf 读出目录的 ".." 目录项指向的父目录（内部使用，不检查权限）
+func (fs *DiskFS) parentOf(dir *Inode) (*Inode, error) {
+	num, _, err := fs.dirLookup(dir, "..")
+	if err != nil {
+		return nil, err
+	}
+	return fs.readInode(num)
+}
+
+// Chdir 改变当前工作目录，之后的相对路径从这里开始解析
+func (fs *DiskFS) Chdir(path string) error {
+	dir, err := fs.namei(path)
+	if err == nil && dir.Type != Directory {
+		err = ErrNotDir
+	}
+	if err == nil {
+		err = fs.access(dir, AccessExec)
+	}
+	if err != nil {
+		return &PathError{"chdir", path, err}
+	}
+	fs.cwd = dir.InodeNumber
+	return nil
+}
+
+// Getwd 返回当前工作目录的绝对路径
+// 目录 inode 中不记录自己的名字：沿 ".." 逐级向上，在父目录中查找指向当前目录的目录项
+func (fs *DiskFS) Getwd() (string, error) {
+	var names []string
+	for num := fs.cwd; num != RootInode; {
+		dir, err := fs.readInode(num)
+		if err != nil {
+			return "", err
+		}
+		parent, err := fs.parentOf(dir)
+		if err != nil {
+			return "", err
+		}
+		entries, err := fs.readDir(parent)
+		if err != nil {
+			return "", err
+		}
+		name := ""
+		for _, e := range entries[2:] {
+			if e.InodeNum == num {
+				name = e.Name
+				break
+			}
+		}
+		if name == "" {
+			return "", &PathError{"getwd", "..", ErrNotExist}
+		}
+		names = append([]string{name}, names...)
+		num = parent.InodeNumber
+	}
+	return "/" + strings.Join(names, "/"), nil
+}
+
+// create 在父目录中创建新的 inode 和目录项
+// 新文件的权限为 0666 &^ umask，新目录为 0777 &^ umask，符号链接总是 0777
+func (fs *DiskFS) create(path string, fileType FileType) (*Inode, error) {
+	parent, name, err := fs.nameiParent(path)
+	if err != nil {
+		return nil, err
+	}
+	if num, _, err := fs.dirLookup(parent, name); err != nil {
+		return nil, err
+	} else if num != -1 {
+		return nil, ErrExist
+	}
+	if err := fs.mayCreate(parent); err != nil {
+		return nil, err
+	}
+
+	mode := uint16(0777)
+	switch fileType {
+	case RegularFile:
+		mode = 0666 &^ uint16(fs.Umask)
+	case Directory:
+		mode = 0777 &^ uint16(fs.Umask)
+	}
+	inode, err := fs.allocInode(fileType, mode)
+	if err != nil {
+		return nil, err
+	}
+	if fileType == Directory {
+		err = fs.initDir(inode, parent.InodeNumber)
+	}
+	if err == nil {
+		err = fs.dirAdd(parent, name, inode.InodeNumber, fileType)
+	}
+	if err != nil {
+		fs.releaseInode(inode) // 撤销已分配的 inode 和数据块
+		return nil, err
+	}
+	if fileType == Directory {
+		parent.LinkCount++ // 子目录的 ".." 指向父目录
+		err = fs.writeInode(parent)
+	}
+	return inode, err
+}
+
+// ---------- 文件系统操作 ----------
+
+// Create 创建空的普通文件
+func (fs *DiskFS) Create(path string) (*Inode, error) {
+	inode, err := fs.create(path, RegularFile)
+	if err != nil {
+		return nil, &PathError{"create", path, err}
+	}
+	return inode, nil
+}
+
+// Mkdir 创建目录
+func (fs *DiskFS) Mkdir(path string) error {
+	if _, err := fs.create(path, Directory); err != nil {
+		return &PathError{"mkdir", path, err}
+	}
+	return nil
+}
+
+// openFile 解析路径，确认是普通文件并检查 want 权限
+func (fs *DiskFS) openFile(path string, want int) (*Inode, error) {
+	inode, err := fs.namei(path)
+	if err != nil {
+		return nil, err
+	}
+	if inode.Type == Directory {
+		return nil, ErrIsDir
+	}
+	if err := fs.access(inode, want); err != nil {
+		return nil, err
+	}
+	return inode, nil
+}
+
+// WriteFile 用 data 覆盖文件内容，文件不存在时先创建
+func (fs *DiskFS) WriteFile(path string, data []byte) error {
+	inode, err := fs.openFile(path, AccessWrite)
+	if errors.Is(err, ErrNotExist) {
+		inode, err = fs.create(path, RegularFile)
+	}
+	if err == nil {
+		err = fs.truncate(inode, 0)
+	}
+	if err == nil {
+		err = fs.writeAt(inode, 0, data)
+	}
+	if err != nil {
+		return &PathError{"write", path, err}
+	}
+	return nil
+}
+
+// WriteAt 从 offset 处写入 data，写到文件末尾之后时文件变长
+func (fs *DiskFS) WriteAt(path string, offset int64, data []byte) error {
+	inode, err := fs.openFile(path, AccessWrite)
+	if err == nil {
+		err = fs.writeAt(inode, offset, data)
+	}
+	if err != nil {
+		return &PathError{"write", path, err}
+	}
+	return nil
+}
+
+// ReadFile 读出文件的全部内容
+func (fs *DiskFS) ReadFile(path string) ([]byte, error) {
+	inode, err := fs.openFile(path, AccessRead)
+	if err != nil {
+		return nil, &PathError{"read", path, err}
+	}
+	data, err := fs.readAt(inode, 0, int(inode.FileSize))
+	if err != nil {
+		return nil, &PathError{"read", path, err}
+	}
+	return data, nil
+}
+
+// ReadAt 从 offset 处读出最多 n 字节
+func (fs *DiskFS) ReadAt(path string, offset int64, n int) ([]byte, error) {
+	inode, err := fs.openFile(path, AccessRead)
+	if err != nil {
+		return nil, &PathError{"read", path, err}
+	}
+	data, err := fs.readAt(inode, offset, n)
+	if err != nil {
+		return nil, &PathError{"read", path, err}
+	}
+	return data, nil
+}
+
+// Truncate 把文件截断或扩展到 size 字节
+func (fs *DiskFS) Truncate(path string, size int64) error {
+	inode, err := fs.openFile(path, AccessWrite)
+	if err == nil {
+		err = fs.truncate(inode, size)
+	}
+	if err != nil {
+		return &PathError{"truncate", path, err}
+	}
+	return nil
+}
+
+// Unlink 删除目录项，硬链接数减为 0 时释放 inode 和数据块
+func (fs *DiskFS) Unlink(path string) error {
+	if err := fs.unlink(path); err != nil {
+		return &PathError{"unlink", path, err}
+	}
+	return nil
+}
+
+func (fs *DiskFS) unlink(path string) error {
+	parent, name, err := fs.nameiParent(path)
+	if err != nil {
+		return err
+	}
+	num, slot, err := fs.dirLookup(parent, name)
+	if err != nil {
+		return err
+	}
+	if num == -1 {
+		return ErrNotExist
+	}
+	inode, err := fs.readInode(num)
+	if err != nil {
+		return err
+	}
+	if inode.Type == Directory {
+		return ErrIsDir
+	}
+	if err := fs.mayDelete(parent, inode); err != nil {
+		return err
+	}
+	if err := fs.dirRemove(parent, slot); err != nil {
+		return err
+	}
+	inode.LinkCount--
+	if inode.LinkCount > 0 {
+		return fs.writeInode(inode)
+	}
+	return fs.releaseInode(inode)
+}
+
+// Rmdir 删除空目录
+func (fs *DiskFS) Rmdir(path string) error {
+	if err := fs.rmdir(path); err != nil {
+		return &PathError{"rmdir", path, err}
+	}
+	return nil
+}
+
+func (fs *DiskFS) rmdir(path string) error {
+	parent, name, err := fs.nameiParent(path)
+	if err != nil {
+		return err
+	}
+	num, slot, err := fs.dirLookup(parent, name)
+	if err != nil {
+		return err
+	}
+	if num == -1 {
+		return ErrNotExist
+	}
+	dir, err := fs.readInode(num)
+	if err != nil {
+		return err
+	}
+	if err := fs.mayDelete(parent, dir); err != nil {
+		return err
+	}
+	entries, err := fs.readDir(dir)
+	if err != nil {
+		return err
+	}
+	if len(entries) > 2 {
+		return ErrNotEmpty
+	}
+	if dir.InodeNumber == fs.cwd {
+		return ErrBusy
+	}
+	if err := fs.dirRemove(parent, slot); err != nil {
+		return err
+	}
+	parent.LinkCount--
+	if err := fs.writeInode(parent); err != nil {
+		return err
+	}
+	return fs.releaseInode(dir)
+}
+
+// Rename 把 oldPath 改名或移动到 newPath
+// 目标是已存在的普通文件时将其替换；移动目录时同时修改它的 ".." 目录项
+func (fs *DiskFS) Rename(oldPath, newPath string) error {
+	if err := fs.rename(oldPath, newPath); err != nil {
+		return &PathError{"rename", oldPath, err}
+	}
+	return nil
+}
+
+func (fs *DiskFS) rename(oldPath, newPath string) error {
+	oldParent, oldName, err := fs.nameiParent(oldPath)
+	if err != nil {
+		return err
+	}
+	num, _, err := fs.dirLookup(oldParent, oldName)
+	if err != nil {
+		return err
+	}
+	if num == -1 {
+		return ErrNotExist
+	}
+	inode, err := fs.readInode(num)
+	if err != nil {
+		return err
+	}
+	if err := fs.mayDelete(oldParent, inode); err != nil {
+		return err
+	}
+	newParent, newName, err := fs.nameiParent(newPath)
+	if err != nil {
+		return err
+	}
+	if err := fs.mayCreate(newParent); err != nil {
+		return err
+	}
+
+	target, _, err := fs.dirLookup(newParent, newName)
+	if err != nil {
+		return err
+	}
+	if target == num {
+		return nil // 同一个文件
+	}
+	if target != -1 {
+		old, err := fs.readInode(target)
+		if err != nil {
+			return err
+		}
+		if old.Type == Directory || inode.Type == Directory {
+			return ErrExist
+		}
+		if err := fs.unlink(newPath); err != nil {
+			return err
+		}
+		if newParent, err = fs.readInode(newParent.InodeNumber); err != nil {
+			return err
+		}
+	}
+
+	if inode.Type == Directory && newParent.InodeNumber != oldParent.InodeNumber {
+		// 移到别的目录时要改写它的 ".." 目录项，需要对它有 w 权限
+		if err := fs.access(inode, AccessWrite); err != nil {
+			return err
+		}
+		// 不能把目录移动到它自己的子目录中：沿新父目录的 ".." 向上检查
+		for d := newParent; ; {
+			if d.InodeNumber == num {
+				return ErrInvalid
+			}
+			if d.InodeNumber == RootInode {
+				break
+			}
+			if d, err = fs.parentOf(d); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := fs.dirAdd(newParent, newName, num, inode.Type); err != nil {
+		return err
+	}
+	// 新旧父目录可能是同一个 inode，重新读出以免覆盖刚写入的目录大小
+	if oldParent, err = fs.readInode(oldParent.InodeNumber); err != nil {
+		return err
+	}
+	_, slot, err := fs.dirLookup(oldParent, oldName)
+	if err != nil {
+		return err
+	}
+	if err := fs.dirRemove(oldParent, slot); err != nil {
+		return err
+	}
+	if inode.Type != Directory || oldParent.InodeNumber == newParent.InodeNumber {
+		return nil
+	}
+
+	_, slot, err = fs.dirLookup(inode, "..")
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, dirEntrySize)
+	encodeDirEntry(buf, "..", newParent.InodeNumber, Directory)
+	if err := fs.writeAt(inode, int64(slot*dirEntrySize), buf); err != nil {
+		return err
+	}
+	oldParent.LinkCount--
+	if err := fs.writeInode(oldParent); err != nil {
+		return err
+	}
+	if newParent, err = fs.readInode(newParent.InodeNumber); err != nil {
+		return err
+	}
+	newParent.LinkCount++
+	return fs.writeInode(newParent)
+}
+
+// Chmod 修改文件的访问权限（mode 的低 9 位为属主/组/其他用户的 rwx，01000 为粘着位）
+// 只有属主和 root 可以修改
+func (fs *DiskFS) Chmod(path string, mode int) error {
+	inode, err := fs.namei(path)
+	if err == nil {
+		err = fs.mayChangeAttr(inode)
+	}
+	if err != nil {
+		return &PathError{"chmod", path, err}
+	}
+	inode.setMode(uint16(mode) & 01777)
+	return fs.writeInode(inode)
+}
+
+// Stat 返回路径对应的 inode（展开符号链接）
+func (fs *DiskFS) Stat(path string) (*Inode, error) {
+	inode, err := fs.namei(path)
+	if err != nil {
+		return nil, &PathError{"stat", path, err}
+	}
+	return inode, nil
+}
+
+// Lstat 与 Stat 相同，但路径本身是符号链接时返回符号链接的 inode
+func (fs *DiskFS) Lstat(path string) (*Inode, error) {
+	inode, err := fs.lnamei(path)
+	if err != nil {
+		return nil, &PathError{"lstat", path, err}
+	}
+	return inode, nil
+}
+
+// ReadDir 列出目录中的目录项（含 "." 和 ".."），需要对目录有 r 权限
+func (fs *DiskFS) ReadDir(path string) ([]DirEntry, error) {
+	dir, err := fs.namei(path)
+	if err == nil && dir.Type == Directory {
+		err = fs.access(dir, AccessRead)
+	}
+	if err == nil {
+		var entries []DirEntry
+		if entries, err = fs.readDir(dir); err == nil {
+			return entries, nil
+		}
+	}
+	return nil, &PathError{"readdir", path, err}
+}
+
+// Blocks 返回文件的数据块号（按逻辑顺序）和间接块号
+func (fs *DiskFS) Blocks(path string) (data, index []int, err error) {
+	inode, err := fs.namei(path)
+	if err == nil {
+		if data, index, err = fs.blockList(inode); err == nil {
+			return data, index, nil
+		}
+	}
+	return nil, nil, &PathError{"blocks", path, err}
+}
+
+// PrintTree 打印目录树，每项附带 inode 号和大小
+func (fs *DiskFS) PrintTree() error {
+	return fs.FprintTree(os.Stdout, "/")
+}
+
+// FprintTree 把以 path 为根的目录树输出到 w
+func (fs *DiskFS) FprintTree(w io.Writer, path string) error {
+	dir, err := fs.namei(path)
+	if err == nil && dir.Type != Directory {
+		err = ErrNotDir
+	}
+	if err != nil {
+		return &PathError{"tree", path, err}
+	}
+	fmt.Fprintf(w, "%s (inode=%d)\n", path, dir.InodeNumber)
+	return fs.printTree(w, dir, "")
+}
+
+func (fs *DiskFS) printTree(w io.Writer, dir *Inode, prefix string) error {
+	if err := fs.access(dir, AccessRead|AccessExec); err != nil {
+		fmt.Fprintf(w, "%s└── (%v)\n", prefix, ErrPermission)
+		return nil
+	}
+	entries, err := fs.readDir(dir)
+	if err != nil {
+		return err
+	}
+	entries = entries[2:] // 跳过 "." 和 ".."
+	for i, e := range entries {
+		connector, childPrefix := "├── ", prefix+"│   "
+		if i == len(entries)-1 {
+			connector, childPrefix = "└── ", prefix+"    "
+		}
+		inode, err := fs.readInode(e.InodeNum)
+		if err != nil {
+			return err
+		}
+		switch inode.Type {
+		case Directory:
+			fmt.Fprintf(w, "%s%s%s/ (inode=%d)\n", prefix, connector, e.Name, e.InodeNum)
+			if err := fs.printTree(w, inode, childPrefix); err != nil {
+				return err
+			}
+		case SymLink:
+			target, err := fs.readlink(inode)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "%s%s%s -> %s (inode=%d)\n", prefix, connector, e.Name, target, e.InodeNum)
+		default:
+			fmt.Fprintf(w, "%s%s%s (inode=%d, %d B)\n", prefix, connector, e.Name, e.InodeNum, inode.FileSize)
+		}
+	}
+	return nil
+}
+
+// printBlocks 打印文件的块分配情况
+func (fs *DiskFS) printBlocks(path string) {
+	inode, err := fs.Stat(path)
+	if err != nil {
+		fmt.Println("错误:", err)
+		return
+	}
+	data, index, err := fs.blockList(inode)
+	if err != nil {
+		fmt.Println("错误:", err)
+		return
+	}
+	fmt.Printf("%s: inode=%d，%d B，数据块 %v", path, inode.InodeNumber, inode.FileSize, data)
+	if len(index) > 0 {
+		fmt.Printf("，间接块 %v", index)
+	}
+	fmt.Println()
+}
+
+// DiskFSExample 磁盘映像文件系统示例
+func DiskFSExample() {
+	fmt.Println("\n--- 磁盘映像文件系统 ---")
+
+	dir, err := os.MkdirTemp("", "fs408-")
+	if err != nil {
+		fmt.Println("错误:", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	image := filepath.Join(dir, "disk.img")
+
+	// 1. 格式化
+	fmt.Println("\n【示例1: 格式化 1MB 磁盘映像】")
+	fs, err := Format(image, 256, 64)
+	if err != nil {
+		fmt.Println("错误:", err)
+		return
+	}
+	fs.Super.Print()
+
+	// 2. 创建目录和文件
+	fmt.Println("\n【示例2: 创建目录和文件】")
+	for _, p := range []string{"/home", "/home/user", "/etc"} {
+		if err := fs.Mkdir(p); err != nil {
+			fmt.Println("错误:", err)
+			return
+		}
+	}
+	big := make([]byte, 60*1024) // 15 块：12 个直接块 + 一次间接
+	for i := range big {
+		big[i] = byte('a' + i/BlockSize)
+	}
+	files := []struct {
+		path string
+		data []byte
+	}{
+		{"/etc/hosts", []byte("127.0.0.1 localhost\n")},
+		{"/home/user/notes.txt", []byte("408: 超级块、位示图、inode 表、数据块\n")},
+		{"/home/user/big.dat", big},
+	}
+	for _, f := range files {
+		if err := fs.WriteFile(f.path, f.data); err != nil {
+			fmt.Println("错误:", err)
+			return
+		}
+	}
+	if err := fs.PrintTree(); err != nil {
+		fmt.Println("错误:", err)
+		return
+	}
+	fmt.Println()
+	fs.printBlocks("/home/user/notes.txt")
+	fs.printBlocks("/home/user/big.dat")
+	inode, _ := fs.Stat("/home/user/big.dat")
+	inode.Print()
+
+	fmt.Println("\nls -a /home/user（目录项只有 文件名 -> inode 号）:")
+	entries, _ := fs.ReadDir("/home/user")
+	for _, e := range entries {
+		fmt.Printf("  %-12s inode=%d\n", e.Name, e.InodeNum)
+	}
+	if node, err := fs.Stat("/home/user/../../etc/./hosts"); err == nil {
+		fmt.Printf("解析 /home/user/../../etc/./hosts → inode=%d（沿 \"..\" 目录项向上）\n", node.InodeNumber)
+	}
+
+	// 3. 读写、截断与删除
+	fmt.Println("\n【示例3: 读写、截断与删除】")
+	fs.WriteAt("/etc/hosts", 20, []byte("10.0.0.8 cs408\n"))
+	data, _ := fs.ReadFile("/etc/hosts")
+	fmt.Printf("追加后 /etc/hosts:\n%s", data)
+	before := fs.Super.FreeBlocks
+	fs.Truncate("/home/user/big.dat", 20*1024)
+	fmt.Printf("big.dat 截断到 20KB: 释放 %d 块（含一次间接块）\n", fs.Super.FreeBlocks-before)
+	fs.printBlocks("/home/user/big.dat")
+	fmt.Println("删除非空目录:", fs.Rmdir("/home/user"))
+	before = fs.Super.FreeBlocks
+	fs.Unlink("/home/user/big.dat")
+	fmt.Printf("unlink big.dat: 硬链接数减为 0，释放 %d 块，空闲 inode %d\n", fs.Super.FreeBlocks-before, fs.Super.FreeInodes)
+	fmt.Println("read big.dat:", func() error { _, err := fs.ReadFile("/home/user/big.dat"); return err }())
+
+	// 4. 卸载后重新挂载
+	fmt.Println("\n【示例4: 卸载后重新挂载】")
+	if err := fs.Unmount(); err != nil {
+		fmt.Println("错误:", err)
+		return
+	}
+	if fs, err = Mount(image); err != nil {
+		fmt.Println("错误:", err)
+		return
+	}
+	defer fs.Unmount()
+	fs.Super.Print()
+	fs.PrintTree()
+	data, _ = fs.ReadFile("/home/user/notes.txt")
+	fmt.Printf("notes.txt: %s", data)
+
+	fmt.Println("\n408考点:")
+	fmt.Println("  • 超级块描述整个文件系统；位示图管理空闲块；inode 表存放文件属性和块指针")
+	fmt.Println("  • 目录是特殊文件，内容为 (文件名, inode 号) 目录项；\".\"、\"..\" 也是目录项")
+	fmt.Println("  • 混合索引：小文件只用直接块，大文件才分配间接块（间接块也占数据区）")
+	fmt.Println("  • 删除文件 = 删除目录项 + 硬链接数减 1，减到 0 才回收 inode 和数据块")
+}
@@ -1,6 +1,28 @@
 package filesystem
 
-import "fmt"
+import (
+	"fmt"
+
+	"CS_Core_Courses/registry"
+)
+
+func init() {
+	registry.Register(&registry.Module{
+		Name:    "os/filesystem",
+		Section: "2.3",
+		Title:   "文件系统",
+		Run:     RunAllFilesystemExamples,
+		Examples: []registry.Example{
+			{Name: "inode", Run: InodeExample},
+			{Name: "directory", Run: DirectoryExample},
+			{Name: "allocation", Run: FileAllocationExample},
+			{Name: "disk", Run: DiskFSExample},
+			{Name: "links", Run: LinkExample},
+			{Name: "permissions", Run: PermissionExample},
+			{Name: "shell", Run: ShellExample},
+		},
+	})
+}
 
 // RunAllFilesystemExamples 运行所有文件系统示例
 func RunAllFilesystemExamples() {
@@ -11,4 +33,8 @@ func RunAllFilesystemExamples() {
 	InodeExample()
 	DirectoryExample()
 	FileAllocationExample()
+	DiskFSExample()
+	LinkExample()
+	PermissionExample()
+	ShellExample()
 }
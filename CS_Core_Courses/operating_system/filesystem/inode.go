@@ -70,6 +70,9 @@ type Inode struct {
 	Owner          Permission        // 属主权限
 	Group          Permission        // 组权限
 	Other          Permission        // 其他权限
+	UID            int               // 属主的用户号
+	GID            int               // 属组号
+	Sticky         bool              // 粘着位：目录中的文件只能由文件属主、目录属主或 root 删除
 	LinkCount      int               // 硬链接数
 	FileSize       int64             // 文件大小（字节）
 	DirectPtr      [DirectBlocks]int // 直接块指针（-1表示未分配）
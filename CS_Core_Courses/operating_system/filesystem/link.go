@@ -0,0 +1,179 @@
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ============================================================
+// 硬链接与符号链接
+// 408考点：硬链接是指向同一 inode 的另一个目录项（硬链接数 +1），
+//         删除时硬链接数减 1，减到 0 才释放 inode 和数据块；
+//         符号链接是一个独立的文件，内容是目标路径，解析路径时再展开，
+//         目标被删除后成为"悬空"链接
+// ============================================================
+
+// MaxSymlinkDepth 解析一条路径时最多展开的符号链接数，超过时返回 ErrLoop
+const MaxSymlinkDepth = 8
+
+// Link 为已有文件 oldPath 创建硬链接 newPath（不允许对目录建立硬链接）
+func (fs *DiskFS) Link(oldPath, newPath string) error {
+	if err := fs.link(oldPath, newPath); err != nil {
+		return &PathError{"link", newPath, err}
+	}
+	return nil
+}
+
+func (fs *DiskFS) link(oldPath, newPath string) error {
+	inode, err := fs.namei(oldPath)
+	if err != nil {
+		return err
+	}
+	if inode.Type == Directory {
+		return ErrIsDir
+	}
+	parent, name, err := fs.nameiParent(newPath)
+	if err != nil {
+		return err
+	}
+	if num, _, err := fs.dirLookup(parent, name); err != nil {
+		return err
+	} else if num != -1 {
+		return ErrExist
+	}
+	if err := fs.mayCreate(parent); err != nil {
+		return err
+	}
+	if err := fs.dirAdd(parent, name, inode.InodeNumber, inode.Type); err != nil {
+		return err
+	}
+	inode.LinkCount++
+	return fs.writeInode(inode)
+}
+
+// Symlink 创建符号链接 linkPath，内容为 target（target 不必存在）
+func (fs *DiskFS) Symlink(target, linkPath string) error {
+	if target == "" {
+		return &PathError{"symlink", linkPath, ErrInvalid}
+	}
+	inode, err := fs.create(linkPath, SymLink)
+	if err == nil {
+		err = fs.writeAt(inode, 0, []byte(target))
+	}
+	if err != nil {
+		return &PathError{"symlink", linkPath, err}
+	}
+	return nil
+}
+
+// Readlink 读出符号链接保存的目标路径
+func (fs *DiskFS) Readlink(path string) (string, error) {
+	inode, err := fs.lnamei(path)
+	if err == nil && inode.Type != SymLink {
+		err = ErrInvalid
+	}
+	var target string
+	if err == nil {
+		target, err = fs.readlink(inode)
+	}
+	if err != nil {
+		return "", &PathError{"readlink", path, err}
+	}
+	return target, nil
+}
+
+func (fs *DiskFS) readlink(inode *Inode) (string, error) {
+	target, err := fs.readAt(inode, 0, int(inode.FileSize))
+	return string(target), err
+}
+
+// LinkExample 硬链接与符号链接示例
+func LinkExample() {
+	fmt.Println("\n--- 硬链接与符号链接 ---")
+
+	dir, err := os.MkdirTemp("", "fs408-")
+	if err != nil {
+		fmt.Println("错误:", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	fs, err := Format(filepath.Join(dir, "disk.img"), 256, 64)
+	if err != nil {
+		fmt.Println("错误:", err)
+		return
+	}
+	defer fs.Unmount()
+	for _, p := range []string{"/data", "/home", "/usr", "/usr/lib"} {
+		fs.Mkdir(p)
+	}
+	fs.WriteFile("/data/report.txt", make([]byte, 3*BlockSize))
+	fs.WriteFile("/usr/lib/libc.so", []byte("ELF"))
+
+	// 1. 硬链接
+	fmt.Println("\n【示例1: 硬链接共享同一个 inode】")
+	fs.Link("/data/report.txt", "/home/report.txt")
+	for _, p := range []string{"/data/report.txt", "/home/report.txt"} {
+		inode, _ := fs.Stat(p)
+		fmt.Printf("  %-18s inode=%d  硬链接数=%d\n", p, inode.InodeNumber, inode.LinkCount)
+	}
+	fmt.Println("  ln /data /home/data:", fs.Link("/data", "/home/data"))
+	free := fs.Super.FreeBlocks
+	fs.Unlink("/data/report.txt")
+	inode, _ := fs.Stat("/home/report.txt")
+	fmt.Printf("  删除 /data/report.txt: 硬链接数=%d，释放 %d 块，/home/report.txt 仍有 %d B\n",
+		inode.LinkCount, fs.Super.FreeBlocks-free, inode.FileSize)
+	fs.Unlink("/home/report.txt")
+	fmt.Printf("  再删除 /home/report.txt: 硬链接数减为 0，释放 %d 块\n", fs.Super.FreeBlocks-free)
+
+	// 2. 符号链接
+	fmt.Println("\n【示例2: 符号链接在解析路径时展开】")
+	fs.Symlink("/usr/lib", "/lib")             // 绝对路径
+	fs.Symlink("../lib/libc.so", "/home/libc") // 相对路径：从链接所在目录 /home 开始解析
+	for _, p := range []string{"/lib", "/home/libc"} {
+		link, _ := fs.Lstat(p)
+		target, _ := fs.Stat(p)
+		to, _ := fs.Readlink(p)
+		fmt.Printf("  %-10s -> %-14s lstat: inode=%d (%s)  stat: inode=%d (%s)\n",
+			p, to, link.InodeNumber, link.Type, target.InodeNumber, target.Type)
+	}
+	data, _ := fs.ReadFile("/lib/libc.so")
+	fmt.Printf("  读取 /lib/libc.so（路径中间的符号链接也会展开）: %q\n", data)
+	fs.Chdir("/lib")
+	wd, _ := fs.Getwd()
+	fmt.Printf("  cd /lib 后 pwd = %s（沿 \"..\" 得到的是实际路径）\n", wd)
+	fs.Chdir("/")
+	fs.Unlink("/usr/lib/libc.so")
+	_, err = fs.ReadFile("/home/libc")
+	target, _ := fs.Readlink("/home/libc")
+	fmt.Printf("  删除 libc.so 后: readlink /home/libc = %s，读取: %v（悬空链接）\n", target, err)
+
+	// 3. 循环
+	fmt.Printf("\n【示例3: 符号链接循环（最多展开 %d 层）】\n", MaxSymlinkDepth)
+	fs.Symlink("b", "/data/a")
+	fs.Symlink("a", "/data/b")
+	_, err = fs.Stat("/data/a")
+	fmt.Printf("  a -> b, b -> a: %v (ELOOP: %v)\n", err, errors.Is(err, ErrLoop))
+	fs.WriteFile("/data/l0", []byte("end"))
+	for depth := 1; depth <= MaxSymlinkDepth+1; depth++ {
+		fs.Symlink(fmt.Sprintf("l%d", depth-1), fmt.Sprintf("/data/l%d", depth))
+	}
+	for _, depth := range []int{MaxSymlinkDepth, MaxSymlinkDepth + 1} {
+		if data, err := fs.ReadFile(fmt.Sprintf("/data/l%d", depth)); err != nil {
+			fmt.Printf("  经过 %d 层链接: %v\n", depth, err)
+		} else {
+			fmt.Printf("  经过 %d 层链接: 读到 %q\n", depth, data)
+		}
+	}
+
+	fmt.Println("\n408考点总结:")
+	fmt.Println("  ┌────────────┬──────────────────────┬──────────────────────┐")
+	fmt.Println("  │            │ 硬链接               │ 符号链接             │")
+	fmt.Println("  ├────────────┼──────────────────────┼──────────────────────┤")
+	fmt.Println("  │ 实现       │ 目录项指向同一 inode │ 新 inode，内容为路径 │")
+	fmt.Println("  │ 跨文件系统 │ 不能                 │ 能                   │")
+	fmt.Println("  │ 指向目录   │ 不能                 │ 能                   │")
+	fmt.Println("  │ 删除目标后 │ 数据仍在             │ 链接悬空             │")
+	fmt.Println("  └────────────┴──────────────────────┴──────────────────────┘")
+}
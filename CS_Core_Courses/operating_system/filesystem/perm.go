@@ -0,0 +1,482 @@
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ============================================================
+// 文件保护：用户、组与访问权限
+// 408考点：访问控制表的简化形式——属主/组/其他用户各一组 rwx；
+//         目录的 r 表示列目录，w 表示在目录中创建/删除，x 表示搜索（经过该目录）；
+//         删除文件需要对所在目录有 w 权限，与文件本身的权限无关（粘着位除外）
+// ============================================================
+
+// 访问权限位（与 rwx 对应）
+const (
+	AccessRead  = 4
+	AccessWrite = 2
+	AccessExec  = 1
+)
+
+// User 调用者身份
+type User struct {
+	Name   string
+	UID    int
+	GID    int   // 主组
+	Groups []int // 附加组
+}
+
+// RootUser 超级用户（uid 0），不受读写权限限制
+var RootUser = User{Name: "root", UID: 0, GID: 0}
+
+// InGroup 用户是否属于组 gid
+func (u User) InGroup(gid int) bool {
+	if u.GID == gid {
+		return true
+	}
+	for _, g := range u.Groups {
+		if g == gid {
+			return true
+		}
+	}
+	return false
+}
+
+func (u User) String() string {
+	return fmt.Sprintf("%s(uid=%d)", u.Name, u.UID)
+}
+
+// Group 用户组
+type Group struct {
+	Name string
+	GID  int
+}
+
+// Accounts 用户和组的登记表（相当于 /etc/passwd 与 /etc/group）
+type Accounts struct {
+	Users  []User
+	Groups []Group
+}
+
+// DefaultAccounts 示例用的用户：alice 与 bob 同属 staff 组，carol 不在 staff 组
+func DefaultAccounts() *Accounts {
+	return &Accounts{
+		Users: []User{
+			RootUser,
+			{Name: "alice", UID: 1000, GID: 1000, Groups: []int{100}},
+			{Name: "bob", UID: 1001, GID: 1001, Groups: []int{100}},
+			{Name: "carol", UID: 1002, GID: 1002},
+		},
+		Groups: []Group{{"root", 0}, {"staff", 100}, {"alice", 1000}, {"bob", 1001}, {"carol", 1002}},
+	}
+}
+
+// LookupUser 按用户名查找
+func (a *Accounts) LookupUser(name string) (User, bool) {
+	for _, u := range a.Users {
+		if u.Name == name {
+			return u, true
+		}
+	}
+	return User{}, false
+}
+
+// LookupGroup 按组名查找组号
+func (a *Accounts) LookupGroup(name string) (int, bool) {
+	for _, g := range a.Groups {
+		if g.Name == name {
+			return g.GID, true
+		}
+	}
+	return 0, false
+}
+
+// UserName 用户号对应的用户名，未登记时返回数字
+func (a *Accounts) UserName(uid int) string {
+	for _, u := range a.Users {
+		if u.UID == uid {
+			return u.Name
+		}
+	}
+	return fmt.Sprint(uid)
+}
+
+// GroupName 组号对应的组名，未登记时返回数字
+func (a *Accounts) GroupName(gid int) string {
+	for _, g := range a.Groups {
+		if g.GID == gid {
+			return g.Name
+		}
+	}
+	return fmt.Sprint(gid)
+}
+
+// parseOwner 解析 chown 的 "用户[:组]" 参数
+func (a *Accounts) parseOwner(spec string) (uid, gid int, err error) {
+	uid, gid = -1, -1
+	name, group, hasGroup := strings.Cut(spec, ":")
+	if name != "" {
+		u, ok := a.LookupUser(name)
+		if !ok {
+			return 0, 0, fmt.Errorf("chown: 无效的用户 %q", name)
+		}
+		uid = u.UID
+	}
+	if hasGroup && group != "" {
+		g, ok := a.LookupGroup(group)
+		if !ok {
+			return 0, 0, fmt.Errorf("chown: 无效的组 %q", group)
+		}
+		gid = g
+	}
+	return uid, gid, nil
+}
+
+// ErrPermission 权限检查失败；具体原因见 *PermissionError
+var ErrPermission = errors.New("权限不够")
+
+// PermissionError 权限检查失败的详细信息，errors.Is(err, ErrPermission) 为真
+type PermissionError struct {
+	User   User
+	Inode  int    // 被检查的 inode 号
+	Mode   string // inode 的类型和权限，如 drwxr-xr-x
+	UID    int    // inode 的属主
+	GID    int    // inode 的属组
+	Want   int    // 需要的权限（AccessRead|AccessWrite|AccessExec 的组合）
+	Reason string // 不是 rwx 检查失败时的原因
+}
+
+func (e *PermissionError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("%v: %s 对 inode %d: %s", ErrPermission, e.User, e.Inode, e.Reason)
+	}
+	return fmt.Sprintf("%v: %s 需要 inode %d 的 %s 权限（%s，属主 uid=%d gid=%d）",
+		ErrPermission, e.User, e.Inode, wantString(e.Want), e.Mode, e.UID, e.GID)
+}
+
+func (e *PermissionError) Is(target error) bool { return target == ErrPermission }
+
+func wantString(want int) string {
+	s := ""
+	for _, c := range []struct {
+		bit  int
+		name string
+	}{{AccessRead, "r"}, {AccessWrite, "w"}, {AccessExec, "x"}} {
+		if want&c.bit != 0 {
+			s += c.name
+		}
+	}
+	return s
+}
+
+// modeString 类似 ls -l 的类型和权限，如 drwxr-xr-x；粘着位显示在其他用户的 x 位（t/T）
+func modeString(inode *Inode) string {
+	kind := "-"
+	switch inode.Type {
+	case Directory:
+		kind = "d"
+	case SymLink:
+		kind = "l"
+	}
+	s := kind + inode.Owner.String() + inode.Group.String() + inode.Other.String()
+	if inode.Sticky {
+		if inode.Other.Execute {
+			s = s[:9] + "t"
+		} else {
+			s = s[:9] + "T"
+		}
+	}
+	return s
+}
+
+func (fs *DiskFS) denied(inode *Inode, want int, reason string) error {
+	return &PermissionError{User: fs.user, Inode: inode.InodeNumber, Mode: modeString(inode),
+		UID: inode.UID, GID: inode.GID, Want: want, Reason: reason}
+}
+
+// access 检查调用者对 inode 是否有 want 权限
+// 按 属主 -> 属组 -> 其他用户 的顺序只选一组权限位检查；
+// root 不受读写限制，但执行普通文件仍要求至少有一类用户有 x 权限
+func (fs *DiskFS) access(inode *Inode, want int) error {
+	if fs.user.UID == 0 {
+		if want&AccessExec == 0 || inode.Type == Directory || inode.mode()&0111 != 0 {
+			return nil
+		}
+		return fs.denied(inode, want, "")
+	}
+	var perm Permission
+	switch {
+	case fs.user.UID == inode.UID:
+		perm = inode.Owner
+	case fs.user.InGroup(inode.GID):
+		perm = inode.Group
+	default:
+		perm = inode.Other
+	}
+	if int(perm.bits())&want != want {
+		return fs.denied(inode, want, "")
+	}
+	return nil
+}
+
+// mayCreate 在目录中创建目录项需要对目录有 w 和 x 权限
+func (fs *DiskFS) mayCreate(dir *Inode) error {
+	return fs.access(dir, AccessWrite|AccessExec)
+}
+
+// mayDelete 从目录中删除 victim 的目录项：需要对目录有 w 和 x 权限；
+// 目录设置了粘着位时，还要求调用者是文件属主、目录属主或 root
+func (fs *DiskFS) mayDelete(dir, victim *Inode) error {
+	if err := fs.mayCreate(dir); err != nil {
+		return err
+	}
+	if dir.Sticky && fs.user.UID != 0 && fs.user.UID != victim.UID && fs.user.UID != dir.UID {
+		return fs.denied(victim, AccessWrite,
+			fmt.Sprintf("目录 inode %d 设置了粘着位，只有文件属主、目录属主或 root 可以删除", dir.InodeNumber))
+	}
+	return nil
+}
+
+// mayChangeAttr 只有属主和 root 可以修改权限和属组
+func (fs *DiskFS) mayChangeAttr(inode *Inode) error {
+	if fs.user.UID != 0 && fs.user.UID != inode.UID {
+		return fs.denied(inode, 0, "只有属主或 root 可以修改")
+	}
+	return nil
+}
+
+// SetUser 切换调用者身份（相当于 su），之后的操作按新身份检查权限
+func (fs *DiskFS) SetUser(u User) {
+	fs.user = u
+}
+
+// CurrentUser 当前调用者身份
+func (fs *DiskFS) CurrentUser() User {
+	return fs.user
+}
+
+// Access 检查调用者能否以 want 方式访问 path（相当于 access 系统调用）
+func (fs *DiskFS) Access(path string, want int) error {
+	inode, err := fs.namei(path)
+	if err == nil {
+		err = fs.access(inode, want)
+	}
+	if err != nil {
+		return &PathError{"access", path, err}
+	}
+	return nil
+}
+
+// Exec 装入可执行文件：只需要 x 权限，不需要 r 权限（由内核读出文件内容）
+func (fs *DiskFS) Exec(path string) ([]byte, error) {
+	inode, err := fs.namei(path)
+	if err == nil && inode.Type == Directory {
+		err = ErrIsDir
+	}
+	if err == nil {
+		err = fs.access(inode, AccessExec)
+	}
+	var image []byte
+	if err == nil {
+		image, err = fs.readAt(inode, 0, int(inode.FileSize))
+	}
+	if err != nil {
+		return nil, &PathError{"exec", path, err}
+	}
+	return image, nil
+}
+
+// Chown 修改属主和属组（uid 或 gid 为 -1 表示不修改）
+// 只有 root 能修改属主；属主可以把属组改为自己所在的组
+func (fs *DiskFS) Chown(path string, uid, gid int) error {
+	inode, err := fs.namei(path)
+	if err == nil {
+		err = fs.chown(inode, uid, gid)
+	}
+	if err != nil {
+		return &PathError{"chown", path, err}
+	}
+	return nil
+}
+
+func (fs *DiskFS) chown(inode *Inode, uid, gid int) error {
+	if fs.user.UID != 0 {
+		if uid != -1 && uid != inode.UID {
+			return fs.denied(inode, 0, "只有 root 可以修改属主")
+		}
+		if err := fs.mayChangeAttr(inode); err != nil {
+			return err
+		}
+		if gid != -1 && !fs.user.InGroup(gid) {
+			return fs.denied(inode, 0, fmt.Sprintf("%s 不属于组 %d", fs.user.Name, gid))
+		}
+	}
+	if uid != -1 {
+		inode.UID = uid
+	}
+	if gid != -1 {
+		inode.GID = gid
+	}
+	return fs.writeInode(inode)
+}
+
+// PermissionExample 权限检查示例
+func PermissionExample() {
+	fmt.Println("\n--- 文件保护与权限检查 ---")
+
+	dir, err := os.MkdirTemp("", "fs408-")
+	if err != nil {
+		fmt.Println("错误:", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	fs, err := Format(filepath.Join(dir, "disk.img"), 256, 64)
+	if err != nil {
+		fmt.Println("错误:", err)
+		return
+	}
+	defer fs.Unmount()
+
+	accounts := DefaultAccounts()
+	alice, _ := accounts.LookupUser("alice")
+	bob, _ := accounts.LookupUser("bob")
+	carol, _ := accounts.LookupUser("carol")
+	as := func(u User) { fs.SetUser(u) }
+	try := func(what string, err error) {
+		if err != nil {
+			fmt.Printf("  %s✗ %v\n", padRight(what, 38), unwrapPath(err))
+		} else {
+			fmt.Printf("  %s✓\n", padRight(what, 38))
+		}
+	}
+	show := func(path string) {
+		inode, err := fs.Lstat(path)
+		if err != nil {
+			fmt.Println("  错误:", err)
+			return
+		}
+		fmt.Printf("  %s %-6s %-6s %s\n", modeString(inode),
+			accounts.UserName(inode.UID), accounts.GroupName(inode.GID), path)
+	}
+
+	fs.Mkdir("/home")
+	fs.Mkdir("/home/alice")
+	fs.Chown("/home/alice", alice.UID, alice.GID)
+	fs.Mkdir("/tmp")
+	fs.Chmod("/tmp", 01777)
+
+	// 1. 文件的 rwx
+	fmt.Println("\n【示例1: 属主/组/其他用户的 rwx（umask 022）】")
+	as(alice)
+	fs.WriteFile("/home/alice/notes.txt", []byte("alice 的笔记\n"))
+	fs.Chown("/home/alice/notes.txt", -1, 100) // 属组改为 staff
+	show("/home/alice/notes.txt")
+	as(bob)
+	try("bob 读 notes.txt（组 r）", readErr(fs, "/home/alice/notes.txt"))
+	try("bob 写 notes.txt（组无 w）", fs.WriteAt("/home/alice/notes.txt", 0, []byte("x")))
+	try("bob chmod notes.txt（不是属主）", fs.Chmod("/home/alice/notes.txt", 0666))
+	as(alice)
+	fs.Chmod("/home/alice/notes.txt", 0640)
+	show("/home/alice/notes.txt")
+	as(bob)
+	try("bob 读 notes.txt（staff 组成员）", readErr(fs, "/home/alice/notes.txt"))
+	as(carol)
+	try("carol 读 notes.txt（其他用户无 r）", readErr(fs, "/home/alice/notes.txt"))
+	as(RootUser)
+	try("root 读 notes.txt（不受限制）", readErr(fs, "/home/alice/notes.txt"))
+
+	// 2. 目录的 rwx
+	fmt.Println("\n【示例2: 目录的 r（列目录）、w（创建/删除）、x（搜索）】")
+	as(bob)
+	try("bob 在 /home/alice 创建文件", fs.WriteFile("/home/alice/bob.txt", nil))
+	as(alice)
+	fs.Chmod("/home/alice", 0711)
+	show("/home/alice")
+	as(bob)
+	try("bob 列出 /home/alice（无 r）", readDirErr(fs, "/home/alice"))
+	try("bob 读 /home/alice/notes.txt（有 x）", readErr(fs, "/home/alice/notes.txt"))
+	as(alice)
+	fs.Chmod("/home/alice", 0700)
+	as(bob)
+	try("bob 读 /home/alice/notes.txt（无 x）", readErr(fs, "/home/alice/notes.txt"))
+	as(alice)
+	fs.WriteFile("/home/alice/readonly.txt", []byte("只读\n"))
+	fs.Chmod("/home/alice/readonly.txt", 0444)
+	try("alice 删除自己目录中的只读文件", fs.Unlink("/home/alice/readonly.txt"))
+
+	// 3. umask 与执行权限
+	fmt.Println("\n【示例3: umask 与执行权限】")
+	fs.Umask = 0077
+	fs.WriteFile("/tmp/private", nil)
+	fs.Mkdir("/tmp/secret")
+	show("/tmp/private")
+	show("/tmp/secret")
+	fs.Umask = 0022
+	fs.WriteFile("/tmp/tool", []byte("#!/bin/sh\necho hi\n"))
+	fs.Chmod("/tmp/tool", 0711)
+	show("/tmp/tool")
+	as(bob)
+	try("bob 执行 /tmp/tool（x 足够）", execErr(fs, "/tmp/tool"))
+	try("bob 读 /tmp/tool（无 r）", readErr(fs, "/tmp/tool"))
+	as(RootUser)
+	try("root 执行 /tmp/private（无人有 x）", execErr(fs, "/tmp/private"))
+
+	// 4. 粘着位
+	fmt.Println("\n【示例4: /tmp 的粘着位】")
+	show("/tmp")
+	as(bob)
+	fs.WriteFile("/tmp/bob.log", []byte("bob\n"))
+	try("bob 删除 alice 的 /tmp/private", fs.Unlink("/tmp/private"))
+	try("bob 删除自己的 /tmp/bob.log", fs.Unlink("/tmp/bob.log"))
+	as(alice)
+	try("alice 删除自己的 /tmp/private", fs.Unlink("/tmp/private"))
+	as(RootUser)
+	fs.Chmod("/tmp", 0777)
+	as(bob)
+	try("去掉粘着位后 bob 删除 /tmp/tool", fs.Unlink("/tmp/tool"))
+
+	// 5. 类型化错误
+	fmt.Println("\n【示例5: 类型化的错误】")
+	as(carol)
+	err = fs.WriteFile("/home/alice/x", nil)
+	var perr *PermissionError
+	if errors.As(err, &perr) {
+		fmt.Printf("  errors.Is(err, ErrPermission)=%v  用户=%s  inode=%d  需要=%s  权限=%s\n",
+			errors.Is(err, ErrPermission), perr.User.Name, perr.Inode, wantString(perr.Want), perr.Mode)
+	}
+	as(RootUser)
+
+	fmt.Println("\n408考点:")
+	fmt.Println("  • 按 属主 → 属组 → 其他用户 只选一组权限位检查，不会叠加")
+	fmt.Println("  • 路径上每一级目录都需要 x 权限；列目录需要 r，创建/删除需要 w 和 x")
+	fmt.Println("  • 删除文件取决于目录的 w 权限；粘着位限制只能删除自己的文件")
+	fmt.Println("  • 新文件权限 = 0666 &^ umask，新目录 = 0777 &^ umask")
+}
+
+func readErr(fs *DiskFS, path string) error {
+	_, err := fs.ReadFile(path)
+	return err
+}
+
+func readDirErr(fs *DiskFS, path string) error {
+	_, err := fs.ReadDir(path)
+	return err
+}
+
+func execErr(fs *DiskFS, path string) error {
+	_, err := fs.Exec(path)
+	return err
+}
+
+// unwrapPath 去掉 PathError 的操作和路径前缀，只保留原因
+func unwrapPath(err error) error {
+	var pe *PathError
+	if errors.As(err, &pe) {
+		return pe.Err
+	}
+	return err
+}
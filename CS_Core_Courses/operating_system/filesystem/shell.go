@@ -0,0 +1,685 @@
+package filesystem
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ============================================================
+// 文件系统 shell
+// 在磁盘文件系统上交互执行 ls/cd/mkdir/cat/rm/ln 等命令，
+// 观察路径解析（含 "." 和 ".."）、目录项、inode 和块分配
+// ============================================================
+
+// Shell 文件系统命令解释器
+type Shell struct {
+	FS       *DiskFS
+	Accounts *Accounts // su、chown 和 ls -l 使用的用户与组
+	out      io.Writer
+}
+
+// NewShell 创建 shell，命令输出写到 out
+func NewShell(fs *DiskFS, out io.Writer) *Shell {
+	return &Shell{FS: fs, Accounts: DefaultAccounts(), out: out}
+}
+
+// shellHelp 命令用法，help 按此顺序输出
+var shellHelp = [][2]string{
+	{"ls [-l] [-a] [路径...]", "列出目录（-l 显示 inode 号、权限、硬链接数、属主、属组、大小）"},
+	{"cd [目录]", "改变当前目录（缺省为 /）"},
+	{"pwd", "显示当前目录（沿 \"..\" 逐级向上查找得到）"},
+	{"mkdir [-p] 目录...", "创建目录（-p 同时创建缺少的上级目录）"},
+	{"touch 文件...", "创建空文件"},
+	{"cat 文件...", "显示文件内容"},
+	{"echo 文本... [>|>> 文件]", "输出文本，或写入/追加到文件"},
+	{"rm [-r] 路径...", "删除文件（-r 递归删除目录）"},
+	{"rmdir 目录...", "删除空目录"},
+	{"mv 源 目标", "改名或移动"},
+	{"cp 源 目标", "复制文件"},
+	{"ln [-s] 源 链接", "创建硬链接（-s 创建符号链接）"},
+	{"readlink 链接...", "显示符号链接的目标路径"},
+	{"chmod 权限 路径...", "修改权限（八进制，如 755；1777 带粘着位）"},
+	{"chown 用户[:组] 路径...", "修改属主和属组（只有 root 能改属主）"},
+	{"su [用户]", "切换用户（缺省为 root），之后的命令按该用户检查权限"},
+	{"id", "显示当前用户的 uid、gid 和所属组"},
+	{"umask [权限]", "显示或设置创建文件时屏蔽的权限位（八进制）"},
+	{"exec 文件", "执行文件（只需要 x 权限），输出文件内容"},
+	{"stat 路径...", "显示 inode 详细信息和块列表"},
+	{"tree [目录]", "显示目录树"},
+	{"df", "显示超级块和空闲块、空闲 inode 数"},
+	{"help", "显示本帮助"},
+	{"exit", "退出"},
+}
+
+// Run 逐行读取并执行命令，直到输入结束或 exit
+// interactive 为 true 时输出提示符；否则回显每条命令和 # 注释，便于阅读脚本的输出
+func (sh *Shell) Run(in io.Reader, interactive bool) error {
+	scanner := bufio.NewScanner(in)
+	for {
+		if interactive {
+			fmt.Fprintf(sh.out, "%s ", sh.prompt())
+		}
+		if !scanner.Scan() {
+			break
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			if !interactive {
+				fmt.Fprintln(sh.out, line)
+			}
+			continue
+		}
+		if !interactive {
+			fmt.Fprintf(sh.out, "%s %s\n", sh.prompt(), line)
+		}
+		if sh.Exec(line) {
+			return nil
+		}
+	}
+	if interactive {
+		fmt.Fprintln(sh.out)
+	}
+	return scanner.Err()
+}
+
+// prompt 形如 alice:/home$，root 用 # 结尾
+func (sh *Shell) prompt() string {
+	wd, err := sh.FS.Getwd()
+	if err != nil {
+		wd = "?"
+	}
+	u := sh.FS.CurrentUser()
+	if u.UID == 0 {
+		return u.Name + ":" + wd + "#"
+	}
+	return u.Name + ":" + wd + "$"
+}
+
+// Exec 执行一条命令，出错时把错误输出到 out；返回 true 表示退出 shell
+func (sh *Shell) Exec(line string) (quit bool) {
+	args, err := tokenize(line)
+	if err == nil && len(args) > 0 {
+		cmd := args[0]
+		args = args[1:]
+		switch cmd {
+		case "exit", "quit":
+			return true
+		case "help":
+			for _, h := range shellHelp {
+				fmt.Fprintf(sh.out, "  %s%s\n", padRight(h[0], 28), h[1])
+			}
+		case "ls":
+			err = sh.ls(args)
+		case "cd":
+			dir := "/"
+			if len(args) > 0 {
+				dir = args[0]
+			}
+			err = sh.FS.Chdir(dir)
+		case "pwd":
+			var wd string
+			if wd, err = sh.FS.Getwd(); err == nil {
+				fmt.Fprintln(sh.out, wd)
+			}
+		case "mkdir":
+			err = sh.mkdir(args)
+		case "touch":
+			err = sh.each(cmd, args, sh.touch)
+		case "cat":
+			err = sh.each(cmd, args, sh.cat)
+		case "echo":
+			err = sh.echo(args)
+		case "rm":
+			err = sh.rm(args)
+		case "rmdir":
+			err = sh.each(cmd, args, sh.FS.Rmdir)
+		case "mv", "cp":
+			err = sh.moveOrCopy(cmd, args)
+		case "ln":
+			err = sh.ln(args)
+		case "readlink":
+			err = sh.each(cmd, args, func(path string) error {
+				target, err := sh.FS.Readlink(path)
+				if err == nil {
+					fmt.Fprintln(sh.out, target)
+				}
+				return err
+			})
+		case "chmod":
+			err = sh.chmod(args)
+		case "chown":
+			err = sh.chown(args)
+		case "su":
+			err = sh.su(args)
+		case "id":
+			sh.id()
+		case "umask":
+			err = sh.umask(args)
+		case "exec":
+			err = sh.each(cmd, args, func(path string) error {
+				image, err := sh.FS.Exec(path)
+				if err == nil {
+					_, err = sh.out.Write(image)
+				}
+				return err
+			})
+		case "stat":
+			err = sh.each(cmd, args, sh.stat)
+		case "tree":
+			dir := "."
+			if len(args) > 0 {
+				dir = args[0]
+			}
+			err = sh.FS.FprintTree(sh.out, dir)
+		case "df":
+			sh.FS.Super.Fprint(sh.out)
+		default:
+			err = fmt.Errorf("%s: 未知命令（输入 help 查看可用命令）", cmd)
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(sh.out, err)
+	}
+	return false
+}
+
+// tokenize 按空白切分命令行，支持单引号和双引号
+func tokenize(line string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	inArg := false
+	var quote rune
+	for _, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("引号不匹配: %s", line)
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
+
+// splitFlags 分离 "-l"、"-la" 形式的选项，allowed 为允许的选项字母
+func splitFlags(cmd string, args []string, allowed string) (map[rune]bool, []string, error) {
+	flags := make(map[rune]bool)
+	var rest []string
+	for _, a := range args {
+		if len(a) < 2 || a[0] != '-' {
+			rest = append(rest, a)
+			continue
+		}
+		for _, r := range a[1:] {
+			if !strings.ContainsRune(allowed, r) {
+				return nil, nil, fmt.Errorf("%s: 无效选项 -%c", cmd, r)
+			}
+			flags[r] = true
+		}
+	}
+	return flags, rest, nil
+}
+
+// each 对每个参数执行 fn
+func (sh *Shell) each(cmd string, args []string, fn func(string) error) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%s: 缺少操作数", cmd)
+	}
+	for _, a := range args {
+		if err := fn(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// joinPath 拼接目录和文件名
+func joinPath(dir, name string) string {
+	if strings.HasSuffix(dir, "/") {
+		return dir + name
+	}
+	return dir + "/" + name
+}
+
+// baseName 路径的最后一级
+func baseName(path string) string {
+	parts := parsePath(path)
+	if len(parts) == 0 {
+		return "/"
+	}
+	return parts[len(parts)-1]
+}
+
+func (sh *Shell) ls(args []string) error {
+	flags, paths, err := splitFlags("ls", args, "la")
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	for i, p := range paths {
+		inode, err := sh.FS.Lstat(p)
+		if err == nil && inode.Type == SymLink && !flags['l'] {
+			inode, err = sh.FS.Stat(p) // 指向目录的符号链接：列出目录内容
+		}
+		if err != nil {
+			return err
+		}
+		if inode.Type != Directory {
+			sh.lsEntry(flags['l'], p, inode)
+			continue
+		}
+		if len(paths) > 1 {
+			if i > 0 {
+				fmt.Fprintln(sh.out)
+			}
+			fmt.Fprintf(sh.out, "%s:\n", p)
+		}
+		entries, err := sh.FS.ReadDir(p)
+		if err != nil {
+			return err
+		}
+		var names []string
+		for _, e := range entries {
+			if !flags['a'] && (e.Name == "." || e.Name == "..") {
+				continue
+			}
+			child, err := sh.FS.readInode(e.InodeNum)
+			if err != nil {
+				return err
+			}
+			if flags['l'] {
+				sh.lsEntry(true, e.Name, child)
+			} else if child.Type == Directory {
+				names = append(names, e.Name+"/")
+			} else {
+				names = append(names, e.Name)
+			}
+		}
+		if len(names) > 0 {
+			fmt.Fprintln(sh.out, strings.Join(names, "  "))
+		}
+	}
+	return nil
+}
+
+// lsEntry 输出一项：inode 号、权限、硬链接数、属主、属组、大小、文件名
+func (sh *Shell) lsEntry(long bool, name string, inode *Inode) {
+	if !long {
+		fmt.Fprintln(sh.out, name)
+		return
+	}
+	fmt.Fprintf(sh.out, "%4d %s %2d %-6s %-6s %6d %s", inode.InodeNumber, modeString(inode), inode.LinkCount,
+		sh.Accounts.UserName(inode.UID), sh.Accounts.GroupName(inode.GID), inode.FileSize, name)
+	if inode.Type == SymLink {
+		if target, err := sh.FS.readlink(inode); err == nil {
+			fmt.Fprintf(sh.out, " -> %s", target)
+		}
+	}
+	fmt.Fprintln(sh.out)
+}
+
+func (sh *Shell) mkdir(args []string) error {
+	flags, paths, err := splitFlags("mkdir", args, "p")
+	if err != nil {
+		return err
+	}
+	if !flags['p'] {
+		return sh.each("mkdir", paths, sh.FS.Mkdir)
+	}
+	return sh.each("mkdir", paths, func(path string) error {
+		prefix := ""
+		if strings.HasPrefix(path, "/") {
+			prefix = "/"
+		}
+		for _, part := range parsePath(path) {
+			prefix += part
+			if inode, err := sh.FS.Stat(prefix); err == nil {
+				if inode.Type != Directory {
+					return &PathError{"mkdir", prefix, ErrNotDir}
+				}
+			} else if err := sh.FS.Mkdir(prefix); err != nil {
+				return err
+			}
+			prefix += "/"
+		}
+		return nil
+	})
+}
+
+func (sh *Shell) touch(path string) error {
+	if _, err := sh.FS.Stat(path); err == nil {
+		return nil
+	}
+	_, err := sh.FS.Create(path)
+	return err
+}
+
+func (sh *Shell) cat(path string) error {
+	data, err := sh.FS.ReadFile(path)
+	if err == nil {
+		_, err = sh.out.Write(data)
+	}
+	return err
+}
+
+// echo 输出文本；"> 文件" 覆盖写入，">> 文件" 追加
+func (sh *Shell) echo(args []string) error {
+	for i, a := range args {
+		if a != ">" && a != ">>" {
+			continue
+		}
+		if i != len(args)-2 {
+			return errors.New("echo: 重定向后需要且只能有一个文件名")
+		}
+		data := []byte(strings.Join(args[:i], " ") + "\n")
+		path := args[i+1]
+		if a == ">" {
+			return sh.FS.WriteFile(path, data)
+		}
+		inode, err := sh.FS.Stat(path)
+		if errors.Is(err, ErrNotExist) {
+			return sh.FS.WriteFile(path, data)
+		}
+		if err != nil {
+			return err
+		}
+		return sh.FS.WriteAt(path, inode.FileSize, data)
+	}
+	fmt.Fprintln(sh.out, strings.Join(args, " "))
+	return nil
+}
+
+func (sh *Shell) rm(args []string) error {
+	flags, paths, err := splitFlags("rm", args, "r")
+	if err != nil {
+		return err
+	}
+	return sh.each("rm", paths, func(path string) error {
+		inode, err := sh.FS.Lstat(path) // 删除符号链接本身，而不是它指向的目录
+		if err != nil {
+			return err
+		}
+		if inode.Type != Directory {
+			return sh.FS.Unlink(path)
+		}
+		if !flags['r'] {
+			return &PathError{"rm", path, ErrIsDir}
+		}
+		return sh.removeAll(path)
+	})
+}
+
+// removeAll 先删除目录中的所有文件和子目录，再删除目录本身
+func (sh *Shell) removeAll(path string) error {
+	entries, err := sh.FS.ReadDir(path)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries[2:] {
+		child := joinPath(path, e.Name)
+		if e.IsDir {
+			err = sh.removeAll(child)
+		} else {
+			err = sh.FS.Unlink(child)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return sh.FS.Rmdir(path)
+}
+
+// targetPath 目标是已存在的目录时，放到该目录下并沿用源文件名
+func (sh *Shell) targetPath(src, dst string) string {
+	if inode, err := sh.FS.Stat(dst); err == nil && inode.Type == Directory {
+		return joinPath(dst, baseName(src))
+	}
+	return dst
+}
+
+func (sh *Shell) moveOrCopy(cmd string, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("用法: %s 源 目标", cmd)
+	}
+	dst := sh.targetPath(args[0], args[1])
+	if cmd == "mv" {
+		return sh.FS.Rename(args[0], dst)
+	}
+	data, err := sh.FS.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+	return sh.FS.WriteFile(dst, data)
+}
+
+func (sh *Shell) ln(args []string) error {
+	flags, paths, err := splitFlags("ln", args, "s")
+	if err != nil {
+		return err
+	}
+	if len(paths) != 2 {
+		return errors.New("用法: ln [-s] 源 链接")
+	}
+	dst := sh.targetPath(paths[0], paths[1])
+	if flags['s'] {
+		return sh.FS.Symlink(paths[0], dst)
+	}
+	return sh.FS.Link(paths[0], dst)
+}
+
+func (sh *Shell) chmod(args []string) error {
+	if len(args) < 2 {
+		return errors.New("用法: chmod 权限 路径...")
+	}
+	mode, err := strconv.ParseUint(args[0], 8, 16)
+	if err != nil || mode > 01777 {
+		return fmt.Errorf("chmod: 无效的权限 %q", args[0])
+	}
+	return sh.each("chmod", args[1:], func(path string) error {
+		return sh.FS.Chmod(path, int(mode))
+	})
+}
+
+func (sh *Shell) chown(args []string) error {
+	if len(args) < 2 {
+		return errors.New("用法: chown 用户[:组] 路径...")
+	}
+	uid, gid, err := sh.Accounts.parseOwner(args[0])
+	if err != nil {
+		return err
+	}
+	return sh.each("chown", args[1:], func(path string) error {
+		return sh.FS.Chown(path, uid, gid)
+	})
+}
+
+// su 切换用户（缺省为 root）；示例 shell 不检查口令
+func (sh *Shell) su(args []string) error {
+	name := "root"
+	if len(args) > 0 {
+		name = args[0]
+	}
+	u, ok := sh.Accounts.LookupUser(name)
+	if !ok {
+		return fmt.Errorf("su: 用户 %q 不存在", name)
+	}
+	sh.FS.SetUser(u)
+	return nil
+}
+
+func (sh *Shell) id() {
+	u := sh.FS.CurrentUser()
+	groups := []string{fmt.Sprintf("%d(%s)", u.GID, sh.Accounts.GroupName(u.GID))}
+	for _, g := range u.Groups {
+		groups = append(groups, fmt.Sprintf("%d(%s)", g, sh.Accounts.GroupName(g)))
+	}
+	fmt.Fprintf(sh.out, "uid=%d(%s) gid=%d(%s) 组=%s\n",
+		u.UID, u.Name, u.GID, sh.Accounts.GroupName(u.GID), strings.Join(groups, ","))
+}
+
+func (sh *Shell) umask(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintf(sh.out, "%04o\n", sh.FS.Umask)
+		return nil
+	}
+	mask, err := strconv.ParseUint(args[0], 8, 16)
+	if err != nil || mask > 0777 {
+		return fmt.Errorf("umask: 无效的权限 %q", args[0])
+	}
+	sh.FS.Umask = int(mask)
+	return nil
+}
+
+// stat 输出 inode 的全部字段，以及按逻辑顺序排列的数据块和间接块
+func (sh *Shell) stat(path string) error {
+	inode, err := sh.FS.Lstat(path)
+	if err != nil {
+		return err
+	}
+	data, index, err := sh.FS.blockList(inode)
+	if err != nil {
+		return err
+	}
+	w := sh.out
+	fmt.Fprintf(w, "  文件: %s", path)
+	if inode.Type == SymLink {
+		if target, err := sh.FS.readlink(inode); err == nil {
+			fmt.Fprintf(w, " -> %s", target)
+		}
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "  inode: %d  类型: %s  权限: %s (%04o)  硬链接数: %d\n",
+		inode.InodeNumber, inode.Type, modeString(inode), inode.mode(), inode.LinkCount)
+	fmt.Fprintf(w, "  属主: %d(%s)  属组: %d(%s)\n",
+		inode.UID, sh.Accounts.UserName(inode.UID), inode.GID, sh.Accounts.GroupName(inode.GID))
+	fmt.Fprintf(w, "  大小: %d B  占用块: %d（数据块 %d + 间接块 %d）\n",
+		inode.FileSize, len(data)+len(index), len(data), len(index))
+	ptrs := make([]string, len(inode.DirectPtr))
+	for i, p := range inode.DirectPtr {
+		ptrs[i] = formatPtr(p)
+	}
+	fmt.Fprintf(w, "  直接块指针: [%s]\n", strings.Join(ptrs, " "))
+	fmt.Fprintf(w, "  间接块指针: 一次 %s，二次 %s，三次 %s\n",
+		formatPtr(inode.SingleIndirect), formatPtr(inode.DoubleIndirect), formatPtr(inode.TripleIndirect))
+	fmt.Fprintf(w, "  数据块: %v\n", data)
+	if len(index) > 0 {
+		fmt.Fprintf(w, "  间接块: %v\n", index)
+	}
+	return nil
+}
+
+func formatPtr(p int) string {
+	if p == noBlock {
+		return "-"
+	}
+	return strconv.Itoa(p)
+}
+
+// padRight 按显示宽度右补空格（汉字占两列）
+func padRight(s string, width int) string {
+	w := 0
+	for _, r := range s {
+		if r > 0x2E80 {
+			w += 2
+		} else {
+			w++
+		}
+	}
+	if w >= width {
+		return s + " "
+	}
+	return s + strings.Repeat(" ", width-w)
+}
+
+// ShellExample 用一段脚本演示文件系统 shell
+func ShellExample() {
+	fmt.Println("\n--- 文件系统 shell ---")
+
+	dir, err := os.MkdirTemp("", "fs408-")
+	if err != nil {
+		fmt.Println("错误:", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	fs, err := Format(filepath.Join(dir, "disk.img"), 256, 64)
+	if err != nil {
+		fmt.Println("错误:", err)
+		return
+	}
+	defer fs.Unmount()
+
+	script := `
+# 相对路径从当前目录解析，".." 是目录中真实的目录项
+mkdir -p /home/user/docs /etc
+cd /home/user
+echo hello 408 > docs/a.txt
+echo 第二行 >> docs/a.txt
+cat docs/a.txt
+ls -la docs
+cd docs/../..
+pwd
+# 硬链接共享 inode（硬链接数变为 2），符号链接是新的 inode
+cd user/docs
+ln a.txt hard.txt
+ln -s a.txt soft
+ls -l
+stat a.txt
+cat soft
+# 删除一个目录项后，另一个硬链接仍能访问数据，符号链接则悬空
+rm a.txt
+cat soft
+mv hard.txt /etc
+cat /etc/hard.txt
+cp /etc/hard.txt copy.txt
+chmod 600 copy.txt
+ls -l . /etc
+cd /
+rm home
+rm -r home
+tree
+# 以普通用户身份操作：/tmp 带粘着位，只能删除自己的文件
+mkdir /tmp
+chmod 1777 /tmp
+su alice
+echo alice > /tmp/a.txt
+touch /etc/x
+su bob
+id
+rm /tmp/a.txt
+cat /tmp/a.txt
+su
+ls -l /tmp
+df
+`
+	NewShell(fs, os.Stdout).Run(strings.NewReader(script), false)
+}
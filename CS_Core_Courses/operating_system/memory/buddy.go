@@ -0,0 +1,366 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// buddyAllocation 伙伴系统中一个已分配块
+type buddyAllocation struct {
+	Order     int // 块的阶（大小 = MinBlockSize × 2^Order）
+	ProcessID int // 占用进程ID
+	Requested int // 进程实际请求的大小
+}
+
+// BuddyAllocator 二进制伙伴系统
+// 408考点：内存块大小均为 2 的幂，分配时逐级对半分裂，释放时与"伙伴"合并；
+// 伙伴地址 = 块地址 XOR 块大小；外部碎片少，但存在内部碎片
+type BuddyAllocator struct {
+	TotalSize    int                     // 总内存大小（MinBlockSize × 2^MaxOrder）
+	MinBlockSize int                     // 最小块大小（0 阶）
+	MaxOrder     int                     // 最高阶（整个内存为一块）
+	FreeLists    [][]int                 // 每阶一个空闲链表，存放空闲块起始地址（升序）
+	Allocated    map[int]buddyAllocation // 已分配块：起始地址 -> 分配信息
+	Splits       int                     // 分裂次数
+	Merges       int                     // 合并次数
+	Failed       int                     // 分配失败次数
+}
+
+// NewBuddyAllocator 创建伙伴系统，totalSize 向下取整为 minBlockSize 的 2 的幂倍
+func NewBuddyAllocator(totalSize, minBlockSize int) *BuddyAllocator {
+	if minBlockSize <= 0 {
+		minBlockSize = 1
+	}
+	maxOrder := 0
+	for minBlockSize<<(maxOrder+1) <= totalSize {
+		maxOrder++
+	}
+	ba := &BuddyAllocator{
+		TotalSize:    minBlockSize << maxOrder,
+		MinBlockSize: minBlockSize,
+		MaxOrder:     maxOrder,
+		FreeLists:    make([][]int, maxOrder+1),
+		Allocated:    make(map[int]buddyAllocation),
+	}
+	ba.FreeLists[maxOrder] = []int{0}
+	return ba
+}
+
+// blockSize 第 order 阶块的大小
+func (ba *BuddyAllocator) blockSize(order int) int {
+	return ba.MinBlockSize << order
+}
+
+// orderFor 容纳 size 字节所需的最小阶，超出总大小时返回 -1
+func (ba *BuddyAllocator) orderFor(size int) int {
+	for order := 0; order <= ba.MaxOrder; order++ {
+		if ba.blockSize(order) >= size {
+			return order
+		}
+	}
+	return -1
+}
+
+// Allocate 为进程分配 size 字节：找到不小于所需阶的最小空闲块，逐级对半分裂
+func (ba *BuddyAllocator) Allocate(processID, size int) bool {
+	need := ba.orderFor(size)
+	if size <= 0 || need < 0 {
+		ba.Failed++
+		return false
+	}
+
+	order := need
+	for order <= ba.MaxOrder && len(ba.FreeLists[order]) == 0 {
+		order++
+	}
+	if order > ba.MaxOrder {
+		ba.Failed++
+		return false
+	}
+
+	start := ba.popFree(order)
+	for order > need {
+		// 分裂：低半块继续使用，高半块（伙伴）放入低一阶的空闲链表
+		order--
+		ba.pushFree(order, start+ba.blockSize(order))
+		ba.Splits++
+	}
+	ba.Allocated[start] = buddyAllocation{Order: need, ProcessID: processID, Requested: size}
+	return true
+}
+
+// Free 释放进程占用的全部块，并与空闲的伙伴逐级合并
+func (ba *BuddyAllocator) Free(processID int) bool {
+	var starts []int
+	for start, a := range ba.Allocated {
+		if a.ProcessID == processID {
+			starts = append(starts, start)
+		}
+	}
+	sort.Ints(starts)
+	for _, start := range starts {
+		ba.freeBlock(start)
+	}
+	return len(starts) > 0
+}
+
+// freeBlock 释放一个块并合并伙伴
+func (ba *BuddyAllocator) freeBlock(start int) {
+	order := ba.Allocated[start].Order
+	delete(ba.Allocated, start)
+
+	for order < ba.MaxOrder {
+		buddy := start ^ ba.blockSize(order)
+		if !ba.removeFree(order, buddy) {
+			break
+		}
+		if buddy < start {
+			start = buddy
+		}
+		order++
+		ba.Merges++
+	}
+	ba.pushFree(order, start)
+}
+
+// pushFree 把空闲块按地址顺序插入链表
+func (ba *BuddyAllocator) pushFree(order, start int) {
+	list := ba.FreeLists[order]
+	i := sort.SearchInts(list, start)
+	list = append(list, 0)
+	copy(list[i+1:], list[i:])
+	list[i] = start
+	ba.FreeLists[order] = list
+}
+
+// popFree 取出链表中地址最低的空闲块
+func (ba *BuddyAllocator) popFree(order int) int {
+	start := ba.FreeLists[order][0]
+	ba.FreeLists[order] = ba.FreeLists[order][1:]
+	return start
+}
+
+// removeFree 从链表中删除指定块，不存在时返回 false
+func (ba *BuddyAllocator) removeFree(order, start int) bool {
+	list := ba.FreeLists[order]
+	i := sort.SearchInts(list, start)
+	if i == len(list) || list[i] != start {
+		return false
+	}
+	ba.FreeLists[order] = append(list[:i], list[i+1:]...)
+	return true
+}
+
+// GetFreeMemory 获取空闲内存总量
+func (ba *BuddyAllocator) GetFreeMemory() int {
+	total := 0
+	for order, list := range ba.FreeLists {
+		total += len(list) * ba.blockSize(order)
+	}
+	return total
+}
+
+// GetUsedMemory 获取已分配块的总大小（含内部碎片）
+func (ba *BuddyAllocator) GetUsedMemory() int {
+	return ba.TotalSize - ba.GetFreeMemory()
+}
+
+// GetFragmentation 获取碎片数（空闲块数量）
+func (ba *BuddyAllocator) GetFragmentation() int {
+	count := 0
+	for _, list := range ba.FreeLists {
+		count += len(list)
+	}
+	return count
+}
+
+// LargestFree 最大空闲块的大小
+func (ba *BuddyAllocator) LargestFree() int {
+	for order := ba.MaxOrder; order >= 0; order-- {
+		if len(ba.FreeLists[order]) > 0 {
+			return ba.blockSize(order)
+		}
+	}
+	return 0
+}
+
+// InternalFragmentation 内部碎片：已分配块大小与实际请求大小之差的总和
+func (ba *BuddyAllocator) InternalFragmentation() int {
+	total := 0
+	for _, a := range ba.Allocated {
+		total += ba.blockSize(a.Order) - a.Requested
+	}
+	return total
+}
+
+// PrintFreeLists 打印各阶空闲链表
+func (ba *BuddyAllocator) PrintFreeLists() {
+	fmt.Println("空闲链表:")
+	for order := ba.MaxOrder; order >= 0; order-- {
+		addrs := make([]string, len(ba.FreeLists[order]))
+		for i, start := range ba.FreeLists[order] {
+			addrs[i] = fmt.Sprintf("%d", start)
+		}
+		list := "空"
+		if len(addrs) > 0 {
+			list = strings.Join(addrs, " → ")
+		}
+		fmt.Printf("  阶%-2d (%5d): %s\n", order, ba.blockSize(order), list)
+	}
+}
+
+// PrintTree 以树形打印伙伴系统的分裂情况
+func (ba *BuddyAllocator) PrintTree() {
+	fmt.Printf("伙伴树 (总大小: %d, 已用: %d, 空闲: %d, 内部碎片: %d):\n",
+		ba.TotalSize, ba.GetUsedMemory(), ba.GetFreeMemory(), ba.InternalFragmentation())
+	ba.printNode(0, ba.MaxOrder, "", "")
+}
+
+// printNode 打印以 (start, order) 为根的子树
+func (ba *BuddyAllocator) printNode(start, order int, prefix, childPrefix string) {
+	size := ba.blockSize(order)
+	label := fmt.Sprintf("[%d, %d)", start, start+size)
+	if a, ok := ba.Allocated[start]; ok && a.Order == order {
+		fmt.Printf("%s%s P%d (请求 %d，内部碎片 %d)\n", prefix, label, a.ProcessID, a.Requested, size-a.Requested)
+		return
+	}
+	if i := sort.SearchInts(ba.FreeLists[order], start); i < len(ba.FreeLists[order]) && ba.FreeLists[order][i] == start {
+		fmt.Printf("%s%s 空闲\n", prefix, label)
+		return
+	}
+	fmt.Printf("%s%s\n", prefix, label)
+	half := size / 2
+	ba.printNode(start, order-1, childPrefix+"├─ ", childPrefix+"│  ")
+	ba.printNode(start+half, order-1, childPrefix+"└─ ", childPrefix+"   ")
+}
+
+// Print 按地址顺序打印内存状态
+func (ba *BuddyAllocator) Print() {
+	type region struct {
+		start, size int
+		owner       string
+	}
+	var regions []region
+	for order, list := range ba.FreeLists {
+		for _, start := range list {
+			regions = append(regions, region{start, ba.blockSize(order), "-"})
+		}
+	}
+	for start, a := range ba.Allocated {
+		regions = append(regions, region{start, ba.blockSize(a.Order), fmt.Sprintf("P%d", a.ProcessID)})
+	}
+	sort.Slice(regions, func(i, j int) bool { return regions[i].start < regions[j].start })
+
+	fmt.Printf("内存状态 (总大小: %d, 已用: %d, 空闲: %d, 碎片数: %d):\n",
+		ba.TotalSize, ba.GetUsedMemory(), ba.GetFreeMemory(), ba.GetFragmentation())
+	fmt.Println("地址    大小    状态      进程")
+	fmt.Println("-" + "------" + "------" + "--------" + "------")
+	for _, r := range regions {
+		status := "空闲"
+		if r.owner != "-" {
+			status = "已分配"
+		}
+		fmt.Printf("%-7d %-7d %-9s %s\n", r.start, r.size, status, r.owner)
+	}
+	fmt.Println()
+}
+
+// AllocRequest 一次内存请求：Size > 0 为分配，Size == 0 为释放该进程的全部内存
+type AllocRequest struct {
+	ProcessID int
+	Size      int
+}
+
+// CompareWithBuddy 在同一请求序列上比较可变分区各策略与伙伴系统
+// 408考点：可变分区只有外部碎片，伙伴系统以内部碎片换取快速分配与合并
+func CompareWithBuddy(totalSize, minBlockSize int, requests []AllocRequest) {
+	header := padDisplay("策略", 10)
+	for i, title := range []string{"成功", "失败", "已用", "空闲", "空闲块", "最大空闲", "内部碎片"} {
+		header += padDisplayLeft(title, []int{9, 9, 11, 11, 11, 15, 13}[i])
+	}
+	fmt.Println(header)
+
+	for _, s := range placementStrategies {
+		mm := NewMemoryManager(totalSize)
+		ok, failed := 0, 0
+		for _, r := range requests {
+			if r.Size == 0 {
+				mm.Free(r.ProcessID)
+			} else if s.Allocate(mm, r.ProcessID, r.Size) {
+				ok++
+			} else {
+				failed++
+			}
+		}
+		fmt.Printf("%s %8d %8d %10d %10d %10d %14d %12d\n", padDisplay(s.Name, 10),
+			ok, failed, mm.GetUsedMemory(), mm.GetFreeMemory(), mm.GetFragmentation(), mm.LargestFree(), 0)
+	}
+
+	ba := NewBuddyAllocator(totalSize, minBlockSize)
+	ok := 0
+	for _, r := range requests {
+		if r.Size == 0 {
+			ba.Free(r.ProcessID)
+		} else if ba.Allocate(r.ProcessID, r.Size) {
+			ok++
+		}
+	}
+	fmt.Printf("%s %8d %8d %10d %10d %10d %14d %12d\n", padDisplay("伙伴系统", 10),
+		ok, ba.Failed, ba.GetUsedMemory(), ba.GetFreeMemory(), ba.GetFragmentation(), ba.LargestFree(), ba.InternalFragmentation())
+}
+
+// BuddySystemExample 伙伴系统示例
+func BuddySystemExample() {
+	fmt.Println("\n╔═══════════════════════════════════════════════════════════╗")
+	fmt.Println("║           操作系统 - 伙伴系统 (Buddy System)              ║")
+	fmt.Println("╚═══════════════════════════════════════════════════════════╝")
+
+	// ============ 示例1: 分裂与合并 ============
+	fmt.Println("\n【示例1】1024 字节内存，最小块 64 字节")
+	fmt.Println("─────────────────────────────────────────────────")
+	ba := NewBuddyAllocator(1024, 64)
+
+	steps := []AllocRequest{{1, 70}, {2, 35}, {3, 80}, {1, 0}, {4, 60}, {2, 0}, {4, 0}, {3, 0}}
+	for _, r := range steps {
+		if r.Size == 0 {
+			merges := ba.Merges
+			ba.Free(r.ProcessID)
+			fmt.Printf("\n释放 P%d（合并 %d 次）:\n", r.ProcessID, ba.Merges-merges)
+		} else {
+			splits := ba.Splits
+			ok := ba.Allocate(r.ProcessID, r.Size)
+			fmt.Printf("\nP%d 请求 %d 字节 -> 块大小 %d（分裂 %d 次，成功: %t）:\n",
+				r.ProcessID, r.Size, ba.blockSize(ba.orderFor(r.Size)), ba.Splits-splits, ok)
+		}
+		ba.PrintTree()
+		if r.ProcessID == 4 && r.Size > 0 {
+			ba.PrintFreeLists()
+		}
+	}
+
+	// ============ 示例2: 与可变分区比较 ============
+	fmt.Println("\n【示例2】同一请求序列下与可变分区策略比较（内存 1024，最小块 32）")
+	fmt.Println("─────────────────────────────────────────────────")
+	requests := []AllocRequest{
+		{1, 100}, {2, 200}, {3, 60}, {4, 130}, {2, 0}, {5, 90},
+		{6, 250}, {3, 0}, {7, 40}, {8, 120}, {1, 0}, {9, 300}, {10, 70},
+	}
+	var desc []string
+	for _, r := range requests {
+		if r.Size == 0 {
+			desc = append(desc, fmt.Sprintf("释放P%d", r.ProcessID))
+		} else {
+			desc = append(desc, fmt.Sprintf("P%d:%d", r.ProcessID, r.Size))
+		}
+	}
+	fmt.Println("请求序列:", strings.Join(desc, " "))
+	fmt.Println()
+	CompareWithBuddy(1024, 32, requests)
+
+	fmt.Println("\n408考点:")
+	fmt.Println("  • 伙伴系统的块大小为 2^k，请求向上取整到 2 的幂，产生内部碎片")
+	fmt.Println("  • 地址为 a、大小为 2^k 的块，其伙伴地址为 a XOR 2^k")
+	fmt.Println("  • 释放时只与伙伴合并，合并后继续检查更高一阶，外部碎片比可变分区少")
+	fmt.Println("  • Linux 内核用伙伴系统管理物理页框")
+}
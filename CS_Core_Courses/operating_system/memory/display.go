@@ -0,0 +1,34 @@
+package memory
+
+import "strings"
+
+// 表格输出用的对齐函数：按终端显示宽度补空格（汉字占两列）
+
+// displayWidth 终端显示宽度（汉字占两列）
+func displayWidth(s string) int {
+	w := 0
+	for _, r := range s {
+		if r >= 0x1100 {
+			w += 2
+		} else {
+			w++
+		}
+	}
+	return w
+}
+
+// padDisplay 按显示宽度在右侧补空格
+func padDisplay(s string, width int) string {
+	if w := displayWidth(s); w < width {
+		return s + strings.Repeat(" ", width-w)
+	}
+	return s
+}
+
+// padDisplayLeft 按显示宽度在左侧补空格（右对齐）
+func padDisplayLeft(s string, width int) string {
+	if w := displayWidth(s); w < width {
+		return strings.Repeat(" ", width-w) + s
+	}
+	return s
+}
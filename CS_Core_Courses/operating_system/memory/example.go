@@ -1,6 +1,28 @@
 package memory
 
-import "fmt"
+import (
+	"fmt"
+
+	"CS_Core_Courses/registry"
+)
+
+func init() {
+	registry.Register(&registry.Module{
+		Name:    "os/memory",
+		Section: "2.2",
+		Title:   "内存管理",
+		Run:     RunAllMemoryMgmtExamples,
+		Examples: []registry.Example{
+			{Name: "memory", Run: MemoryExample},
+			{Name: "workload", Run: WorkloadExample},
+			{Name: "buddy", Run: BuddySystemExample},
+			{Name: "slab", Run: SlabExample},
+			{Name: "paging", Run: PagingExample},
+			{Name: "page_tables", Run: PageTableOrganizationExample},
+			{Name: "segmentation", Run: SegmentationExample},
+		},
+	})
+}
 
 // RunAllMemoryMgmtExamples 运行所有内存管理相关的示例
 func RunAllMemoryMgmtExamples() {
@@ -12,13 +34,25 @@ func RunAllMemoryMgmtExamples() {
 	// 运行基础内存管理示例（从memory.go）
 	MemoryExample()
 
+	// 运行动态分区分配负载对比示例
+	WorkloadExample()
+
+	// 运行伙伴系统示例
+	BuddySystemExample()
+
+	// 运行 slab 分配器示例
+	SlabExample()
+
 	// 运行分页机制示例
 	PagingExample()
 
+	// 运行反置页表与哈希页表示例
+	PageTableOrganizationExample()
+
 	// 运行分段机制示例
 	SegmentationExample()
 
 	fmt.Println("\n╔══════════════════════════════════════════════════════════╗")
 	fmt.Println("║                    模块运行完毕                           ║")
-	fmt.Println("╚══════════════════════════════════════════════════════════╝\n")
+	fmt.Print("╚══════════════════════════════════════════════════════════╝\n\n")
 }
@@ -0,0 +1,322 @@
+package memory
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// HashedEntry 哈希页表的链表元素
+type HashedEntry struct {
+	PID   int          // 进程号
+	VPN   int          // 虚拟页号
+	Frame int          // 物理帧号
+	Next  *HashedEntry // 同一桶中的下一个元素
+}
+
+// HashedPageTable 哈希页表
+// 408考点：地址空间很大（如 64 位）时，以虚拟页号的哈希值为索引，
+// 每个桶挂一条 (页号, 帧号) 链表，表的大小只与实际映射的页数有关
+type HashedPageTable struct {
+	PageSize int            // 页面大小
+	Buckets  []*HashedEntry // 哈希桶
+	Count    int            // 已映射的页数
+	Lookups  int            // 查找次数
+	Probes   int            // 查找时比较的元素总数
+}
+
+// NewHashedPageTable 创建哈希页表
+func NewHashedPageTable(pageSize, numBuckets int) *HashedPageTable {
+	if numBuckets <= 0 {
+		numBuckets = 16
+	}
+	return &HashedPageTable{
+		PageSize: pageSize,
+		Buckets:  make([]*HashedEntry, numBuckets),
+	}
+}
+
+// Map 建立 (进程号, 页号) -> 帧号 的映射，已存在时更新帧号
+func (hpt *HashedPageTable) Map(pid, vpn, frame int) {
+	bucket := hashPage(pid, vpn, len(hpt.Buckets))
+	for e := hpt.Buckets[bucket]; e != nil; e = e.Next {
+		if e.PID == pid && e.VPN == vpn {
+			e.Frame = frame
+			return
+		}
+	}
+	hpt.Buckets[bucket] = &HashedEntry{PID: pid, VPN: vpn, Frame: frame, Next: hpt.Buckets[bucket]}
+	hpt.Count++
+}
+
+// Lookup 查找 (进程号, 页号) 对应的帧号
+func (hpt *HashedPageTable) Lookup(pid, vpn int) (frame, probes int, ok bool) {
+	hpt.Lookups++
+	for e := hpt.Buckets[hashPage(pid, vpn, len(hpt.Buckets))]; e != nil; e = e.Next {
+		probes++
+		if e.PID == pid && e.VPN == vpn {
+			hpt.Probes += probes
+			return e.Frame, probes, true
+		}
+	}
+	hpt.Probes += probes
+	return -1, probes, false
+}
+
+// Translate 把进程的逻辑地址转换为物理地址
+func (hpt *HashedPageTable) Translate(pid, logicalAddr int) (int, bool) {
+	frame, _, ok := hpt.Lookup(pid, logicalAddr/hpt.PageSize)
+	if !ok {
+		return -1, false // 缺页
+	}
+	return frame*hpt.PageSize + logicalAddr%hpt.PageSize, true
+}
+
+// Unmap 删除映射
+func (hpt *HashedPageTable) Unmap(pid, vpn int) bool {
+	bucket := hashPage(pid, vpn, len(hpt.Buckets))
+	for p := &hpt.Buckets[bucket]; *p != nil; p = &(*p).Next {
+		if (*p).PID == pid && (*p).VPN == vpn {
+			*p = (*p).Next
+			hpt.Count--
+			return true
+		}
+	}
+	return false
+}
+
+// FreeProcess 删除进程的全部映射，返回删除的页数
+func (hpt *HashedPageTable) FreeProcess(pid int) int {
+	freed := 0
+	for i := range hpt.Buckets {
+		for p := &hpt.Buckets[i]; *p != nil; {
+			if (*p).PID == pid {
+				*p = (*p).Next
+				freed++
+				continue
+			}
+			p = &(*p).Next
+		}
+	}
+	hpt.Count -= freed
+	return freed
+}
+
+// LoadFactor 装填因子（映射页数 / 桶数）
+func (hpt *HashedPageTable) LoadFactor() float64 {
+	return float64(hpt.Count) / float64(len(hpt.Buckets))
+}
+
+// LongestChain 最长冲突链的长度
+func (hpt *HashedPageTable) LongestChain() int {
+	longest := 0
+	for _, head := range hpt.Buckets {
+		n := 0
+		for e := head; e != nil; e = e.Next {
+			n++
+		}
+		if n > longest {
+			longest = n
+		}
+	}
+	return longest
+}
+
+// AverageProbes 平均每次查找比较的元素数
+func (hpt *HashedPageTable) AverageProbes() float64 {
+	if hpt.Lookups == 0 {
+		return 0
+	}
+	return float64(hpt.Probes) / float64(hpt.Lookups)
+}
+
+// Print 打印各桶的链表
+func (hpt *HashedPageTable) Print() {
+	fmt.Printf("哈希页表 (%d 桶，%d 个映射，装填因子 %.2f，最长链 %d):\n",
+		len(hpt.Buckets), hpt.Count, hpt.LoadFactor(), hpt.LongestChain())
+	for i, head := range hpt.Buckets {
+		if head == nil {
+			continue
+		}
+		var chain []string
+		for e := head; e != nil; e = e.Next {
+			chain = append(chain, fmt.Sprintf("(P%d, 0x%X → F%d)", e.PID, e.VPN, e.Frame))
+		}
+		fmt.Printf("  [%d] %s\n", i, strings.Join(chain, " → "))
+	}
+	fmt.Println()
+}
+
+// OverheadConfig 页表开销对比的系统参数
+type OverheadConfig struct {
+	VirtualAddressBits int // 虚拟地址位数
+	PhysicalMemory     int // 物理内存大小（字节）
+	PageSize           int // 页面大小（字节）
+	PTESize            int // 普通页表项大小（字节）
+	Processes          int // 进程数
+	ResidentPages      int // 每个进程实际使用的页数（假设连续）
+}
+
+// TableOverhead 一种页表组织方式的内存开销
+type TableOverhead struct {
+	Name  string
+	Bytes float64 // 全部进程的页表总字节数
+	Note  string
+}
+
+// PageTableOverhead 计算给定地址空间下各种页表组织方式的内存开销
+// 408考点：单级页表大小 = 页数 × 页表项大小；多级页表只为用到的部分建二级页表；
+// 反置页表大小只与物理帧数有关，与进程数和虚拟地址空间无关
+func PageTableOverhead(cfg OverheadConfig) []TableOverhead {
+	offsetBits := bits.Len(uint(cfg.PageSize)) - 1
+	vpnBits := cfg.VirtualAddressBits - offsetBits
+	frames := cfg.PhysicalMemory / cfg.PageSize
+	frameBits := bits.Len(uint(frames - 1))
+	processes := float64(cfg.Processes)
+
+	// 单级页表（PageTable / PagingSystem）：每个进程覆盖整个虚拟地址空间
+	single := pow2(vpnBits) * float64(cfg.PTESize)
+
+	// 二级页表（MultiLevelPageTable）：二级页表占一页，页目录覆盖剩余的页号位
+	entriesPerTable := cfg.PageSize / cfg.PTESize
+	l2Bits := bits.Len(uint(entriesPerTable)) - 1
+	if l2Bits > vpnBits {
+		l2Bits = vpnBits
+	}
+	l2Tables := (cfg.ResidentPages + entriesPerTable - 1) / entriesPerTable
+	directory := pow2(vpnBits-l2Bits) * float64(cfg.PTESize)
+	twoLevel := directory + float64(l2Tables*cfg.PageSize)
+
+	// 反置页表：每帧一项（PID 16 位 + 页号 + 链指针 + 有效位），加上哈希锚表
+	invertedEntry := bytesFor(16 + vpnBits + frameBits + 1)
+	anchorEntry := bytesFor(frameBits + 1)
+	inverted := float64(frames*invertedEntry + frames*anchorEntry)
+
+	// 哈希页表：每个映射一个元素（PID 16 位 + 页号 + 帧号 + 有效位 + 32 位指针），桶数取映射数
+	mapped := cfg.Processes * cfg.ResidentPages
+	hashedEntry := bytesFor(16 + vpnBits + frameBits + 1 + 32)
+	hashed := float64(mapped*hashedEntry + mapped*4)
+
+	return []TableOverhead{
+		{"单级页表 (PageTable)", single * processes,
+			fmt.Sprintf("每进程 2^%d 项 × %dB", vpnBits, cfg.PTESize)},
+		{"二级页表 (MultiLevelPageTable)", twoLevel * processes,
+			fmt.Sprintf("每进程页目录 2^%d 项 + %d 个二级页表", vpnBits-l2Bits, l2Tables)},
+		{"反置页表 (InvertedPageTable)", inverted,
+			fmt.Sprintf("全系统 %d 项 × %dB + 锚表 %d × %dB", frames, invertedEntry, frames, anchorEntry)},
+		{"哈希页表 (HashedPageTable)", hashed,
+			fmt.Sprintf("%d 个映射 × %dB + 桶 %d × 4B", mapped, hashedEntry, mapped)},
+	}
+}
+
+// PrintPageTableOverhead 打印页表开销对比
+func PrintPageTableOverhead(cfg OverheadConfig) {
+	fmt.Printf("虚拟地址 %d 位，物理内存 %s，页面 %s，页表项 %dB，%d 个进程各用 %d 页\n",
+		cfg.VirtualAddressBits, formatBytes(float64(cfg.PhysicalMemory)), formatBytes(float64(cfg.PageSize)),
+		cfg.PTESize, cfg.Processes, cfg.ResidentPages)
+	for _, o := range PageTableOverhead(cfg) {
+		fmt.Printf("  %s %10s   %s\n", padDisplay(o.Name, 32), formatBytes(o.Bytes), o.Note)
+	}
+}
+
+// pow2 返回 2^n（浮点数，避免 64 位地址空间溢出）
+func pow2(n int) float64 {
+	v := 1.0
+	for i := 0; i < n; i++ {
+		v *= 2
+	}
+	return v
+}
+
+// bytesFor 存放 n 位所需的字节数
+func bytesFor(n int) int {
+	return (n + 7) / 8
+}
+
+// formatBytes 以 KB/MB/GB/TB/PB 显示字节数
+func formatBytes(b float64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB", "PB"}
+	i := 0
+	for b >= 1024 && i < len(units)-1 {
+		b /= 1024
+		i++
+	}
+	return strings.TrimSuffix(fmt.Sprintf("%.1f", b), ".0") + units[i]
+}
+
+// PageTableOrganizationExample 反置页表与哈希页表示例
+func PageTableOrganizationExample() {
+	fmt.Println("\n╔═══════════════════════════════════════════════════════════╗")
+	fmt.Println("║        操作系统 - 反置页表与哈希页表 (Page Tables)        ║")
+	fmt.Println("╚═══════════════════════════════════════════════════════════╝")
+
+	// ============ 示例1: 反置页表 ============
+	fmt.Println("\n【示例1】反置页表：按物理帧建表，(PID, 页号) 经哈希锚表查找")
+	fmt.Println("─────────────────────────────────────────────────")
+
+	ipt := NewInvertedPageTable(4096, 8, 4)
+	for _, m := range [][2]int{{1, 0}, {1, 1}, {2, 0}, {1, 5}, {2, 3}, {3, 0}, {2, 1}} {
+		frame, _ := ipt.Map(m[0], m[1])
+		fmt.Printf("  P%d 页 %d -> 帧 F%d\n", m[0], m[1], frame)
+	}
+	fmt.Println()
+	ipt.Print()
+
+	fmt.Println("地址转换:")
+	for _, q := range [][2]int{{1, 5*4096 + 100}, {2, 4096 + 8}, {3, 0x20}, {3, 2 * 4096}} {
+		vpn := q[1] / ipt.PageSize
+		if frame, probes, ok := ipt.Lookup(q[0], vpn); ok {
+			addr := frame*ipt.PageSize + q[1]%ipt.PageSize
+			fmt.Printf("  P%d 逻辑地址 %d (页 %d) -> 物理地址 %d，比较 %d 项\n", q[0], q[1], vpn, addr, probes)
+		} else {
+			fmt.Printf("  P%d 逻辑地址 %d (页 %d) -> 缺页，比较 %d 项\n", q[0], q[1], vpn, probes)
+		}
+	}
+	fmt.Printf("\n进程 P2 结束，释放 %d 帧\n", ipt.FreeProcess(2))
+	frame, _ := ipt.Map(3, 7)
+	fmt.Printf("P3 页 7 -> 帧 F%d（复用释放的帧）\n\n", frame)
+	ipt.Print()
+
+	// ============ 示例2: 哈希页表 ============
+	fmt.Println("\n【示例2】哈希页表：64 位地址空间中的稀疏映射")
+	fmt.Println("─────────────────────────────────────────────────")
+
+	hpt := NewHashedPageTable(4096, 8)
+	vpns := []int{0x400, 0x401, 0x402, 0x7FFFFFFF0, 0x7FFFFFFFF, 0x10000}
+	frame = 0
+	for pid := 1; pid <= 2; pid++ {
+		for _, vpn := range vpns {
+			hpt.Map(pid, vpn, frame)
+			frame++
+		}
+	}
+	hpt.Print()
+	for _, q := range [][2]int{{1, 0x7FFFFFFFF*4096 + 0x10}, {2, 0x401 * 4096}, {2, 0x500 * 4096}} {
+		if addr, ok := hpt.Translate(q[0], q[1]); ok {
+			fmt.Printf("  P%d 逻辑地址 0x%X -> 物理地址 0x%X\n", q[0], q[1], addr)
+		} else {
+			fmt.Printf("  P%d 逻辑地址 0x%X -> 缺页\n", q[0], q[1])
+		}
+	}
+	fmt.Printf("  平均比较次数: %.2f\n", hpt.AverageProbes())
+	fmt.Printf("\n进程 P1 结束，删除 %d 个映射，剩余 %d 个\n", hpt.FreeProcess(1), hpt.Count)
+
+	// ============ 示例3: 内存开销对比 ============
+	fmt.Println("\n【示例3】页表内存开销对比")
+	fmt.Println("─────────────────────────────────────────────────")
+	PrintPageTableOverhead(OverheadConfig{
+		VirtualAddressBits: 32, PhysicalMemory: 1 << 30, PageSize: 4096, PTESize: 4,
+		Processes: 10, ResidentPages: 1000,
+	})
+	fmt.Println()
+	PrintPageTableOverhead(OverheadConfig{
+		VirtualAddressBits: 48, PhysicalMemory: 16 << 30, PageSize: 4096, PTESize: 8,
+		Processes: 100, ResidentPages: 10000,
+	})
+
+	fmt.Println("\n408考点:")
+	fmt.Println("  • 反置页表每个物理帧一项，表的大小与物理内存成正比，与进程数和虚拟地址空间无关")
+	fmt.Println("  • 反置页表查找需要按 (PID, 页号) 搜索，通常借助哈希表；难以实现页面共享")
+	fmt.Println("  • 哈希页表适合大（64 位）且稀疏的地址空间，冲突的页号用链表链接")
+	fmt.Println("  • 单级页表大小 = 2^页号位数 × 页表项大小，地址空间越大越不可行")
+}
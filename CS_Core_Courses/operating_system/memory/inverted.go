@@ -0,0 +1,186 @@
+package memory
+
+import (
+	"fmt"
+	"strings"
+)
+
+// InvertedEntry 反置页表项（下标即物理帧号）
+type InvertedEntry struct {
+	PID   int  // 进程号
+	VPN   int  // 虚拟页号
+	Valid bool // 该帧是否已分配
+	Next  int  // 冲突链中下一项的帧号，-1 表示链尾
+}
+
+// InvertedPageTable 反置页表
+// 408考点：反置页表按物理帧建表，整个系统只有一张表，大小与物理内存成正比；
+// 查找时以 (进程号, 页号) 为键，经哈希锚表定位冲突链
+type InvertedPageTable struct {
+	PageSize   int             // 页面大小
+	NumFrames  int             // 物理帧数（即表项数）
+	Entries    []InvertedEntry // 反置页表
+	HashAnchor []int           // 哈希锚表：哈希值 -> 冲突链首项的帧号，-1 表示空
+	Lookups    int             // 查找次数
+	Probes     int             // 查找时比较的表项总数
+}
+
+// NewInvertedPageTable 创建反置页表，anchorSize 为哈希锚表项数（<=0 时与帧数相同）
+func NewInvertedPageTable(pageSize, numFrames, anchorSize int) *InvertedPageTable {
+	if anchorSize <= 0 {
+		anchorSize = numFrames
+	}
+	ipt := &InvertedPageTable{
+		PageSize:   pageSize,
+		NumFrames:  numFrames,
+		Entries:    make([]InvertedEntry, numFrames),
+		HashAnchor: make([]int, anchorSize),
+	}
+	for i := range ipt.Entries {
+		ipt.Entries[i].Next = -1
+	}
+	for i := range ipt.HashAnchor {
+		ipt.HashAnchor[i] = -1
+	}
+	return ipt
+}
+
+// hashPage 计算 (进程号, 页号) 的哈希值
+func hashPage(pid, vpn, size int) int {
+	h := uint64(pid)<<40 ^ uint64(vpn)
+	h ^= h >> 33
+	h *= 0xFF51AFD7ED558CCD
+	h ^= h >> 33
+	return int(h % uint64(size))
+}
+
+// Map 为进程的虚拟页分配一个空闲帧并插入冲突链首，页面已映射时返回原帧号
+func (ipt *InvertedPageTable) Map(pid, vpn int) (int, bool) {
+	if frame, _, ok := ipt.find(pid, vpn); ok {
+		return frame, true
+	}
+	for frame := range ipt.Entries {
+		if ipt.Entries[frame].Valid {
+			continue
+		}
+		bucket := hashPage(pid, vpn, len(ipt.HashAnchor))
+		ipt.Entries[frame] = InvertedEntry{PID: pid, VPN: vpn, Valid: true, Next: ipt.HashAnchor[bucket]}
+		ipt.HashAnchor[bucket] = frame
+		return frame, true
+	}
+	return -1, false // 物理内存已满
+}
+
+// find 沿冲突链查找，返回帧号和比较次数
+func (ipt *InvertedPageTable) find(pid, vpn int) (frame, probes int, ok bool) {
+	for frame = ipt.HashAnchor[hashPage(pid, vpn, len(ipt.HashAnchor))]; frame != -1; frame = ipt.Entries[frame].Next {
+		probes++
+		if e := ipt.Entries[frame]; e.PID == pid && e.VPN == vpn {
+			return frame, probes, true
+		}
+	}
+	return -1, probes, false
+}
+
+// Lookup 查找 (进程号, 页号) 所在的帧
+func (ipt *InvertedPageTable) Lookup(pid, vpn int) (frame, probes int, ok bool) {
+	frame, probes, ok = ipt.find(pid, vpn)
+	ipt.Lookups++
+	ipt.Probes += probes
+	return frame, probes, ok
+}
+
+// Translate 把进程的逻辑地址转换为物理地址
+func (ipt *InvertedPageTable) Translate(pid, logicalAddr int) (int, bool) {
+	frame, _, ok := ipt.Lookup(pid, logicalAddr/ipt.PageSize)
+	if !ok {
+		return -1, false // 缺页
+	}
+	return frame*ipt.PageSize + logicalAddr%ipt.PageSize, true
+}
+
+// Unmap 解除映射，把表项从冲突链中摘下并释放帧
+func (ipt *InvertedPageTable) Unmap(pid, vpn int) bool {
+	bucket := hashPage(pid, vpn, len(ipt.HashAnchor))
+	prev := -1
+	for frame := ipt.HashAnchor[bucket]; frame != -1; frame = ipt.Entries[frame].Next {
+		if e := ipt.Entries[frame]; e.PID == pid && e.VPN == vpn {
+			if prev == -1 {
+				ipt.HashAnchor[bucket] = e.Next
+			} else {
+				ipt.Entries[prev].Next = e.Next
+			}
+			ipt.Entries[frame] = InvertedEntry{Next: -1}
+			return true
+		}
+		prev = frame
+	}
+	return false
+}
+
+// FreeProcess 进程结束时释放它占用的所有帧，返回释放的帧数
+// 反置页表没有按进程组织，需要扫描整张表
+func (ipt *InvertedPageTable) FreeProcess(pid int) int {
+	freed := 0
+	for frame := range ipt.Entries {
+		if e := ipt.Entries[frame]; e.Valid && e.PID == pid {
+			ipt.Unmap(pid, e.VPN)
+			freed++
+		}
+	}
+	return freed
+}
+
+// UsedFrames 已分配的帧数
+func (ipt *InvertedPageTable) UsedFrames() int {
+	used := 0
+	for _, e := range ipt.Entries {
+		if e.Valid {
+			used++
+		}
+	}
+	return used
+}
+
+// AverageProbes 平均每次查找比较的表项数
+func (ipt *InvertedPageTable) AverageProbes() float64 {
+	if ipt.Lookups == 0 {
+		return 0
+	}
+	return float64(ipt.Probes) / float64(ipt.Lookups)
+}
+
+// Print 打印反置页表和哈希锚表
+func (ipt *InvertedPageTable) Print() {
+	fmt.Printf("反置页表 (%d 帧，已用 %d):\n", ipt.NumFrames, ipt.UsedFrames())
+	fmt.Println("帧号    PID     页号    链指针")
+	fmt.Println("-" + strings.Repeat("-", 30))
+	for frame, e := range ipt.Entries {
+		if !e.Valid {
+			fmt.Printf("F%-6d %-7s %-7s %s\n", frame, "-", "-", "-")
+			continue
+		}
+		next := "-"
+		if e.Next != -1 {
+			next = fmt.Sprintf("F%d", e.Next)
+		}
+		fmt.Printf("F%-6d %-7d %-7d %s\n", frame, e.PID, e.VPN, next)
+	}
+
+	var chains []string
+	for bucket, head := range ipt.HashAnchor {
+		if head == -1 {
+			continue
+		}
+		chain := fmt.Sprintf("[%d]", bucket)
+		for frame := head; frame != -1; frame = ipt.Entries[frame].Next {
+			chain += fmt.Sprintf(" → F%d(P%d,%d)", frame, ipt.Entries[frame].PID, ipt.Entries[frame].VPN)
+		}
+		chains = append(chains, chain)
+	}
+	fmt.Printf("哈希锚表 (%d 项，非空 %d):\n", len(ipt.HashAnchor), len(chains))
+	for _, chain := range chains {
+		fmt.Println("  " + chain)
+	}
+	fmt.Println()
+}
@@ -14,8 +14,11 @@ type MemoryBlock struct {
 
 // MemoryManager 内存管理器
 type MemoryManager struct {
-	TotalSize int            // 总内存大小
-	Blocks    []*MemoryBlock // 内存块列表
+	TotalSize    int            // 总内存大小
+	Blocks       []*MemoryBlock // 内存块列表
+	NextFitStart int            // 循环首次适应的起始地址（上次分配结束处）
+	Coalesced    int            // 释放时与相邻空闲块合并的次数
+	CompactCount int            // Compact() 运行次数
 }
 
 // NewMemoryManager 创建内存管理器
@@ -80,6 +83,28 @@ func (mm *MemoryManager) WorstFit(processID, size int) bool {
 	return false
 }
 
+// NextFit 循环首次适应（邻近适应）算法
+// 408考点：从上次分配结束的位置开始查找，到末尾后回到开头，使空闲分区分布更均匀
+func (mm *MemoryManager) NextFit(processID, size int) bool {
+	// 找到上次分配结束处所在的块，从它开始循环查找
+	first := 0
+	for i, block := range mm.Blocks {
+		if block.Start+block.Size > mm.NextFitStart {
+			first = i
+			break
+		}
+	}
+	for k := 0; k < len(mm.Blocks); k++ {
+		i := (first + k) % len(mm.Blocks)
+		block := mm.Blocks[i]
+		if block.Free && block.Size >= size {
+			mm.NextFitStart = block.Start + size
+			return mm.allocateBlock(i, processID, size)
+		}
+	}
+	return false
+}
+
 // allocateBlock 分配内存块
 func (mm *MemoryManager) allocateBlock(idx, processID, size int) bool {
 	block := mm.Blocks[idx]
@@ -142,6 +167,7 @@ func (mm *MemoryManager) compact() {
 		if current.Free && next.Free {
 			// 合并
 			current.Size += next.Size
+			mm.Coalesced++
 		} else {
 			newBlocks = append(newBlocks, current)
 			current = next
@@ -151,6 +177,39 @@ func (mm *MemoryManager) compact() {
 	mm.Blocks = newBlocks
 }
 
+// Compact 紧凑：把所有已分配块按原顺序移到内存低端，空闲内存合并为高端的一整块
+// 408考点：紧凑消除外部碎片，但要移动进程并修改重定位寄存器，开销较大，只在必要时进行
+func (mm *MemoryManager) Compact() {
+	mm.CompactCount++
+	newBlocks := make([]*MemoryBlock, 0, len(mm.Blocks))
+	addr := 0
+	for _, block := range mm.Blocks {
+		if block.Free {
+			continue
+		}
+		block.Start = addr
+		addr += block.Size
+		newBlocks = append(newBlocks, block)
+	}
+	if addr < mm.TotalSize {
+		newBlocks = append(newBlocks, &MemoryBlock{Start: addr, Size: mm.TotalSize - addr, Free: true, ProcessID: -1})
+	}
+	mm.Blocks = newBlocks
+	mm.NextFitStart = addr
+}
+
+// AllocateOrCompact 按放置策略 allocate 分配；失败但空闲总量足够时先紧凑再重试
+func (mm *MemoryManager) AllocateOrCompact(allocate func(mm *MemoryManager, processID, size int) bool, processID, size int) bool {
+	if allocate(mm, processID, size) {
+		return true
+	}
+	if mm.GetFreeMemory() < size {
+		return false
+	}
+	mm.Compact()
+	return allocate(mm, processID, size)
+}
+
 // GetFreeMemory 获取空闲内存总量
 func (mm *MemoryManager) GetFreeMemory() int {
 	total := 0
@@ -178,6 +237,41 @@ func (mm *MemoryManager) GetFragmentation() int {
 	return count
 }
 
+// LargestFree 最大空闲块的大小
+func (mm *MemoryManager) LargestFree() int {
+	largest := 0
+	for _, block := range mm.Blocks {
+		if block.Free && block.Size > largest {
+			largest = block.Size
+		}
+	}
+	return largest
+}
+
+// ExternalFragmentation 外部碎片率：1 - 最大空闲块 / 空闲总量
+// 空闲内存全部连续时为 0，越接近 1 说明空闲内存越零散
+func (mm *MemoryManager) ExternalFragmentation() float64 {
+	free := mm.GetFreeMemory()
+	if free == 0 {
+		return 0
+	}
+	return 1 - float64(mm.LargestFree())/float64(free)
+}
+
+// placementStrategy 可变分区的放置策略
+type placementStrategy struct {
+	Name     string
+	Allocate func(mm *MemoryManager, processID, size int) bool
+}
+
+// placementStrategies MemoryManager 支持的放置策略
+var placementStrategies = []placementStrategy{
+	{"首次适应", (*MemoryManager).FirstFit},
+	{"循环首次适应", (*MemoryManager).NextFit},
+	{"最佳适应", (*MemoryManager).BestFit},
+	{"最差适应", (*MemoryManager).WorstFit},
+}
+
 // Print 打印内存状态
 func (mm *MemoryManager) Print() {
 	fmt.Printf("内存状态 (总大小: %d, 已用: %d, 空闲: %d, 碎片数: %d):\n",
@@ -343,6 +437,7 @@ func MemoryExample() {
 
 	fmt.Println("\n5. 内存分配算法比较:")
 	fmt.Println("  - 首次适应 (First Fit): 从头开始找第一个合适的块，速度快")
+	fmt.Println("  - 循环首次适应 (Next Fit): 从上次分配结束处开始找，空闲块分布更均匀")
 	fmt.Println("  - 最佳适应 (Best Fit): 找最小的合适块，减少大块碎片")
 	fmt.Println("  - 最差适应 (Worst Fit): 找最大的块，减少小碎片")
 	fmt.Println()
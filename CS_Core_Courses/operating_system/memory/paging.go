@@ -40,6 +40,26 @@ func (ps *PagingSystem) AllocateFrame() (int, bool) {
 	return -1, false // 无空闲帧
 }
 
+// FreeFrame 释放物理帧
+func (ps *PagingSystem) FreeFrame(frameNum int) bool {
+	if frameNum < 0 || frameNum >= ps.NumFrames || !ps.MemoryFrames[frameNum] {
+		return false
+	}
+	ps.MemoryFrames[frameNum] = false
+	return true
+}
+
+// FreeFrameCount 空闲帧数
+func (ps *PagingSystem) FreeFrameCount() int {
+	count := 0
+	for _, used := range ps.MemoryFrames {
+		if !used {
+			count++
+		}
+	}
+	return count
+}
+
 // LoadPage 加载页面到内存（模拟缺页处理）
 // 408考点：缺页中断处理过程
 func (ps *PagingSystem) LoadPage(pageNum int) bool {
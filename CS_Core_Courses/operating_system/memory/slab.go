@@ -0,0 +1,323 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Slab 一个 slab：占用一个物理帧，切分为若干大小相同的对象
+type Slab struct {
+	Frame int    // 所在物理帧号
+	InUse int    // 已分配的对象数
+	used  []bool // 各对象是否已分配
+}
+
+// free 是否没有已分配的对象
+func (s *Slab) free() bool { return s.InUse == 0 }
+
+// full 是否已分满
+func (s *Slab) full() bool { return s.InUse == len(s.used) }
+
+// SlabCache 对象缓存：管理同一大小对象的所有 slab
+// 满 slab、部分 slab、空 slab 分别放在三个链表中，分配时优先使用部分 slab
+type SlabCache struct {
+	Name           string  // 缓存名称，如 task_struct、inode
+	ObjectSize     int     // 对象大小（字节）
+	ObjectsPerSlab int     // 每个 slab 可容纳的对象数
+	Full           []*Slab // 满 slab
+	Partial        []*Slab // 部分使用的 slab
+	Empty          []*Slab // 空 slab
+	Allocs         int     // 分配次数
+	Frees          int     // 释放次数
+	Grows          int     // 新建 slab 次数
+	Shrinks        int     // 回收 slab 次数
+
+	allocator *SlabAllocator
+	slabs     map[int]*Slab // 帧号 -> slab
+}
+
+// SlabAllocator slab 分配器（内核对象分配）
+// 408考点扩展：内核频繁分配固定大小的小对象，slab 在页框之上按对象缓存，
+// 避免每次分配都切分页框，也减少内部碎片
+type SlabAllocator struct {
+	Paging   *PagingSystem // 底层页框分配
+	Caches   []*SlabCache  // 按创建顺序排列的缓存
+	Reclaims int           // 内存紧张时的回收次数
+}
+
+// NewSlabAllocator 在分页系统的页框之上创建 slab 分配器
+func NewSlabAllocator(ps *PagingSystem) *SlabAllocator {
+	return &SlabAllocator{Paging: ps}
+}
+
+// CreateCache 创建对象缓存
+func (sa *SlabAllocator) CreateCache(name string, objectSize int) (*SlabCache, error) {
+	if objectSize <= 0 || objectSize > sa.Paging.PageSize {
+		return nil, fmt.Errorf("对象大小 %d 无效（应在 1~%d 之间）", objectSize, sa.Paging.PageSize)
+	}
+	if sa.Cache(name) != nil {
+		return nil, fmt.Errorf("缓存 %q 已存在", name)
+	}
+	c := &SlabCache{
+		Name:           name,
+		ObjectSize:     objectSize,
+		ObjectsPerSlab: sa.Paging.PageSize / objectSize,
+		allocator:      sa,
+		slabs:          make(map[int]*Slab),
+	}
+	sa.Caches = append(sa.Caches, c)
+	return c, nil
+}
+
+// Cache 按名称查找缓存
+func (sa *SlabAllocator) Cache(name string) *SlabCache {
+	for _, c := range sa.Caches {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// Reclaim 内存紧张时回收所有缓存中的空 slab，返回归还的页框数
+func (sa *SlabAllocator) Reclaim() int {
+	sa.Reclaims++
+	freed := 0
+	for _, c := range sa.Caches {
+		freed += c.Shrink()
+	}
+	return freed
+}
+
+// Alloc 分配一个对象，返回其物理地址
+// 顺序：部分 slab → 空 slab → 申请新页框；页框用完时先回收各缓存的空 slab 再重试
+func (c *SlabCache) Alloc() (int, error) {
+	var slab *Slab
+	switch {
+	case len(c.Partial) > 0:
+		slab = c.Partial[0]
+	case len(c.Empty) > 0:
+		slab = c.Empty[0]
+		c.Empty = c.Empty[1:]
+		c.Partial = append(c.Partial, slab)
+	default:
+		var err error
+		if slab, err = c.grow(); err != nil {
+			return -1, err
+		}
+		c.Partial = append(c.Partial, slab)
+	}
+
+	index := 0
+	for slab.used[index] {
+		index++
+	}
+	slab.used[index] = true
+	slab.InUse++
+	c.Allocs++
+	if slab.full() {
+		c.Partial = removeSlab(c.Partial, slab)
+		c.Full = append(c.Full, slab)
+	}
+	return slab.Frame*c.allocator.Paging.PageSize + index*c.ObjectSize, nil
+}
+
+// grow 申请一个页框作为新 slab
+func (c *SlabCache) grow() (*Slab, error) {
+	frame, ok := c.allocator.Paging.AllocateFrame()
+	if !ok && c.allocator.Reclaim() > 0 {
+		frame, ok = c.allocator.Paging.AllocateFrame()
+	}
+	if !ok {
+		return nil, fmt.Errorf("缓存 %s: 没有空闲页框", c.Name)
+	}
+	slab := &Slab{Frame: frame, used: make([]bool, c.ObjectsPerSlab)}
+	c.slabs[frame] = slab
+	c.Grows++
+	return slab, nil
+}
+
+// Free 释放地址为 addr 的对象
+func (c *SlabCache) Free(addr int) error {
+	pageSize := c.allocator.Paging.PageSize
+	slab, ok := c.slabs[addr/pageSize]
+	offset := addr % pageSize
+	if !ok || offset%c.ObjectSize != 0 || offset/c.ObjectSize >= c.ObjectsPerSlab {
+		return fmt.Errorf("缓存 %s: 地址 %d 不是该缓存的对象", c.Name, addr)
+	}
+	index := offset / c.ObjectSize
+	if !slab.used[index] {
+		return fmt.Errorf("缓存 %s: 地址 %d 重复释放", c.Name, addr)
+	}
+
+	wasFull := slab.full()
+	slab.used[index] = false
+	slab.InUse--
+	c.Frees++
+	// 每个 slab 只放一个对象时，会从满直接变为空
+	if wasFull {
+		c.Full = removeSlab(c.Full, slab)
+	} else if slab.free() {
+		c.Partial = removeSlab(c.Partial, slab)
+	}
+	switch {
+	case slab.free():
+		c.Empty = append(c.Empty, slab)
+	case wasFull:
+		c.Partial = append(c.Partial, slab)
+	}
+	return nil
+}
+
+// Shrink 把空 slab 占用的页框归还给分页系统，返回归还的页框数
+func (c *SlabCache) Shrink() int {
+	for _, slab := range c.Empty {
+		c.allocator.Paging.FreeFrame(slab.Frame)
+		delete(c.slabs, slab.Frame)
+	}
+	n := len(c.Empty)
+	c.Empty = nil
+	c.Shrinks += n
+	return n
+}
+
+// ActiveObjects 已分配的对象数
+func (c *SlabCache) ActiveObjects() int {
+	n := 0
+	for _, slab := range c.slabs {
+		n += slab.InUse
+	}
+	return n
+}
+
+// WastePerSlab 每个 slab 末尾放不下一个对象的剩余字节（内部碎片）
+func (c *SlabCache) WastePerSlab() int {
+	return c.allocator.Paging.PageSize - c.ObjectsPerSlab*c.ObjectSize
+}
+
+// removeSlab 从链表中删除 slab
+func removeSlab(list []*Slab, slab *Slab) []*Slab {
+	for i, s := range list {
+		if s == slab {
+			return append(list[:i], list[i+1:]...)
+		}
+	}
+	return list
+}
+
+// PrintStatistics 打印各缓存统计（类似 /proc/slabinfo）
+func (sa *SlabAllocator) PrintStatistics() {
+	fmt.Printf("slab 统计 (页框 %d 个，空闲 %d，回收 %d 次):\n",
+		sa.Paging.NumFrames, sa.Paging.FreeFrameCount(), sa.Reclaims)
+	header := padDisplay("缓存", 13)
+	titles := []string{"对象大小", "每slab", "活动/总对象", "满", "部分", "空", "分配", "释放", "新建", "回收", "每slab浪费"}
+	for i, width := range []int{9, 8, 13, 4, 6, 4, 6, 6, 6, 6, 12} {
+		header += padDisplayLeft(titles[i], width)
+	}
+	fmt.Println(header)
+	for _, c := range sa.Caches {
+		total := len(c.slabs) * c.ObjectsPerSlab
+		fmt.Printf("%-13s %8d %7d %12s %3d %5d %3d %5d %5d %5d %5d %11d\n",
+			c.Name, c.ObjectSize, c.ObjectsPerSlab, fmt.Sprintf("%d/%d", c.ActiveObjects(), total),
+			len(c.Full), len(c.Partial), len(c.Empty), c.Allocs, c.Frees, c.Grows, c.Shrinks, c.WastePerSlab())
+	}
+}
+
+// PrintSlabs 打印缓存中每个 slab 的占用情况（■ 已分配，□ 空闲）
+func (c *SlabCache) PrintSlabs() {
+	frames := make([]int, 0, len(c.slabs))
+	for frame := range c.slabs {
+		frames = append(frames, frame)
+	}
+	sort.Ints(frames)
+	fmt.Printf("缓存 %s (对象 %dB，每 slab %d 个):\n", c.Name, c.ObjectSize, c.ObjectsPerSlab)
+	for _, frame := range frames {
+		slab := c.slabs[frame]
+		state := "部分"
+		if slab.full() {
+			state = "满"
+		} else if slab.free() {
+			state = "空"
+		}
+		bitmap := ""
+		for _, used := range slab.used {
+			if used {
+				bitmap += "■"
+			} else {
+				bitmap += "□"
+			}
+		}
+		fmt.Printf("  F%-3d %s %d/%d %s\n", frame, padDisplay(state, 4), slab.InUse, c.ObjectsPerSlab, bitmap)
+	}
+}
+
+// SlabExample slab 分配器示例
+func SlabExample() {
+	fmt.Println("\n╔═══════════════════════════════════════════════════════════╗")
+	fmt.Println("║          操作系统 - slab 分配器 (Slab Allocator)          ║")
+	fmt.Println("╚═══════════════════════════════════════════════════════════╝")
+
+	// 页大小 4KB，共 6 个物理帧，全部交给 slab 分配器
+	ps := NewPagingSystem(4096, 8, 6)
+	sa := NewSlabAllocator(ps)
+	tasks, _ := sa.CreateCache("task_struct", 1700)
+	inodes, _ := sa.CreateCache("inode", 600)
+	dentries, _ := sa.CreateCache("dentry", 192)
+
+	// ============ 示例1: 分配对象 ============
+	fmt.Println("\n【示例1】分配对象：优先使用部分 slab，没有时申请新页框")
+	fmt.Println("─────────────────────────────────────────────────")
+	var taskAddrs, inodeAddrs []int
+	for i := 0; i < 3; i++ {
+		addr, _ := tasks.Alloc()
+		taskAddrs = append(taskAddrs, addr)
+	}
+	for i := 0; i < 8; i++ {
+		addr, _ := inodes.Alloc()
+		inodeAddrs = append(inodeAddrs, addr)
+	}
+	dentry, _ := dentries.Alloc()
+	fmt.Printf("task_struct 对象地址: %v\n", taskAddrs)
+	fmt.Printf("inode 对象地址: %v\n", inodeAddrs)
+	fmt.Printf("dentry 对象地址: %d\n\n", dentry)
+	tasks.PrintSlabs()
+	inodes.PrintSlabs()
+	fmt.Println()
+	sa.PrintStatistics()
+
+	// ============ 示例2: 释放对象 ============
+	fmt.Println("\n【示例2】释放对象：满 → 部分 → 空")
+	fmt.Println("─────────────────────────────────────────────────")
+	for _, addr := range inodeAddrs[:6] {
+		inodes.Free(addr)
+	}
+	if err := inodes.Free(inodeAddrs[0]); err != nil {
+		fmt.Println("再次释放:", err)
+	}
+	if err := inodes.Free(inodeAddrs[6] + 1); err != nil {
+		fmt.Println("错误地址:", err)
+	}
+	inodes.PrintSlabs()
+	fmt.Println("空 slab 暂不归还页框，下次分配可直接复用")
+
+	// ============ 示例3: 内存紧张时回收 ============
+	fmt.Println("\n【示例3】页框用完时回收空 slab")
+	fmt.Println("─────────────────────────────────────────────────")
+	for i := 0; i < 6; i++ {
+		if _, err := tasks.Alloc(); err != nil {
+			fmt.Println("分配失败:", err)
+			break
+		}
+	}
+	fmt.Printf("task_struct 再分配后，空闲页框 %d 个，回收 %d 次\n\n", ps.FreeFrameCount(), sa.Reclaims)
+	tasks.PrintSlabs()
+	inodes.PrintSlabs()
+	fmt.Println()
+	sa.PrintStatistics()
+
+	fmt.Println("\n要点:")
+	fmt.Println("  • 每个对象缓存只管理一种大小的对象，对象在 slab 内紧密排列")
+	fmt.Println("  • 每个 slab 末尾放不下一个对象的空间是唯一的内部碎片")
+	fmt.Println("  • 空 slab 先保留以加速后续分配，内存紧张时再回收给页框分配器")
+	fmt.Println("  • Linux 内核中 slab 建立在伙伴系统之上，用于 task_struct、inode 等内核对象")
+}
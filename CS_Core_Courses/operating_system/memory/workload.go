@@ -0,0 +1,255 @@
+package memory
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// ParseWorkload 读取分配/释放请求序列
+// 每行一条请求："alloc <进程> <大小>"（或 "a"）、"free <进程>"（或 "f"）；# 开头为注释
+func ParseWorkload(r io.Reader) ([]AllocRequest, error) {
+	var requests []AllocRequest
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		var req AllocRequest
+		var err error
+		switch strings.ToLower(fields[0]) {
+		case "alloc", "a":
+			if len(fields) != 3 {
+				return nil, fmt.Errorf("第 %d 行: alloc 需要进程号和大小", line)
+			}
+			if req.ProcessID, err = strconv.Atoi(fields[1]); err == nil {
+				req.Size, err = strconv.Atoi(fields[2])
+			}
+			if err == nil && req.Size <= 0 {
+				err = fmt.Errorf("大小必须为正数")
+			}
+		case "free", "f":
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("第 %d 行: free 需要进程号", line)
+			}
+			req.ProcessID, err = strconv.Atoi(fields[1])
+		default:
+			return nil, fmt.Errorf("第 %d 行: 未知的请求 %q", line, fields[0])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 行: %v", line, err)
+		}
+		requests = append(requests, req)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// WorkloadConfig 随机请求序列的参数
+type WorkloadConfig struct {
+	Seed      int64   // 随机种子，相同种子生成相同序列
+	Requests  int     // 请求条数
+	MinSize   int     // 分配大小下限
+	MaxSize   int     // 分配大小上限
+	FreeRatio float64 // 释放请求所占比例（0~1）
+}
+
+// RandomWorkload 按种子生成分配/释放交替的请求序列，只释放仍存活的进程
+func RandomWorkload(cfg WorkloadConfig) []AllocRequest {
+	if cfg.MinSize <= 0 {
+		cfg.MinSize = 1
+	}
+	if cfg.MaxSize < cfg.MinSize {
+		cfg.MaxSize = cfg.MinSize
+	}
+	if cfg.FreeRatio <= 0 {
+		cfg.FreeRatio = 0.4
+	}
+
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	var requests []AllocRequest
+	var live []int
+	nextPID := 1
+	for len(requests) < cfg.Requests {
+		if len(live) > 0 && rng.Float64() < cfg.FreeRatio {
+			i := rng.Intn(len(live))
+			requests = append(requests, AllocRequest{ProcessID: live[i]})
+			live = append(live[:i], live[i+1:]...)
+			continue
+		}
+		size := cfg.MinSize + rng.Intn(cfg.MaxSize-cfg.MinSize+1)
+		requests = append(requests, AllocRequest{ProcessID: nextPID, Size: size})
+		live = append(live, nextPID)
+		nextPID++
+	}
+	return requests
+}
+
+// WorkloadResult 一种放置策略回放请求序列的结果
+type WorkloadResult struct {
+	Strategy      string
+	Allocated     int       // 成功分配次数
+	Failed        int       // 分配失败次数（紧凑后仍放不下）
+	CompactRuns   int       // 因外部碎片而不得不紧凑的次数
+	Coalesced     int       // 合并掉的空闲块数
+	Fragmentation []float64 // 每条请求之后的外部碎片率
+	FreeBlocks    []int     // 每条请求之后的空闲块数
+	Final         *MemoryManager
+}
+
+// AverageFragmentation 平均外部碎片率
+func (r WorkloadResult) AverageFragmentation() float64 {
+	if len(r.Fragmentation) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, f := range r.Fragmentation {
+		sum += f
+	}
+	return sum / float64(len(r.Fragmentation))
+}
+
+// PeakFragmentation 最大外部碎片率
+func (r WorkloadResult) PeakFragmentation() float64 {
+	peak := 0.0
+	for _, f := range r.Fragmentation {
+		if f > peak {
+			peak = f
+		}
+	}
+	return peak
+}
+
+// RunWorkload 在总大小为 totalSize 的内存上，用每种放置策略回放同一请求序列
+// 找不到足够大的空闲分区但空闲总量足够时，先紧凑再分配
+func RunWorkload(totalSize int, requests []AllocRequest) []WorkloadResult {
+	results := make([]WorkloadResult, 0, len(placementStrategies))
+	for _, s := range placementStrategies {
+		mm := NewMemoryManager(totalSize)
+		res := WorkloadResult{Strategy: s.Name, Final: mm}
+		for _, r := range requests {
+			if r.Size == 0 {
+				mm.Free(r.ProcessID)
+			} else if mm.AllocateOrCompact(s.Allocate, r.ProcessID, r.Size) {
+				res.Allocated++
+			} else {
+				res.Failed++
+			}
+			res.Fragmentation = append(res.Fragmentation, mm.ExternalFragmentation())
+			res.FreeBlocks = append(res.FreeBlocks, mm.GetFragmentation())
+		}
+		res.CompactRuns = mm.CompactCount
+		res.Coalesced = mm.Coalesced
+		results = append(results, res)
+	}
+	return results
+}
+
+// PrintWorkloadReport 打印碎片随时间的变化（约 samples 个采样点）和各策略汇总
+func PrintWorkloadReport(results []WorkloadResult, samples int) {
+	if len(results) == 0 || len(results[0].Fragmentation) == 0 {
+		fmt.Println("没有请求")
+		return
+	}
+	steps := len(results[0].Fragmentation)
+	if samples <= 0 || samples > steps {
+		samples = steps
+	}
+
+	fmt.Println("外部碎片率随时间变化（括号内为空闲块数）:")
+	header := padDisplay("请求", 8)
+	for _, r := range results {
+		header += padDisplayLeft(r.Strategy, 16)
+	}
+	fmt.Println(header)
+	for k := 1; k <= samples; k++ {
+		step := k * steps / samples
+		row := padDisplay(strconv.Itoa(step), 8)
+		for _, r := range results {
+			row += padDisplayLeft(fmt.Sprintf("%5.1f%% (%d)", r.Fragmentation[step-1]*100, r.FreeBlocks[step-1]), 16)
+		}
+		fmt.Println(row)
+	}
+
+	fmt.Println("\n汇总:")
+	header = padDisplay("策略", 14)
+	for i, title := range []string{"成功", "失败", "紧凑次数", "合并块数", "平均碎片", "峰值碎片", "最终空闲块"} {
+		header += padDisplayLeft(title, []int{7, 7, 10, 10, 10, 10, 12}[i])
+	}
+	fmt.Println(header)
+	for _, r := range results {
+		fmt.Printf("%s%7d%7d%10d%10d%9.1f%%%9.1f%%%12d\n", padDisplay(r.Strategy, 14),
+			r.Allocated, r.Failed, r.CompactRuns, r.Coalesced,
+			r.AverageFragmentation()*100, r.PeakFragmentation()*100, r.Final.GetFragmentation())
+	}
+}
+
+// WorkloadExample 循环首次适应与分配负载对比示例
+func WorkloadExample() {
+	fmt.Println("\n╔═══════════════════════════════════════════════════════════╗")
+	fmt.Println("║        操作系统 - 动态分区分配负载对比 (Workload)         ║")
+	fmt.Println("╚═══════════════════════════════════════════════════════════╝")
+
+	// ============ 示例1: 循环首次适应 ============
+	fmt.Println("\n【示例1】循环首次适应：从上次分配结束处继续查找")
+	fmt.Println("─────────────────────────────────────────────────")
+	mm := NewMemoryManager(1000)
+	mm.NextFit(1, 200)
+	mm.NextFit(2, 150)
+	mm.NextFit(3, 300)
+	mm.Free(1)
+	fmt.Println("释放进程1后:")
+	mm.Print()
+	fmt.Printf("分配进程4 (100)：从地址 %d 开始查找\n", mm.NextFitStart)
+	mm.NextFit(4, 100)
+	mm.Print()
+	fmt.Println("首次适应会把进程4放在地址 0，循环首次适应则放在上次分配结束处之后")
+
+	// ============ 示例2: 请求序列文件 ============
+	fmt.Println("\n【示例2】回放请求序列（内存 650）")
+	fmt.Println("─────────────────────────────────────────────────")
+	requests, err := ParseWorkload(strings.NewReader(`
+# 经典题目：先放入若干作业，再释放其中一部分，观察后续大作业能否装入
+alloc 1 130
+alloc 2 60
+alloc 3 100
+alloc 4 200
+free 2
+alloc 5 60
+free 3
+alloc 6 40
+free 1
+alloc 7 150
+alloc 8 220
+free 5
+alloc 9 180
+`))
+	if err != nil {
+		fmt.Println("错误:", err)
+		return
+	}
+	PrintWorkloadReport(RunWorkload(650, requests), 13)
+
+	// ============ 示例3: 随机负载 ============
+	fmt.Println("\n【示例3】随机负载（种子 408，400 条请求，大小 20~200，内存 4096）")
+	fmt.Println("─────────────────────────────────────────────────")
+	requests = RandomWorkload(WorkloadConfig{Seed: 408, Requests: 400, MinSize: 20, MaxSize: 200, FreeRatio: 0.45})
+	PrintWorkloadReport(RunWorkload(4096, requests), 8)
+
+	fmt.Println("\n408考点:")
+	fmt.Println("  • 首次适应：低地址端留下许多小碎片，但高地址端保留大空闲区")
+	fmt.Println("  • 循环首次适应：空闲分区分布均匀，但大空闲区容易被拆散")
+	fmt.Println("  • 最佳适应：留下大量难以利用的小碎片")
+	fmt.Println("  • 最差适应：大空闲区最先被用掉，后来的大作业可能无法装入")
+	fmt.Println("  • 外部碎片率 = 1 - 最大空闲块 / 空闲总量；释放时合并相邻空闲分区")
+	fmt.Println("  • 空闲总量够而没有足够大的分区时才需要紧凑，紧凑次数反映外部碎片的代价")
+}
@@ -0,0 +1,238 @@
+package process
+
+// HRRNScheduler 高响应比优先调度器（非抢占）
+// 408 考点：响应比 = (等待时间 + 要求服务时间) / 要求服务时间，
+// 兼顾短作业优先和先来先服务，长作业等待越久响应比越高，不会饥饿
+type HRRNScheduler struct {
+	queue []*ProcessControlBlock
+	now   int // 当前时间，由模拟器通过 Tick 更新
+}
+
+func NewHRRNScheduler() *HRRNScheduler {
+	return &HRRNScheduler{
+		queue: make([]*ProcessControlBlock, 0),
+	}
+}
+
+func (s *HRRNScheduler) AddProcess(process *ProcessControlBlock) {
+	s.queue = append(s.queue, process)
+}
+
+// Tick 更新当前时间
+func (s *HRRNScheduler) Tick(now int) {
+	s.now = now
+}
+
+// ResponseRatio 计算进程在当前时刻的响应比
+func (s *HRRNScheduler) ResponseRatio(process *ProcessControlBlock) float64 {
+	if process.BurstTime <= 0 {
+		return 0
+	}
+	wait := s.now - process.ArrivalTime
+	if wait < 0 {
+		wait = 0
+	}
+	return float64(wait+process.BurstTime) / float64(process.BurstTime)
+}
+
+func (s *HRRNScheduler) NextProcess() *ProcessControlBlock {
+	if len(s.queue) == 0 {
+		return nil
+	}
+
+	// 选择响应比最高的进程，相同时先到达者优先
+	best := 0
+	bestRatio := s.ResponseRatio(s.queue[0])
+	for i := 1; i < len(s.queue); i++ {
+		ratio := s.ResponseRatio(s.queue[i])
+		if ratio > bestRatio || (ratio == bestRatio && s.queue[i].ArrivalTime < s.queue[best].ArrivalTime) {
+			best = i
+			bestRatio = ratio
+		}
+	}
+
+	process := s.queue[best]
+	s.queue = append(s.queue[:best], s.queue[best+1:]...)
+	return process
+}
+
+func (s *HRRNScheduler) HasNext() bool {
+	return len(s.queue) > 0
+}
+
+func (s *HRRNScheduler) RemoveProcess(pid int) bool {
+	for i, proc := range s.queue {
+		if proc.PID == pid {
+			s.queue = append(s.queue[:i], s.queue[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// MLFQConfig 多级反馈队列配置
+type MLFQConfig struct {
+	Levels        int   // 队列级数（默认 3）
+	TimeSlices    []int // 各级时间片，为空时按 1, 2, 4, ... 逐级加倍
+	BoostInterval int   // 优先级提升周期，0 表示不提升
+	Preemptive    bool  // 高优先级队列有新进程时是否抢占低优先级进程
+}
+
+// MLFQScheduler 多级反馈队列调度器
+// 408 考点：
+//   - 新进程进入第 0 级（最高优先级）队列队尾
+//   - 只有高级队列为空时才调度低级队列
+//   - 时间片用完未结束的进程降到下一级队列，最低级队列内按 RR 轮转
+//   - 周期性提升优先级，防止低级队列中的长作业饥饿
+//
+// 被抢占的进程留在原级别队尾，下次调度时重新获得完整时间片
+type MLFQScheduler struct {
+	queues        [][]*ProcessControlBlock
+	timeSlices    []int
+	levels        map[int]int // PID -> 所在级别
+	boostInterval int
+	nextBoost     int
+	preemptive    bool
+}
+
+// NewMLFQScheduler 创建多级反馈队列调度器
+func NewMLFQScheduler(config MLFQConfig) *MLFQScheduler {
+	levels := config.Levels
+	if levels <= 0 {
+		levels = len(config.TimeSlices)
+	}
+	if levels <= 0 {
+		levels = 3
+	}
+
+	timeSlices := make([]int, levels)
+	for i := range timeSlices {
+		switch {
+		case i < len(config.TimeSlices) && config.TimeSlices[i] > 0:
+			timeSlices[i] = config.TimeSlices[i]
+		case i > 0:
+			timeSlices[i] = timeSlices[i-1] * 2
+		default:
+			timeSlices[i] = 1
+		}
+	}
+
+	nextBoost := -1
+	if config.BoostInterval > 0 {
+		nextBoost = config.BoostInterval
+	}
+
+	return &MLFQScheduler{
+		queues:        make([][]*ProcessControlBlock, levels),
+		timeSlices:    timeSlices,
+		levels:        make(map[int]int),
+		boostInterval: config.BoostInterval,
+		nextBoost:     nextBoost,
+		preemptive:    config.Preemptive,
+	}
+}
+
+// AddProcess 新进程进入最高级队列，已知进程回到其当前级别的队尾
+func (s *MLFQScheduler) AddProcess(process *ProcessControlBlock) {
+	level, known := s.levels[process.PID]
+	if !known {
+		level = 0
+		s.levels[process.PID] = 0
+	}
+	s.queues[level] = append(s.queues[level], process)
+}
+
+// NextProcess 从最高的非空队列取出队首进程
+func (s *MLFQScheduler) NextProcess() *ProcessControlBlock {
+	for level, queue := range s.queues {
+		if len(queue) > 0 {
+			process := queue[0]
+			s.queues[level] = queue[1:]
+			return process
+		}
+	}
+	return nil
+}
+
+func (s *MLFQScheduler) HasNext() bool {
+	for _, queue := range s.queues {
+		if len(queue) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MLFQScheduler) RemoveProcess(pid int) bool {
+	for level, queue := range s.queues {
+		for i, proc := range queue {
+			if proc.PID == pid {
+				s.queues[level] = append(queue[:i], queue[i+1:]...)
+				delete(s.levels, pid)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Level 返回进程当前所在的队列级别
+func (s *MLFQScheduler) Level(process *ProcessControlBlock) int {
+	return s.levels[process.PID]
+}
+
+// QuantumFor 返回进程所在级别的时间片
+func (s *MLFQScheduler) QuantumFor(process *ProcessControlBlock) int {
+	return s.timeSlices[s.levels[process.PID]]
+}
+
+// SliceExpired 时间片用完：降一级（最低级保持不变）后放入队尾
+func (s *MLFQScheduler) SliceExpired(process *ProcessControlBlock) {
+	level := s.levels[process.PID]
+	if level < len(s.queues)-1 {
+		level++
+	}
+	s.levels[process.PID] = level
+	s.queues[level] = append(s.queues[level], process)
+}
+
+// ShouldPreempt 存在比运行进程更高级别的就绪进程时抢占
+func (s *MLFQScheduler) ShouldPreempt(running *ProcessControlBlock) bool {
+	runningLevel := s.levels[running.PID]
+	for level := 0; level < runningLevel; level++ {
+		if len(s.queues[level]) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Preemptive 是否为抢占式
+func (s *MLFQScheduler) Preemptive() bool {
+	return s.preemptive
+}
+
+// NextBoost 返回下一次优先级提升的时刻
+func (s *MLFQScheduler) NextBoost() int {
+	return s.nextBoost
+}
+
+// Tick 到达提升时刻时把所有进程移回最高级队列（保持原有先后顺序）
+func (s *MLFQScheduler) Tick(now int) {
+	if s.boostInterval <= 0 || now < s.nextBoost {
+		return
+	}
+	for s.nextBoost <= now {
+		s.nextBoost += s.boostInterval
+	}
+
+	boosted := make([]*ProcessControlBlock, 0)
+	for level, queue := range s.queues {
+		boosted = append(boosted, queue...)
+		s.queues[level] = nil
+	}
+	s.queues[0] = boosted
+	for pid := range s.levels {
+		s.levels[pid] = 0
+	}
+}
@@ -1,7 +1,22 @@
 package process
 
+import "CS_Core_Courses/registry"
+
+func init() {
+	registry.Register(&registry.Module{
+		Name:    "os/process",
+		Section: "2.1",
+		Title:   "进程管理",
+		Run:     RunAllProcessExamples,
+		Examples: []registry.Example{
+			{Name: "process", Run: ProcessExample},
+			{Name: "scheduler", Run: SchedulerExample},
+		},
+	})
+}
+
 // RunAllProcessExamples 运行所有进程管理相关的示例
 func RunAllProcessExamples() {
 	ProcessExample()
 	SchedulerExample()
-}
\ No newline at end of file
+}
@@ -36,21 +36,22 @@ func (ps ProcessState) String() string {
 
 // ProcessControlBlock 进程控制块 (PCB)
 type ProcessControlBlock struct {
-	PID             int           // 进程ID
-	ParentPID       int           // 父进程ID
-	State           ProcessState  // 进程状态
-	Priority        int           // 优先级
-	BurstTime       int           // 需要的CPU时间
-	RemainingTime   int           // 剩余时间
-	ArrivalTime     int           // 到达时间
-	WaitTime        int           // 等待时间
-	TurnaroundTime  int           // 周转时间
-	ResponseTime    int           // 响应时间
-	ContextSwitches int           // 上下文切换次数
-	MemoryUsage     int           // 内存使用量
-	StartTime       time.Time     // 开始时间
-	EndTime         time.Time     // 结束时间
-	Children        []int         // 子进程PID列表
+	PID             int          // 进程ID
+	ParentPID       int          // 父进程ID
+	State           ProcessState // 进程状态
+	Priority        int          // 优先级
+	BurstTime       int          // 需要的CPU时间
+	RemainingTime   int          // 剩余时间
+	ArrivalTime     int          // 到达时间
+	WaitTime        int          // 等待时间
+	TurnaroundTime  int          // 周转时间
+	ResponseTime    int          // 响应时间
+	CompletionTime  int          // 完成时间（调度模拟中的时刻）
+	ContextSwitches int          // 上下文切换次数
+	MemoryUsage     int          // 内存使用量
+	StartTime       time.Time    // 开始时间
+	EndTime         time.Time    // 结束时间
+	Children        []int        // 子进程PID列表
 }
 
 // NewProcess 创建新进程
@@ -72,6 +73,17 @@ func NewProcess(pid, parentPid, priority, burstTime, arrivalTime int) *ProcessCo
 	}
 }
 
+// Reset 重置运行时统计，使进程回到刚创建时的状态
+func (pcb *ProcessControlBlock) Reset() {
+	pcb.State = StateNew
+	pcb.RemainingTime = pcb.BurstTime
+	pcb.WaitTime = 0
+	pcb.TurnaroundTime = 0
+	pcb.ResponseTime = -1
+	pcb.CompletionTime = 0
+	pcb.ContextSwitches = 0
+}
+
 // SetState 设置进程状态
 func (pcb *ProcessControlBlock) SetState(state ProcessState) {
 	pcb.State = state
@@ -266,10 +278,10 @@ func ProcessExample() {
 
 	// 创建进程
 	fmt.Println("创建进程:")
-	pid1 := pm.CreateProcess(0, 2, 5, 0)  // 父进程ID=0(无父进程), 优先级=2, 执行时间=5, 到达时间=0
-	pid2 := pm.CreateProcess(0, 1, 3, 1)  // 优先级=1, 执行时间=3, 到达时间=1
+	pid1 := pm.CreateProcess(0, 2, 5, 0)    // 父进程ID=0(无父进程), 优先级=2, 执行时间=5, 到达时间=0
+	pid2 := pm.CreateProcess(0, 1, 3, 1)    // 优先级=1, 执行时间=3, 到达时间=1
 	pid3 := pm.CreateProcess(pid1, 3, 8, 2) // pid1的子进程, 优先级=3, 执行时间=8, 到达时间=2
-	pid4 := pm.CreateProcess(0, 1, 6, 3)  // 优先级=1, 执行时间=6, 到达时间=3
+	pid4 := pm.CreateProcess(0, 1, 6, 3)    // 优先级=1, 执行时间=6, 到达时间=3
 
 	fmt.Printf("创建进程 PID: %d (主进程)\n", pid1)
 	fmt.Printf("创建进程 PID: %d (主进程)\n", pid2)
@@ -318,4 +330,4 @@ func ProcessExample() {
 	// 打印所有进程信息
 	pm.PrintAllProcesses()
 	fmt.Println()
-}
\ No newline at end of file
+}
@@ -0,0 +1,186 @@
+package process
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Segment 甘特图中的一段：某进程在 [Start, End) 内占用 CPU
+type Segment struct {
+	PID   int  // 进程ID（Idle 为 true 时无意义）
+	Start int  // 开始时刻
+	End   int  // 结束时刻
+	Idle  bool // CPU 空闲
+}
+
+// Label 返回甘特图中显示的名称
+func (seg Segment) Label() string {
+	if seg.Idle {
+		return "idle"
+	}
+	return fmt.Sprintf("P%d", seg.PID)
+}
+
+// ProcessMetrics 单个进程的调度指标
+// 408 考点：
+//   - 周转时间 = 完成时间 - 到达时间
+//   - 带权周转时间 = 周转时间 / 运行时间
+//   - 等待时间 = 周转时间 - 运行时间
+//   - 响应时间 = 首次运行时刻 - 到达时间
+type ProcessMetrics struct {
+	PID                int
+	ArrivalTime        int
+	BurstTime          int
+	Priority           int
+	CompletionTime     int
+	TurnaroundTime     int
+	WeightedTurnaround float64
+	WaitTime           int
+	ResponseTime       int
+}
+
+// SimulationResult 一次调度模拟的结果
+type SimulationResult struct {
+	Segments  []Segment        // 按时间顺序的执行片段
+	Processes []ProcessMetrics // 按输入顺序的进程指标
+	TotalTime int              // 全部进程完成的时刻
+}
+
+// AverageTurnaround 平均周转时间
+func (r *SimulationResult) AverageTurnaround() float64 {
+	return r.average(func(m ProcessMetrics) float64 { return float64(m.TurnaroundTime) })
+}
+
+// AverageWeightedTurnaround 平均带权周转时间
+func (r *SimulationResult) AverageWeightedTurnaround() float64 {
+	return r.average(func(m ProcessMetrics) float64 { return m.WeightedTurnaround })
+}
+
+// AverageWait 平均等待时间
+func (r *SimulationResult) AverageWait() float64 {
+	return r.average(func(m ProcessMetrics) float64 { return float64(m.WaitTime) })
+}
+
+// AverageResponse 平均响应时间
+func (r *SimulationResult) AverageResponse() float64 {
+	return r.average(func(m ProcessMetrics) float64 { return float64(m.ResponseTime) })
+}
+
+func (r *SimulationResult) average(value func(ProcessMetrics) float64) float64 {
+	if len(r.Processes) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, m := range r.Processes {
+		total += value(m)
+	}
+	return total / float64(len(r.Processes))
+}
+
+// CPUUtilization CPU 利用率（百分比）
+func (r *SimulationResult) CPUUtilization() float64 {
+	if r.TotalTime == 0 {
+		return 0
+	}
+	busy := 0
+	for _, seg := range r.Segments {
+		if !seg.Idle {
+			busy += seg.End - seg.Start
+		}
+	}
+	return float64(busy) / float64(r.TotalTime) * 100
+}
+
+// GanttChart 渲染 ASCII 甘特图，每个时间单位占两个字符宽度
+//
+//	| P1 |   P2    |  P4  |
+//	0    2         7      10
+func (r *SimulationResult) GanttChart() string {
+	if len(r.Segments) == 0 {
+		return "(无执行记录)\n"
+	}
+
+	var bar strings.Builder
+	axis := []byte("0")
+	bar.WriteString("|")
+	for _, seg := range r.Segments {
+		label := seg.Label()
+		endLabel := strconv.Itoa(seg.End)
+
+		width := (seg.End - seg.Start) * 2
+		if width < len(label)+2 {
+			width = len(label) + 2
+		}
+		if width < len(endLabel)+1 {
+			width = len(endLabel) + 1
+		}
+
+		left := (width - len(label)) / 2
+		bar.WriteString(strings.Repeat(" ", left))
+		bar.WriteString(label)
+		bar.WriteString(strings.Repeat(" ", width-left-len(label)))
+		bar.WriteString("|")
+
+		// 刻度写在竖线正下方
+		pos := bar.Len() - 1
+		for len(axis) < pos {
+			axis = append(axis, ' ')
+		}
+		axis = append(axis[:pos], endLabel...)
+	}
+
+	return bar.String() + "\n" + string(axis) + "\n"
+}
+
+// MarkdownTable 渲染 Markdown 表格，末行为平均值
+func (r *SimulationResult) MarkdownTable() string {
+	var sb strings.Builder
+	sb.WriteString("| 进程 | 到达时间 | 运行时间 | 优先级 | 完成时间 | 周转时间 | 带权周转时间 | 等待时间 | 响应时间 |\n")
+	sb.WriteString("|------|---------:|---------:|-------:|---------:|---------:|-------------:|---------:|---------:|\n")
+	for _, m := range r.Processes {
+		sb.WriteString(fmt.Sprintf("| P%d | %d | %d | %d | %d | %d | %.2f | %d | %d |\n",
+			m.PID, m.ArrivalTime, m.BurstTime, m.Priority, m.CompletionTime,
+			m.TurnaroundTime, m.WeightedTurnaround, m.WaitTime, m.ResponseTime))
+	}
+	sb.WriteString(fmt.Sprintf("| 平均 | | | | | %.2f | %.2f | %.2f | %.2f |\n",
+		r.AverageTurnaround(), r.AverageWeightedTurnaround(), r.AverageWait(), r.AverageResponse()))
+	return sb.String()
+}
+
+// CSV 渲染 CSV（表头为英文，便于导入表格软件或脚本）
+func (r *SimulationResult) CSV() string {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"pid", "arrival", "burst", "priority", "completion",
+		"turnaround", "weighted_turnaround", "wait", "response"})
+	for _, m := range r.Processes {
+		w.Write([]string{
+			strconv.Itoa(m.PID),
+			strconv.Itoa(m.ArrivalTime),
+			strconv.Itoa(m.BurstTime),
+			strconv.Itoa(m.Priority),
+			strconv.Itoa(m.CompletionTime),
+			strconv.Itoa(m.TurnaroundTime),
+			strconv.FormatFloat(m.WeightedTurnaround, 'f', 2, 64),
+			strconv.Itoa(m.WaitTime),
+			strconv.Itoa(m.ResponseTime),
+		})
+	}
+	w.Flush()
+	return buf.String()
+}
+
+// SegmentsCSV 渲染执行片段的 CSV
+func (r *SimulationResult) SegmentsCSV() string {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"label", "start", "end"})
+	for _, seg := range r.Segments {
+		w.Write([]string{seg.Label(), strconv.Itoa(seg.Start), strconv.Itoa(seg.End)})
+	}
+	w.Flush()
+	return buf.String()
+}
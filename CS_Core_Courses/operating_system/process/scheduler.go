@@ -3,6 +3,8 @@ package process
 import (
 	"fmt"
 	"sort"
+
+	"CS_Core_Courses/trace"
 )
 
 // Scheduler 调度器接口
@@ -152,17 +154,16 @@ func (s *PriorityScheduler) RemoveProcess(pid int) bool {
 }
 
 // RRScheduler 时间片轮转调度器
+// 就绪队列为 FIFO：NextProcess 取出队首，时间片用完未结束的进程由模拟器重新放回队尾
 type RRScheduler struct {
-	queue      []*ProcessControlBlock
-	timeSlice  int
-	currentPos int
+	queue     []*ProcessControlBlock
+	timeSlice int
 }
 
 func NewRRScheduler(timeSlice int) *RRScheduler {
 	return &RRScheduler{
-		queue:      make([]*ProcessControlBlock, 0),
-		timeSlice:  timeSlice,
-		currentPos: 0,
+		queue:     make([]*ProcessControlBlock, 0),
+		timeSlice: timeSlice,
 	}
 }
 
@@ -175,19 +176,8 @@ func (s *RRScheduler) NextProcess() *ProcessControlBlock {
 		return nil
 	}
 
-	// 轮转到下一个位置
-	if s.currentPos >= len(s.queue) {
-		s.currentPos = 0
-	}
-
-	process := s.queue[s.currentPos]
-	s.currentPos++
-
-	// 如果是最后一个位置，重置
-	if s.currentPos >= len(s.queue) {
-		s.currentPos = 0
-	}
-
+	process := s.queue[0]
+	s.queue = s.queue[1:]
 	return process
 }
 
@@ -199,31 +189,108 @@ func (s *RRScheduler) RemoveProcess(pid int) bool {
 	for i, proc := range s.queue {
 		if proc.PID == pid {
 			s.queue = append(s.queue[:i], s.queue[i+1:]...)
-			// 如果删除的当前位置之前的进程，需要调整位置
-			if i < s.currentPos {
-				s.currentPos--
-			}
-			if s.currentPos < 0 {
-				s.currentPos = 0
-			}
 			return true
 		}
 	}
 	return false
 }
 
+// TimeSlice 返回时间片长度
+func (s *RRScheduler) TimeSlice() int {
+	return s.timeSlice
+}
+
+// SRTFScheduler 最短剩余时间优先调度器（抢占式 SJF）
+// 新进程到达时，若其剩余时间比正在运行的进程更短则发生抢占
+type SRTFScheduler struct {
+	SJFScheduler
+}
+
+func NewSRTFScheduler() *SRTFScheduler {
+	return &SRTFScheduler{SJFScheduler: *NewSJFScheduler()}
+}
+
+// Preemptive SRTF 是抢占式调度
+func (s *SRTFScheduler) Preemptive() bool {
+	return true
+}
+
+// PreemptivePriorityScheduler 抢占式优先级调度器
+// 新进程到达时，若其优先级更高（数字更小）则抢占正在运行的进程
+type PreemptivePriorityScheduler struct {
+	PriorityScheduler
+}
+
+func NewPreemptivePriorityScheduler() *PreemptivePriorityScheduler {
+	return &PreemptivePriorityScheduler{PriorityScheduler: *NewPriorityScheduler()}
+}
+
+// Preemptive 抢占式优先级是抢占式调度
+func (s *PreemptivePriorityScheduler) Preemptive() bool {
+	return true
+}
+
+// TimeSliced 按时间片运行的调度器（如 RR）
+type TimeSliced interface {
+	TimeSlice() int
+}
+
+// Preemptor 抢占式调度器：新进程进入就绪队列时重新选择运行进程
+type Preemptor interface {
+	Preemptive() bool
+}
+
+// isPreemptive 判断调度器是否为抢占式
+func isPreemptive(s Scheduler) bool {
+	p, ok := s.(Preemptor)
+	return ok && p.Preemptive()
+}
+
+// Clocked 需要感知当前时间的调度器
+// 模拟器在每次选择进程前调用 Tick（如 HRRN 计算响应比、MLFQ 周期性提升优先级）
+type Clocked interface {
+	Tick(now int)
+}
+
+// FeedbackScheduler 根据进程运行情况调整其所在队列的调度器（如多级反馈队列）
+type FeedbackScheduler interface {
+	// QuantumFor 返回进程在当前队列中的时间片
+	QuantumFor(process *ProcessControlBlock) int
+	// SliceExpired 进程用完时间片仍未结束时调用，由调度器决定放入哪个队列
+	SliceExpired(process *ProcessControlBlock)
+	// ShouldPreempt 有新进程就绪时判断是否抢占正在运行的进程
+	ShouldPreempt(running *ProcessControlBlock) bool
+	// NextBoost 返回下一次优先级提升的时刻，-1 表示不提升
+	NextBoost() int
+}
+
+// timeSliceFor 返回进程本次运行的时间片，非时间片调度返回 0
+func timeSliceFor(s Scheduler, process *ProcessControlBlock) int {
+	if fb, ok := s.(FeedbackScheduler); ok {
+		return fb.QuantumFor(process)
+	}
+	if ts, ok := s.(TimeSliced); ok && ts.TimeSlice() > 0 {
+		return ts.TimeSlice()
+	}
+	return 0
+}
+
 // Simulation 调度模拟器
+// 事件驱动：时间直接推进到下一个事件（进程到达、进程完成、时间片用完、优先级提升），
+// 进程在到达时刻才进入调度器的就绪队列
 type Simulation struct {
 	processes []*ProcessControlBlock
 	scheduler Scheduler
 	time      int
+	segments  []Segment    // 执行片段（甘特图）
+	Tracer    trace.Tracer // 事件输出（nil 时使用默认控制台）
 }
 
 // NewSimulation 创建调度模拟
+// 进程的运行时统计会被重置，同一组进程可以依次交给不同的调度器模拟
 func NewSimulation(scheduler Scheduler, processes []*ProcessControlBlock) *Simulation {
-	// 将所有进程加入调度器
 	for _, proc := range processes {
-		scheduler.AddProcess(proc)
+		proc.Reset()
 	}
 
 	return &Simulation{
@@ -233,50 +300,238 @@ func NewSimulation(scheduler Scheduler, processes []*ProcessControlBlock) *Simul
 	}
 }
 
+// Time 返回当前模拟时间
+func (sim *Simulation) Time() int {
+	return sim.time
+}
+
+// tick 把当前时间通知给需要感知时间的调度器
+func (sim *Simulation) tick() {
+	if c, ok := sim.scheduler.(Clocked); ok {
+		c.Tick(sim.time)
+	}
+}
+
+// emit 发送调度事件
+func (sim *Simulation) emit(kind, message string, fields map[string]any) {
+	trace.Emit(sim.Tracer, trace.Event{
+		Cycle:     sim.time,
+		Component: "scheduler",
+		Kind:      kind,
+		Message:   message,
+		Fields:    fields,
+	})
+}
+
 // Run 运行模拟
-func (sim *Simulation) Run() {
-	fmt.Printf("=== 开始调度模拟 (时间: %d) ===\n", sim.time)
+// 408 考点：按到达时间入队，抢占式调度在新进程到达时重新选择，
+// 结束后每个 PCB 上记录完成时间、周转时间、等待时间和响应时间，
+// 返回值包含甘特图片段和各进程指标
+func (sim *Simulation) Run() *SimulationResult {
+	sim.segments = sim.segments[:0]
+	sim.emit("start", fmt.Sprintf("=== 开始调度模拟 (时间: %d) ===", sim.time), nil)
+
+	// 按到达时间排序的待到达进程（稳定排序保持输入顺序）
+	pending := make([]*ProcessControlBlock, len(sim.processes))
+	copy(pending, sim.processes)
+	sort.SliceStable(pending, func(i, j int) bool {
+		return pending[i].ArrivalTime < pending[j].ArrivalTime
+	})
+
+	preemptive := isPreemptive(sim.scheduler)
+	feedback, _ := sim.scheduler.(FeedbackScheduler)
+
+	// admit 把到达时间不晚于当前时间的进程放入就绪队列
+	admit := func() bool {
+		arrived := false
+		for len(pending) > 0 && pending[0].ArrivalTime <= sim.time {
+			proc := pending[0]
+			pending = pending[1:]
+			proc.State = StateReady
+			sim.scheduler.AddProcess(proc)
+			sim.emit("arrive", "", map[string]any{"pid": proc.PID})
+			arrived = true
+		}
+		return arrived
+	}
 
-	for sim.scheduler.HasNext() {
-		process := sim.scheduler.NextProcess()
+	var running *ProcessControlBlock
+	sliceUsed := 0 // 当前进程在本时间片内已运行的时间
+	completed := 0
 
-		if process == nil {
-			sim.time++
-			continue
+	for completed < len(sim.processes) {
+		sim.tick()
+		admit()
+
+		if running == nil {
+			if !sim.scheduler.HasNext() {
+				// CPU 空闲，直接跳到下一个进程到达
+				if len(pending) == 0 {
+					break
+				}
+				sim.emit("idle", fmt.Sprintf("时间 %d: CPU 空闲", sim.time),
+					map[string]any{"until": pending[0].ArrivalTime})
+				sim.record(Segment{Start: sim.time, End: pending[0].ArrivalTime, Idle: true})
+				sim.time = pending[0].ArrivalTime
+				continue
+			}
+
+			running = sim.scheduler.NextProcess()
+			sim.dispatch(running)
+			sliceUsed = 0
 		}
 
-		fmt.Printf("时间 %d: 调度进程 PID:%d (剩余时间: %d)\n",
-			sim.time, process.PID, process.RemainingTime)
+		// 计算下一个事件发生的时刻
+		timeSlice := timeSliceFor(sim.scheduler, running)
+		next := sim.time + running.RemainingTime
+		if timeSlice > 0 && sim.time+timeSlice-sliceUsed < next {
+			next = sim.time + max(timeSlice-sliceUsed, 0)
+		}
+		if len(pending) > 0 && pending[0].ArrivalTime < next {
+			next = pending[0].ArrivalTime
+		}
+		boost := -1
+		if feedback != nil {
+			if boost = feedback.NextBoost(); boost > sim.time && boost < next {
+				next = boost
+			}
+		}
 
-		// 设置进程为运行状态
-		process.State = StateRunning
-		process.WaitTime = sim.time - process.ArrivalTime
+		// 运行到下一个事件
+		ran := next - sim.time
+		sim.record(Segment{PID: running.PID, Start: sim.time, End: next})
+		running.RemainingTime -= ran
+		sliceUsed += ran
+		sim.time = next
+		sim.tick()
+
+		if sim.time == boost {
+			// 优先级提升后正在运行的进程也回到最高级队列，重新开始计时
+			sliceUsed = 0
+			sim.emit("boost", fmt.Sprintf("时间 %d: 优先级提升，所有进程回到最高级队列", sim.time), nil)
+		}
 
-		// 根据调度器类型执行
-		switch scheduler := sim.scheduler.(type) {
-		case *RRScheduler:
-			process.Execute(scheduler.timeSlice)
-		default:
-			// FCFS, SJF, Priority 执行到完成
-			process.Execute(process.RemainingTime)
+		if running.IsCompleted() {
+			sim.complete(running)
+			running = nil
+			completed++
+			continue
 		}
 
-		if process.IsCompleted() {
-			fmt.Printf("进程 PID:%d 完成\n", process.PID)
-		} else {
-			// 对于时间片轮转，如果进程未完成，重新加入队列
-			if rrScheduler, ok := sim.scheduler.(*RRScheduler); ok {
-				if !process.IsCompleted() {
-					process.State = StateReady
-					rrScheduler.AddProcess(process)
-				}
+		if timeSlice > 0 && sliceUsed >= timeSlice {
+			// 时间片用完：同一时刻到达的新进程先入队，被剥夺的进程排在其后
+			admit()
+			sim.emit("slice_expire", fmt.Sprintf("时间 %d: 进程 PID:%d 时间片用完 (剩余时间: %d)",
+				sim.time, running.PID, running.RemainingTime),
+				map[string]any{"pid": running.PID, "remaining": running.RemainingTime})
+			running.State = StateReady
+			if feedback != nil {
+				feedback.SliceExpired(running)
+			} else {
+				sim.scheduler.AddProcess(running)
 			}
+			running = nil
+			continue
 		}
 
-		sim.time++
+		// 新进程到达：抢占式调度器重新选择
+		if admit() && preemptive {
+			if feedback != nil && !feedback.ShouldPreempt(running) {
+				continue
+			}
+			running.State = StateReady
+			sim.scheduler.AddProcess(running)
+			candidate := sim.scheduler.NextProcess()
+			if candidate == running {
+				running.State = StateRunning
+				continue
+			}
+			sim.emit("preempt", fmt.Sprintf("时间 %d: 进程 PID:%d 被 PID:%d 抢占 (剩余时间: %d)",
+				sim.time, running.PID, candidate.PID, running.RemainingTime),
+				map[string]any{"pid": running.PID, "by": candidate.PID, "remaining": running.RemainingTime})
+			running = candidate
+			sim.dispatch(running)
+			sliceUsed = 0
+		}
 	}
 
-	fmt.Printf("=== 调度模拟结束 (总时间: %d) ===\n", sim.time)
+	sim.emit("end", fmt.Sprintf("=== 调度模拟结束 (总时间: %d) ===", sim.time), nil)
+	return sim.result()
+}
+
+// record 记录执行片段，与上一段首尾相接的同一进程片段会合并
+func (sim *Simulation) record(seg Segment) {
+	if seg.End <= seg.Start {
+		return
+	}
+	if n := len(sim.segments); n > 0 {
+		last := &sim.segments[n-1]
+		if last.End == seg.Start && last.Idle == seg.Idle && last.PID == seg.PID {
+			last.End = seg.End
+			return
+		}
+	}
+	sim.segments = append(sim.segments, seg)
+}
+
+// result 汇总模拟结果
+func (sim *Simulation) result() *SimulationResult {
+	result := &SimulationResult{
+		Segments:  make([]Segment, len(sim.segments)),
+		Processes: make([]ProcessMetrics, 0, len(sim.processes)),
+		TotalTime: sim.time,
+	}
+	copy(result.Segments, sim.segments)
+
+	for _, proc := range sim.processes {
+		weighted := 0.0
+		if proc.BurstTime > 0 {
+			weighted = float64(proc.TurnaroundTime) / float64(proc.BurstTime)
+		}
+		result.Processes = append(result.Processes, ProcessMetrics{
+			PID:                proc.PID,
+			ArrivalTime:        proc.ArrivalTime,
+			BurstTime:          proc.BurstTime,
+			Priority:           proc.Priority,
+			CompletionTime:     proc.CompletionTime,
+			TurnaroundTime:     proc.TurnaroundTime,
+			WeightedTurnaround: weighted,
+			WaitTime:           proc.WaitTime,
+			ResponseTime:       proc.ResponseTime,
+		})
+	}
+	return result
+}
+
+// dispatch 把进程调度到 CPU 上运行
+func (sim *Simulation) dispatch(process *ProcessControlBlock) {
+	process.State = StateRunning
+	process.ContextSwitches++
+	if process.ResponseTime == -1 {
+		// 响应时间 = 首次运行时刻 - 到达时刻
+		process.ResponseTime = sim.time - process.ArrivalTime
+	}
+
+	sim.emit("dispatch", fmt.Sprintf("时间 %d: 调度进程 PID:%d (剩余时间: %d)",
+		sim.time, process.PID, process.RemainingTime),
+		map[string]any{"pid": process.PID, "remaining": process.RemainingTime})
+}
+
+// complete 记录进程完成时的各项时间
+// 408 考点：周转时间 = 完成时间 - 到达时间；等待时间 = 周转时间 - 运行时间
+func (sim *Simulation) complete(process *ProcessControlBlock) {
+	process.State = StateTerminated
+	process.CompletionTime = sim.time
+	process.TurnaroundTime = sim.time - process.ArrivalTime
+	process.WaitTime = process.TurnaroundTime - process.BurstTime
+
+	sim.emit("complete", fmt.Sprintf("时间 %d: 进程 PID:%d 完成", sim.time, process.PID),
+		map[string]any{
+			"pid":        process.PID,
+			"turnaround": process.TurnaroundTime,
+			"wait":       process.WaitTime,
+			"response":   process.ResponseTime,
+		})
 }
 
 // 示例函数
@@ -291,56 +546,48 @@ func SchedulerExample() {
 		NewProcess(4, 0, 1, 3, 6),  // PID=4, 优先级=1, 执行时间=3, 到达时间=6
 	}
 
-	// FCFS调度
-	fmt.Println("\n--- FCFS 调度 ---")
-	fcfsScheduler := NewFCFSScheduler()
-	fcfsSimulation := NewSimulation(fcfsScheduler, processes)
-	fcfsSimulation.Run()
-
-	// 重置进程状态
-	for _, proc := range processes {
-		proc.RemainingTime = proc.BurstTime
-		proc.State = StateReady
-		proc.WaitTime = 0
-		proc.TurnaroundTime = 0
-		proc.ResponseTime = -1
+	// NewSimulation 会重置进程状态，同一组进程可依次用于各个调度算法
+	runs := []struct {
+		title     string
+		scheduler Scheduler
+	}{
+		{"FCFS 调度", NewFCFSScheduler()},
+		{"SJF 调度（非抢占）", NewSJFScheduler()},
+		{"SRTF 调度（抢占式 SJF）", NewSRTFScheduler()},
+		{"Priority 调度（非抢占）", NewPriorityScheduler()},
+		{"Priority 调度（抢占式）", NewPreemptivePriorityScheduler()},
+		{"RR 调度 (时间片=3)", NewRRScheduler(3)},
+		{"HRRN 调度（高响应比优先）", NewHRRNScheduler()},
+		{"MLFQ 调度 (3 级, 时间片 2/4/8, 抢占式)", NewMLFQScheduler(MLFQConfig{
+			Levels:     3,
+			TimeSlices: []int{2, 4, 8},
+			Preemptive: true,
+		})},
+		{"MLFQ 调度 (每 12 个时间单位提升优先级)", NewMLFQScheduler(MLFQConfig{
+			Levels:        3,
+			TimeSlices:    []int{2, 4, 8},
+			BoostInterval: 12,
+			Preemptive:    true,
+		})},
 	}
 
-	// SJF调度
-	fmt.Println("\n--- SJF 调度 ---")
-	sjfScheduler := NewSJFScheduler()
-	sjfSimulation := NewSimulation(sjfScheduler, processes)
-	sjfSimulation.Run()
+	for _, r := range runs {
+		fmt.Printf("\n--- %s ---\n", r.title)
+		simulation := NewSimulation(r.scheduler, processes)
+		result := simulation.Run()
 
-	// 重置进程状态
-	for _, proc := range processes {
-		proc.RemainingTime = proc.BurstTime
-		proc.State = StateReady
-		proc.WaitTime = 0
-		proc.TurnaroundTime = 0
-		proc.ResponseTime = -1
+		fmt.Println("\n甘特图：")
+		fmt.Print(result.GanttChart())
+		fmt.Println()
+		fmt.Print(result.MarkdownTable())
+		fmt.Printf("CPU 利用率: %.2f%%\n", result.CPUUtilization())
 	}
 
-	// Priority调度
-	fmt.Println("\n--- Priority 调度 ---")
-	priorityScheduler := NewPriorityScheduler()
-	prioritySimulation := NewSimulation(priorityScheduler, processes)
-	prioritySimulation.Run()
-
-	// 重置进程状态
-	for _, proc := range processes {
-		proc.RemainingTime = proc.BurstTime
-		proc.State = StateReady
-		proc.WaitTime = 0
-		proc.TurnaroundTime = 0
-		proc.ResponseTime = -1
-	}
-
-	// RR调度
-	fmt.Println("\n--- RR 调度 (时间片=3) ---")
-	rrScheduler := NewRRScheduler(3)
-	rrSimulation := NewSimulation(rrScheduler, processes)
-	rrSimulation.Run()
+	// 导出 CSV，便于和手算结果对照
+	fmt.Println("\n--- SRTF 调度结果 (CSV) ---")
+	csvSimulation := NewSimulation(NewSRTFScheduler(), processes)
+	csvSimulation.Tracer = trace.Discard // 过程已在上面输出过，这里只要结果
+	fmt.Print(csvSimulation.Run().CSV())
 
 	fmt.Println()
-}
\ No newline at end of file
+}
@@ -1,6 +1,23 @@
 package scheduling
 
-import "fmt"
+import (
+	"fmt"
+
+	"CS_Core_Courses/registry"
+)
+
+func init() {
+	registry.Register(&registry.Module{
+		Name:    "os/scheduling",
+		Section: "2.4",
+		Title:   "磁盘调度与死锁",
+		Run:     RunAllSchedulingExamples,
+		Examples: []registry.Example{
+			{Name: "disk", Run: DiskSchedulerExample},
+			{Name: "deadlock", Run: DeadlockExample},
+		},
+	})
+}
 
 // RunAllSchedulingExamples 运行所有调度相关的示例
 func RunAllSchedulingExamples() {
@@ -17,5 +34,5 @@ func RunAllSchedulingExamples() {
 
 	fmt.Println("\n╔══════════════════════════════════════════════════════════╗")
 	fmt.Println("║                    模块运行完毕                           ║")
-	fmt.Println("╚══════════════════════════════════════════════════════════╝\n")
+	fmt.Print("╚══════════════════════════════════════════════════════════╝\n\n")
 }
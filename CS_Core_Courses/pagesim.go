@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"CS_Core_Courses/computer_architecture/memory"
+)
+
+// pagePolicies 命令行中页面替换算法的名称
+var pagePolicies = map[string]memory.PageReplacementPolicy{
+	"fifo":    memory.PageFIFO,
+	"lru":     memory.PageLRU,
+	"clock":   memory.PageClock,
+	"opt":     memory.PageOptimal,
+	"eclock":  memory.PageEnhancedClock,
+	"lfu":     memory.PageLFU,
+	"aging":   memory.PageAging,
+	"wsclock": memory.PageWSClock,
+}
+
+// cmdPageSim 对页面引用串比较各页面替换算法，并检测 Belady 异常
+func cmdPageSim(args []string) int {
+	fs := flag.NewFlagSet("pagesim", flag.ContinueOnError)
+	frameRange := fs.String("frames", "1-5", "页框数范围，如 3 或 1-6")
+	policyList := fs.String("policy", "", "只运行指定算法，逗号分隔（fifo、lru、clock、opt、eclock、lfu、aging、wsclock），默认全部")
+	window := fs.Int("window", memory.DefaultWorkingSetWindow, "WSClock 的工作集窗口 Δ")
+	agingInterval := fs.Int("aging", memory.DefaultAgingInterval, "老化算法的时钟中断间隔（访问次数）")
+	show := fs.Int("show", 0, "打印该页框数下各算法的驻留矩阵（0 为不打印）")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "用法: %s pagesim [选项] <页面引用串>\n\n", programName)
+		fmt.Fprintln(os.Stderr, "引用串中的页号用空格或逗号分隔，如 \"1 2 3 4 1 2 5 1 2 3 4 5\"；页号后加 w 表示写访问，如 \"1 2w 3\"")
+		fmt.Fprintln(os.Stderr, "\n选项:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	references, writes, err := memory.ParseReferenceStringRW(strings.Join(fs.Args(), " "))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "错误:", err)
+		return exitUsage
+	}
+	minFrames, maxFrames, err := parseFrameRange(*frameRange)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "错误:", err)
+		return exitUsage
+	}
+	var policies []memory.PageReplacementPolicy
+	if *policyList != "" {
+		for _, name := range strings.Split(*policyList, ",") {
+			p, ok := pagePolicies[strings.ToLower(strings.TrimSpace(name))]
+			if !ok {
+				fmt.Fprintf(os.Stderr, "未知的页面替换算法 %q\n", name)
+				return exitUsage
+			}
+			policies = append(policies, p)
+		}
+	}
+
+	opts := memory.ReplacementOptions{Writes: writes, WorkingSetWindow: *window, AgingInterval: *agingInterval}
+	c, err := memory.CompareReplacementWith(references, minFrames, maxFrames, opts, policies...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "错误:", err)
+		return exitUsage
+	}
+	c.PrintFaultTable()
+	if *show > 0 {
+		for _, p := range c.Policies {
+			c.PrintResidency(p, *show)
+		}
+	}
+	if n := len(c.Anomalies()); n > 0 {
+		fmt.Printf("\n检测到 %d 处 Belady 异常（见上表标注）\n", n)
+	} else {
+		fmt.Println("\n未出现 Belady 异常")
+	}
+	return exitOK
+}
+
+// parseFrameRange 解析 "3" 或 "1-6" 形式的页框数范围
+func parseFrameRange(s string) (int, int, error) {
+	lo, hi, found := strings.Cut(s, "-")
+	min, err := strconv.Atoi(strings.TrimSpace(lo))
+	if err != nil {
+		return 0, 0, fmt.Errorf("无效的页框数范围 %q", s)
+	}
+	max := min
+	if found {
+		if max, err = strconv.Atoi(strings.TrimSpace(hi)); err != nil {
+			return 0, 0, fmt.Errorf("无效的页框数范围 %q", s)
+		}
+	}
+	return min, max, nil
+}
@@ -0,0 +1,155 @@
+package registry
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Example 模块内一个可单独运行的示例
+type Example struct {
+	Name string // 示例名称（用于 --only 选择）
+	Run  func() // 示例入口函数
+}
+
+// Module 可被命令行发现并运行的学习模块
+// 各模块在自己包的 init() 中调用 Register 注册
+type Module struct {
+	Name     string    // 模块标识，如 "os/memory"、"arch/pipeline"
+	Section  string    // 章节编号，如 "2.2"，决定列表与运行顺序
+	Title    string    // 模块中文标题
+	Run      func()    // 运行整个模块（RunAllXxxExamples）
+	Examples []Example // 可单独选择的示例
+}
+
+// ShortName 返回模块标识的最后一段，如 "os/memory" → "memory"
+func (m *Module) ShortName() string {
+	if idx := strings.LastIndex(m.Name, "/"); idx >= 0 {
+		return m.Name[idx+1:]
+	}
+	return m.Name
+}
+
+// Example 按名称查找示例
+func (m *Module) Example(name string) (Example, bool) {
+	for _, ex := range m.Examples {
+		if ex.Name == name {
+			return ex, true
+		}
+	}
+	return Example{}, false
+}
+
+// ExampleNames 返回所有示例名称
+func (m *Module) ExampleNames() []string {
+	names := make([]string, len(m.Examples))
+	for i, ex := range m.Examples {
+		names[i] = ex.Name
+	}
+	return names
+}
+
+var modules = make(map[string]*Module)
+
+// Register 注册模块，重复注册同名模块会 panic（属于编程错误）
+func Register(m *Module) {
+	if m == nil || m.Name == "" || m.Run == nil {
+		panic("registry: 模块必须提供 Name 和 Run")
+	}
+	if _, exists := modules[m.Name]; exists {
+		panic(fmt.Sprintf("registry: 模块 %q 重复注册", m.Name))
+	}
+	modules[m.Name] = m
+}
+
+// Modules 返回按章节编号排序的全部模块
+func Modules() []*Module {
+	list := make([]*Module, 0, len(modules))
+	for _, m := range modules {
+		list = append(list, m)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return lessSection(list[i].Section, list[j].Section)
+	})
+	return list
+}
+
+// lessSection 按数字逐段比较章节编号，保证 "1.10" 排在 "1.9" 之后
+func lessSection(a, b string) bool {
+	pa := strings.Split(a, ".")
+	pb := strings.Split(b, ".")
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		var na, nb int
+		fmt.Sscanf(pa[i], "%d", &na)
+		fmt.Sscanf(pb[i], "%d", &nb)
+		if na != nb {
+			return na < nb
+		}
+	}
+	return len(pa) < len(pb)
+}
+
+// Lookup 根据完整标识或唯一的短名称查找模块
+// 例如 "os/memory" 精确匹配；"pipeline" 仅当只有一个模块以它结尾时匹配
+func Lookup(name string) (*Module, error) {
+	if m, ok := modules[name]; ok {
+		return m, nil
+	}
+
+	var matches []*Module
+	for _, m := range Modules() {
+		if m.ShortName() == name {
+			matches = append(matches, m)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("未知模块 %q（使用 list 查看可用模块）", name)
+	case 1:
+		return matches[0], nil
+	default:
+		candidates := make([]string, len(matches))
+		for i, m := range matches {
+			candidates[i] = m.Name
+		}
+		return nil, fmt.Errorf("模块名 %q 有歧义，可能是: %s", name, strings.Join(candidates, ", "))
+	}
+}
+
+// RunModule 运行模块；only 非空时只运行指定名称的示例
+// 示例中的 panic 会被捕获并作为错误返回
+func RunModule(m *Module, only []string) error {
+	if len(only) == 0 {
+		return safeRun(m.Name, m.Run)
+	}
+
+	// 先校验所有名称，避免运行到一半才发现拼写错误
+	selected := make([]Example, 0, len(only))
+	for _, name := range only {
+		ex, ok := m.Example(name)
+		if !ok {
+			return fmt.Errorf("模块 %s 没有示例 %q（可选: %s）",
+				m.Name, name, strings.Join(m.ExampleNames(), ", "))
+		}
+		selected = append(selected, ex)
+	}
+
+	for _, ex := range selected {
+		if err := safeRun(m.Name+"/"+ex.Name, ex.Run); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// safeRun 运行函数并把 panic 转换为错误
+func safeRun(name string, fn func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%s 运行失败: %v", name, r)
+		}
+	}()
+	fn()
+	return nil
+}
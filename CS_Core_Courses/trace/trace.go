@@ -0,0 +1,203 @@
+package trace
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Event 模拟器产生的一条结构化事件
+// Message 是给人看的中文描述（控制台输出用），Fields 保存可断言的结构化数据
+type Event struct {
+	Timestamp time.Time      `json:"timestamp"`         // 墙上时钟时间（Emit 时自动填写）
+	Cycle     int            `json:"cycle"`             // 模拟器内部的逻辑时间：时钟周期、调度时刻、尝试次数等
+	Component string         `json:"component"`         // 产生事件的组件，如 "pipeline"、"cache"
+	Kind      string         `json:"kind"`              // 事件类型，如 "stall"、"hit"、"dispatch"
+	Message   string         `json:"message,omitempty"` // 控制台文本，为空时控制台不输出
+	Fields    map[string]any `json:"fields,omitempty"`  // 结构化字段
+}
+
+// Tracer 事件接收器，各模拟器通过它输出执行过程
+type Tracer interface {
+	Emit(e Event)
+}
+
+// Emit 向 tracer 发送事件；tracer 为 nil 时使用默认的控制台输出
+// 模拟器统一通过此函数发送事件，保证未注入 Tracer 时行为与原来一致
+func Emit(t Tracer, e Event) {
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now()
+	}
+	if t == nil {
+		t = Default()
+	}
+	t.Emit(e)
+}
+
+// ============================
+// 控制台输出
+// ============================
+
+// Console 把事件的 Message 原样写入 io.Writer，保留原先的中文控制台输出
+type Console struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewConsole 创建控制台输出
+func NewConsole(w io.Writer) *Console {
+	return &Console{w: w}
+}
+
+// Emit 输出一行消息，Message 为空的事件只用于记录，不输出
+func (c *Console) Emit(e Event) {
+	if e.Message == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintln(c.w, e.Message)
+}
+
+var (
+	defaultMu     sync.RWMutex
+	defaultTracer Tracer = NewConsole(os.Stdout)
+)
+
+// Default 返回默认 Tracer（标准输出控制台）
+func Default() Tracer {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultTracer
+}
+
+// SetDefault 替换默认 Tracer，返回原来的 Tracer 以便恢复
+// 传入 nil 恢复为标准输出控制台
+func SetDefault(t Tracer) Tracer {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	old := defaultTracer
+	if t == nil {
+		t = NewConsole(os.Stdout)
+	}
+	defaultTracer = t
+	return old
+}
+
+// ============================
+// 内存记录
+// ============================
+
+// Recorder 把事件保存在内存中，便于检查和断言模拟器行为
+type Recorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+// NewRecorder 创建内存记录器
+func NewRecorder() *Recorder {
+	return &Recorder{events: make([]Event, 0)}
+}
+
+// Emit 记录事件
+func (r *Recorder) Emit(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+}
+
+// Events 返回已记录事件的副本
+func (r *Recorder) Events() []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	events := make([]Event, len(r.events))
+	copy(events, r.events)
+	return events
+}
+
+// Filter 返回指定组件和类型的事件，参数为空字符串表示不限制
+func (r *Recorder) Filter(component, kind string) []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var matched []Event
+	for _, e := range r.events {
+		if (component == "" || e.Component == component) && (kind == "" || e.Kind == kind) {
+			matched = append(matched, e)
+		}
+	}
+	return matched
+}
+
+// Count 统计指定组件和类型的事件数
+func (r *Recorder) Count(component, kind string) int {
+	return len(r.Filter(component, kind))
+}
+
+// Reset 清空记录
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = r.events[:0]
+}
+
+// ============================
+// JSON Lines 输出
+// ============================
+
+// JSONLines 每个事件输出一行 JSON，便于用脚本分析
+type JSONLines struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+// NewJSONLines 创建 JSON Lines 输出
+func NewJSONLines(w io.Writer) *JSONLines {
+	return &JSONLines{enc: json.NewEncoder(w)}
+}
+
+// Emit 编码并写出事件，遇到第一个写入错误后停止写出
+func (j *JSONLines) Emit(e Event) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.err != nil {
+		return
+	}
+	j.err = j.enc.Encode(e)
+}
+
+// Err 返回写出过程中遇到的第一个错误
+func (j *JSONLines) Err() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.err
+}
+
+// ============================
+// 组合
+// ============================
+
+// multi 把事件分发给多个 Tracer
+type multi []Tracer
+
+func (m multi) Emit(e Event) {
+	for _, t := range m {
+		t.Emit(e)
+	}
+}
+
+// Multi 返回同时输出到多个 Tracer 的 Tracer
+func Multi(tracers ...Tracer) Tracer {
+	return multi(tracers)
+}
+
+// nop 丢弃所有事件
+type nop struct{}
+
+func (nop) Emit(Event) {}
+
+// Discard 丢弃所有事件，用于静默运行模拟器
+var Discard Tracer = nop{}